@@ -1,12 +1,19 @@
 package main
 
 import (
+	"os"
+
 	"jpcorrect-backend/internal/cmd"
 
 	_ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cmd.Migrate(os.Args[2:])
+		return
+	}
+
 	// cmd.TestConnection()
 	cmd.Execute()
 }
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"log"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -129,6 +130,11 @@ func main() {
 		proxyWebSocket(w, r, apiPort)
 	})
 
+	// ICE 伺服器 (STUN/TURN 憑證) 代理到主 API 服務器
+	http.HandleFunc("/v1/webrtc/ice-servers", func(w http.ResponseWriter, r *http.Request) {
+		proxyAPI(w, r, apiPort)
+	})
+
 	webPort := os.Getenv("WEBRTC_DEMO_PORT")
 	if webPort == "" {
 		webPort = "3000"
@@ -156,6 +162,21 @@ func main() {
 	}
 }
 
+// proxyAPI 將 HTTP 請求轉發到主 API 服務器
+func proxyAPI(w http.ResponseWriter, r *http.Request, apiPort string) {
+	scheme := "http"
+	if proxyWebSocketUseHTTPS {
+		scheme = "https"
+	}
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: scheme, Host: "localhost:" + apiPort})
+	proxy.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // 開發環境跳過證書驗證
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
 // proxyWebSocket 使用 gorilla/websocket 將連接代理到主 API 服務器
 func proxyWebSocket(w http.ResponseWriter, r *http.Request, apiPort string) {
 	// 升級客戶端連接為 WebSocket
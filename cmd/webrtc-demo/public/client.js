@@ -1,9 +1,15 @@
+// 信令協定版本，見 docs/signaling.schema.json
+const PROTOCOL_VERSION = 1;
+
 /* WebSocket compatibility wrapper using JSON messages */
 function createSocket() {
     let ws;
     const handlers = new Map();
     let connected = false;
     let id = null;
+    // 斷線後在寬限時間內帶 resumeToken 重連，可保留同一個 id 與房間
+    let resumeToken = null;
+    let resumed = false;
 
     let reconnectAttempts = 0;
     let reconnectTimer = null;
@@ -50,9 +56,10 @@ function createSocket() {
         }
 
         ws.addEventListener('open', () => {
-            // connected will be confirmed when server sends 'connected' message with id
+            // 第一則訊息必須是 auth；connected will be confirmed when server sends 'connected' message with id
             console.log('WebSocket open');
             isReconnecting = false;
+            ws.send(JSON.stringify({ v: PROTOCOL_VERSION, type: 'auth', payload: { token: authToken, resume: resumeToken || undefined } }));
         });
 
         ws.addEventListener('message', (ev) => {
@@ -63,6 +70,8 @@ function createSocket() {
 
                 if (type === 'connected') {
                     id = payload.id;
+                    resumeToken = payload.resumeToken;
+                    resumed = !!payload.resumed;
                     connected = true;
                     reconnectAttempts = 0;
                     if (reconnectTimer) { clearTimeout(reconnectTimer); reconnectTimer = null; }
@@ -106,7 +115,7 @@ function createSocket() {
         emit: (event, payload) => {
             try {
                 if (ws && ws.readyState === WebSocket.OPEN) {
-                    ws.send(JSON.stringify({ type: event, payload: payload || null }));
+                    ws.send(JSON.stringify({ v: PROTOCOL_VERSION, type: event, payload: payload || null }));
                 } else {
                     console.warn('WebSocket not open, cannot send', event);
                 }
@@ -117,12 +126,18 @@ function createSocket() {
         connect: () => { if (!connected) connect(); },
         close: () => { intentionallyClosed = true; if (reconnectTimer) clearTimeout(reconnectTimer); if (ws) ws.close(); },
         get connected() { return connected; },
+        get resumed() { return resumed; },
         get id() { return id; }
     };
 }
 
 const socket = createSocket();
 
+// 房間對應到活動 (Event) ID，由網址參數 ?event=<event UUID> 指定
+const eventId = new URLSearchParams(window.location.search).get('event');
+// 連線需要 JWT，由網址參數 ?token=<JWT> 指定（連線後以 auth 訊息送出）
+const authToken = new URLSearchParams(window.location.search).get('token');
+
 let localStream = null;
 let processedStream = null;
 let peerConnections = new Map();
@@ -134,13 +149,30 @@ let localGainNode = null;
 let remoteAnalysers = new Map();
 let audioGainNodes = new Map();
 
-// ICE 伺服器位置
-const iceServers = {
-    iceServers: [
-        { urls: 'stun:stun.l.google.com:19302' },
-        { urls: 'stun:stun1.l.google.com:19302' }
-    ]
-};
+// ICE 伺服器位置；由 API 取得 STUN/TURN 設定，未設定時使用公用 STUN
+const defaultIceServers = [
+    { urls: 'stun:stun.l.google.com:19302' },
+    { urls: 'stun:stun1.l.google.com:19302' }
+];
+let iceServers = { iceServers: defaultIceServers };
+let iceServersExpireAt = 0;
+
+// 取得 ICE 伺服器；TURN 憑證過期前重新取得
+async function refreshIceServers() {
+    if (Date.now() < iceServersExpireAt) return;
+    try {
+        const res = await fetch('/v1/webrtc/ice-servers', { headers: { Authorization: `Bearer ${authToken}` } });
+        if (!res.ok) throw new Error(`HTTP ${res.status}`);
+        const body = await res.json();
+        if (body.ice_servers.length > 0) {
+            iceServers = { iceServers: body.ice_servers };
+        }
+        // 無 TURN 憑證時 10 分鐘後再確認設定
+        iceServersExpireAt = body.expires_at ? Date.parse(body.expires_at) - 60 * 1000 : Date.now() + 10 * 60 * 1000;
+    } catch (e) {
+        console.warn('取得 ICE 伺服器失敗，使用預設 STUN', e);
+    }
+}
 
 // DOM 元素
 const joinBtn = document.getElementById('joinBtn');
@@ -163,7 +195,7 @@ socket.on('connect', () => {
     console.log('✅ 連線到伺服器，ID:', myUserId);
     
     // 自動取得目前線上使用者列表
-    socket.emit('get-online-users');
+    socket.emit('get-online-users', { eventId });
 });
 
 socket.on('connect_error', (error) => {
@@ -179,7 +211,7 @@ socket.on('connect_timeout', () => {
 socket.on('error', (error) => {
     console.error('❌ Socket 錯誤:', error);
     if (error.message) {
-        alert(`伺服器錯誤: ${error.message}`);
+        alert(`伺服器錯誤 (${error.code}): ${error.message}`);
     }
 });
 
@@ -239,10 +271,10 @@ function attemptReconnect() {
             clearInterval(reconnectIntervalId);
             reconnectIntervalId = null;
             
-            // 如果之前已經加入聊天室，重新加入
-            if (myUserName) {
+            // 如果之前已經加入聊天室且連線未能恢復，重新加入
+            if (myUserName && !socket.resumed) {
                 console.log('重新加入聊天室:', myUserName);
-                socket.emit('join-room', { userName: myUserName });
+                socket.emit('join-room', { eventId });
             }
         } else {
             console.log('重新連線中...');
@@ -399,6 +431,9 @@ async function joinChat() {
     const validatedName = validation.name;
 
     try {
+        // 加入前先取得 ICE 伺服器 (TURN 憑證)
+        await refreshIceServers();
+
         // 請求麥克風權限
         localStream = await navigator.mediaDevices.getUserMedia({ 
             audio: true, 
@@ -435,7 +470,7 @@ async function joinChat() {
         console.log('processedStream 已就緒:', !!processedStream);
         
         // 加入房間（觸發伺服器發送當前使用者列表）
-        socket.emit('join-room', { userName: validatedName });
+        socket.emit('join-room', { eventId });
         
         // 更改按鈕為離開狀態
         joinBtn.innerHTML = '<i class="fas fa-times"></i><span>離開通話</span>';
@@ -637,7 +672,7 @@ function leaveChat() {
     }
     
     // 重新取得線上使用者列表，以僅查看模式顯示
-    socket.emit('get-online-users');
+    socket.emit('get-online-users', { eventId });
     
     // 重置按鈕狀態
     joinBtn.innerHTML = '<i class="fas fa-plus"></i><span>加入通話</span>';
@@ -688,6 +723,8 @@ async function createPeerConnection(userId, createOffer = false) {
     }
 
     console.log('建立 peer connection 給:', userId);
+    // 憑證快過期時在背景更新，供之後的連線使用
+    refreshIceServers();
     const peerConnection = new RTCPeerConnection(iceServers);
     peerConnections.set(userId, peerConnection);
 
@@ -918,7 +955,7 @@ window.addEventListener('load', () => {
         updateStatus('已連線到伺服器');
         console.log('✅ Socket 已連線');
         // 自動取得目前線上使用者列表
-        socket.emit('get-online-users');
+        socket.emit('get-online-users', { eventId });
     } else {
         // 還在連線中
         updateStatus('嘗試連線中...');
@@ -972,7 +1009,7 @@ document.addEventListener('visibilitychange', () => {
             
             // 如果已加入聊天室，驗證狀態
             if (myUserName) {
-                socket.emit('get-online-users');
+                socket.emit('get-online-users', { eventId });
             }
         }
     } else {
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// speakingOrderRequest lists the attendees who speak first, in order.
+type speakingOrderRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// respondAgendaError maps errors of the agenda service.
+func respondAgendaError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidAgenda) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// pushAgenda sends the current agenda of the event to everyone in its room
+// as an agenda-updated message. Failures are logged only; clients can still
+// fetch the agenda.
+func (a *API) pushAgenda(ctx context.Context, eventID uuid.UUID) {
+	event, err := a.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		log.Printf("查詢活動失敗 (event: %s): %v", eventID, err)
+		return
+	}
+	agenda, err := a.agenda.Build(ctx, event)
+	if err != nil {
+		log.Printf("產生議程失敗 (event: %s): %v", eventID, err)
+		return
+	}
+	a.webrtcHub.BroadcastToRoom(eventID.String(), "", msgAgendaUpdated, agenda)
+}
+
+// PracticeAgendaHandler returns how the practice runs in its mode.
+func (a *API) PracticeAgendaHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+
+	agenda, err := a.agenda.Build(c.Request.Context(), practice)
+	if err != nil {
+		respondAgendaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, agenda)
+}
+
+// PracticeSpeakingOrderHandler sets who speaks first. Attendees left out
+// speak afterwards, by name.
+func (a *API) PracticeSpeakingOrderHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+
+	var req speakingOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := a.agenda.SetSpeakingOrder(ctx, practice.ID, req.UserIDs); err != nil {
+		respondAgendaError(c, err)
+		return
+	}
+	agenda, err := a.agenda.Build(ctx, practice)
+	if err != nil {
+		respondAgendaError(c, err)
+		return
+	}
+	a.webrtcHub.BroadcastToRoom(practice.ID.String(), "", msgAgendaUpdated, agenda)
+
+	c.JSON(http.StatusOK, agenda)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPracticeAgenda(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+
+	emcee, ann, bob, outsider := newTestClient("emcee"), newTestClient("ann"), newTestClient("bob"), newTestClient("outsider")
+	tokens := map[*domain.Client]string{}
+	for _, c := range []*domain.Client{emcee, ann, bob, outsider} {
+		s.users[c.UserID] = &domain.User{ID: c.UserID, Name: c.Name, Email: c.ID + "@example.com", Role: domain.UserRoleUser}
+		tokens[c] = signTestToken(t, jwt.RegisteredClaims{
+			Subject:   c.UserID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		a.webrtcHub.AddClient(c)
+	}
+
+	start := time.Date(2030, 1, 7, 19, 0, 0, 0, time.UTC)
+	newPractice := func(start time.Time, attendees ...*domain.Client) uuid.UUID {
+		id := uuid.New()
+		s.events[id] = &domain.Event{ID: id, Title: "practice", Mode: domain.EventModeReport, StartTime: start, ExpectedDuration: 20, Status: domain.EventStatusScheduled}
+		ea := uuid.New()
+		s.eventAttendees[ea] = &domain.EventAttendee{ID: ea, EventID: id, UserID: emcee.UserID, Role: domain.EventAttendeeRoleEmcee}
+		for _, c := range attendees {
+			attend(s, id, c)
+		}
+		return id
+	}
+	getAgenda := func(t *testing.T, id uuid.UUID) domain.Agenda {
+		t.Helper()
+		w := doRequest(t, router, http.MethodGet, "/v1/practices/"+id.String()+"/agenda", tokens[bob], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var agenda domain.Agenda
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &agenda))
+		return agenda
+	}
+	names := func(speakers []domain.AgendaSpeaker) []string {
+		var out []string
+		for _, sp := range speakers {
+			out = append(out, sp.Name)
+		}
+		return out
+	}
+
+	t.Run("Report", func(t *testing.T) {
+		id := newPractice(start, ann, bob)
+
+		agenda := getAgenda(t, id)
+
+		assert.Equal(t, domain.EventModeReport, agenda.Mode)
+		assert.Equal(t, []string{"Ann", "Bob"}, names(agenda.Speakers))
+		require.Len(t, agenda.Slots, 2)
+		assert.True(t, start.Add(10*time.Minute).Equal(agenda.Slots[1].StartsAt))
+	})
+
+	t.Run("SpeakingOrder", func(t *testing.T) {
+		id := newPractice(start, ann, bob)
+		sendMessage(t, a, ann, "join-room", JoinPayload{EventID: id.String()})
+		drain(t, ann)
+		body := fmt.Sprintf(`{"user_ids":[%q]}`, bob.UserID)
+
+		w := doRequest(t, router, http.MethodPut, "/v1/practices/"+id.String()+"/speaking-order", tokens[ann], body)
+		require.Equal(t, http.StatusForbidden, w.Code, "only the host sets the order")
+
+		w = doRequest(t, router, http.MethodPut, "/v1/practices/"+id.String()+"/speaking-order", tokens[emcee], body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, []string{"Bob", "Ann"}, names(getAgenda(t, id).Speakers))
+		assert.Equal(t, []string{"agenda-updated"}, messageTypes(drain(t, ann)))
+
+		// Attendees added later are pushed a new agenda as well
+		attendeeBody := fmt.Sprintf(`{"event_id":%q,"user_id":%q,"role":"member"}`, id, outsider.UserID)
+		w = doRequest(t, router, http.MethodPost, "/v1/event-attendees", tokens[emcee], attendeeBody)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, []string{"agenda-updated"}, messageTypes(drain(t, ann)))
+		assert.Equal(t, []string{"Bob", "Ann", "Outsider"}, names(getAgenda(t, id).Speakers))
+	})
+
+	t.Run("InvalidSpeakingOrder", func(t *testing.T) {
+		id := newPractice(start, ann, bob)
+
+		for name, ids := range map[string][]uuid.UUID{
+			"Duplicate":   {ann.UserID, ann.UserID},
+			"NotAttendee": {outsider.UserID},
+		} {
+			body, err := json.Marshal(speakingOrderRequest{UserIDs: ids})
+			require.NoError(t, err)
+			w := doRequest(t, router, http.MethodPut, "/v1/practices/"+id.String()+"/speaking-order", tokens[emcee], string(body))
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+	})
+
+	t.Run("Review", func(t *testing.T) {
+		past := newPractice(start.Add(-7*24*time.Hour), ann)
+		mistake := uuid.New()
+		s.mistakes[mistake] = &domain.Mistake{ID: mistake, EventID: past, UserID: ann.UserID}
+		id := newPractice(start, ann, bob)
+
+		body := fmt.Sprintf(`{"title":"review","mode":"review","start_time":%q,"review_of_id":%q}`, start.Format(time.RFC3339), past)
+		w := doRequest(t, router, http.MethodPut, "/v1/practices/"+id.String(), tokens[emcee], body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		agenda := getAgenda(t, id)
+		require.NotNil(t, agenda.Review)
+		assert.Equal(t, past, agenda.Review.EventID)
+		require.Len(t, agenda.Review.Mistakes, 1)
+		assert.Equal(t, mistake, agenda.Review.Mistakes[0].ID)
+
+		// The reviewed event must come first
+		later := newPractice(start.Add(time.Hour))
+		body = fmt.Sprintf(`{"title":"review","mode":"review","start_time":%q,"review_of_id":%q}`, start.Format(time.RFC3339), later)
+		w = doRequest(t, router, http.MethodPut, "/v1/practices/"+id.String(), tokens[emcee], body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("ReviewOfUnattendedEvent", func(t *testing.T) {
+		past := uuid.New()
+		s.events[past] = &domain.Event{ID: past, Title: "private", StartTime: start.Add(-time.Hour), Status: domain.EventStatusEnded}
+
+		body := fmt.Sprintf(`{"title":"review","mode":"review","start_time":%q,"review_of_id":%q}`, start.Format(time.RFC3339), past)
+		w := doRequest(t, router, http.MethodPost, "/v1/practices", tokens[outsider], body)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
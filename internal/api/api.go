@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"net/http"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 
 	"jpcorrect-backend/internal/domain"
 	"jpcorrect-backend/internal/repository"
+	"jpcorrect-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -17,28 +19,48 @@ import (
 )
 
 type API struct {
-	db                *gorm.DB
-	apiToolsURL       string
-	proxyTransport    *http.Transport
-	jwksURL           string
-	jwksCache         keyfunc.Keyfunc
-	jwksCtx           context.Context
-	jwksCancel        context.CancelFunc
-	jwksMutex         sync.Mutex
-	jwksErr           error
-	userRepo          domain.UserRepository
-	guildRepo         domain.GuildRepository
-	guildAttendeeRepo domain.GuildAttendeeRepository
-	eventRepo         domain.EventRepository
-	eventAttendeeRepo domain.EventAttendeeRepository
-	transcriptRepo    domain.TranscriptRepository
-	mistakeRepo       domain.MistakeRepository
-	webrtcHub         domain.WebRTCHub
-	rateLimiter       *RateLimiter
-	upgrader          websocket.Upgrader
+	db                  *gorm.DB
+	apiToolsURL         string
+	proxyTransport      *http.Transport
+	jwksURL             string
+	jwksCache           keyfunc.Keyfunc
+	jwksCtx             context.Context
+	jwksCancel          context.CancelFunc
+	jwksMutex           sync.Mutex
+	jwksErr             error
+	userRepo            domain.UserRepository
+	guildRepo           domain.GuildRepository
+	guildAttendeeRepo   domain.GuildAttendeeRepository
+	eventRepo           domain.EventRepository
+	eventAttendeeRepo   domain.EventAttendeeRepository
+	transcriptRepo      domain.TranscriptRepository
+	mistakeRepo         domain.MistakeRepository
+	attendanceRepo      domain.AttendanceRepository
+	pointRepo           domain.PointRepository
+	leaderboardRepo     domain.LeaderboardRepository
+	guildMembershipRepo domain.GuildMembershipRepository
+	eventSeriesRepo     domain.EventSeriesRepository
+	eventMessageRepo    domain.EventMessageRepository
+	punctuality         *service.PunctualityService
+	points              *service.PointsService
+	guildMembership     *service.GuildMembershipService
+	eventSeries         *service.EventSeriesService
+	agenda              *service.AgendaService
+	seriesCancel        context.CancelFunc
+	icalSecret          []byte
+	ice                 ICEConfig
+	webrtcHub           domain.WebRTCHub
+	hubCancel           context.CancelFunc
+	sweepCancel         context.CancelFunc
+	rateLimiter         *RateLimiter
+	upgrader            websocket.Upgrader
+	pongWait            time.Duration
+	pingPeriod          time.Duration
+	sessions            *resumeRegistry
+	resumeGrace         time.Duration
 }
 
-func NewAPI(url string, transport *http.Transport, db *gorm.DB, jwksURL string, allowedOrigins []string) *API {
+func NewAPI(url string, transport *http.Transport, db *gorm.DB, jwksURL string, allowedOrigins []string, lateGrace time.Duration, levelCurves domain.LevelCurves, icalSecret string, ice ICEConfig) *API {
 	userRepo := repository.NewGormUserRepository(db)
 	guildRepo := repository.NewGormGuildRepository(db)
 	guildAttendeeRepo := repository.NewGormGuildAttendeeRepository(db)
@@ -46,7 +68,22 @@ func NewAPI(url string, transport *http.Transport, db *gorm.DB, jwksURL string,
 	eventAttendeeRepo := repository.NewGormEventAttendeeRepository(db)
 	transcriptRepo := repository.NewGormTranscriptRepository(db)
 	mistakeRepo := repository.NewGormMistakeRepository(db)
+	attendanceRepo := repository.NewGormAttendanceRepository(db)
+	pointRepo := repository.NewGormPointRepository(db)
+	leaderboardRepo := repository.NewGormLeaderboardRepository(db)
+	guildMembershipRepo := repository.NewGormGuildMembershipRepository(db)
+	eventSeriesRepo := repository.NewGormEventSeriesRepository(db)
+	eventMessageRepo := repository.NewGormEventMessageRepository(db)
+	punctuality := service.NewPunctualityService(eventRepo, eventAttendeeRepo, lateGrace)
+	guildMembership := service.NewGuildMembershipService(guildMembershipRepo, guildAttendeeRepo)
+	points := service.NewPointsService(pointRepo, eventAttendeeRepo, mistakeRepo, attendanceRepo, service.DefaultPointRules, levelCurves)
+	eventSeries := service.NewEventSeriesService(eventSeriesRepo, service.DefaultSeriesHorizon)
+	agenda := service.NewAgendaService(eventRepo, eventAttendeeRepo, userRepo, mistakeRepo)
+	seriesCtx, seriesCancel := context.WithCancel(context.Background())
+	go eventSeries.Run(seriesCtx, time.Hour)
 	webrtcHub := NewHub()
+	sweepCtx, sweepCancel := context.WithCancel(context.Background())
+	go webrtcHub.sweepStates(sweepCtx, roomStateSweep)
 	rateLimiter := NewRateLimiter(10*time.Second, 15) // 10秒窗口，最多15次連線
 
 	// 配置 WebSocket upgrader 的來源驗證
@@ -71,119 +108,225 @@ func NewAPI(url string, transport *http.Transport, db *gorm.DB, jwksURL string,
 	}
 
 	return &API{
-		db:                db,
-		apiToolsURL:       url,
-		proxyTransport:    transport,
-		jwksURL:           jwksURL,
-		userRepo:          userRepo,
-		guildRepo:         guildRepo,
-		guildAttendeeRepo: guildAttendeeRepo,
-		eventRepo:         eventRepo,
-		eventAttendeeRepo: eventAttendeeRepo,
-		transcriptRepo:    transcriptRepo,
-		mistakeRepo:       mistakeRepo,
-		webrtcHub:         webrtcHub,
-		rateLimiter:       rateLimiter,
-		upgrader:          upgrader,
+		db:                  db,
+		apiToolsURL:         url,
+		proxyTransport:      transport,
+		jwksURL:             jwksURL,
+		userRepo:            userRepo,
+		guildRepo:           guildRepo,
+		guildAttendeeRepo:   guildAttendeeRepo,
+		eventRepo:           eventRepo,
+		eventAttendeeRepo:   eventAttendeeRepo,
+		transcriptRepo:      transcriptRepo,
+		mistakeRepo:         mistakeRepo,
+		attendanceRepo:      attendanceRepo,
+		pointRepo:           pointRepo,
+		leaderboardRepo:     leaderboardRepo,
+		guildMembershipRepo: guildMembershipRepo,
+		eventSeriesRepo:     eventSeriesRepo,
+		eventMessageRepo:    eventMessageRepo,
+		punctuality:         punctuality,
+		points:              points,
+		guildMembership:     guildMembership,
+		eventSeries:         eventSeries,
+		agenda:              agenda,
+		seriesCancel:        seriesCancel,
+		icalSecret:          []byte(icalSecret),
+		ice:                 ice,
+		webrtcHub:           webrtcHub,
+		sweepCancel:         sweepCancel,
+		rateLimiter:         rateLimiter,
+		upgrader:            upgrader,
+		pongWait:            wsPongWait,
+		pingPeriod:          wsPingPeriod,
+		sessions:            newResumeRegistry(),
+		resumeGrace:         wsResumeGrace,
 	}
 }
 
-// Close stops the RateLimiter's cleanup goroutine
+// ConnectHub shares the WebRTC rooms with the other instances of the API
+// through backend until Close
+func (api *API) ConnectHub(backend domain.HubBackend) {
+	hub, ok := api.webrtcHub.(*Hub)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	api.hubCancel = cancel
+	hub.Connect(ctx, backend)
+}
+
+// Close stops the RateLimiter's cleanup goroutine, the event series
+// materializer, the room state sweep and the hub fan-out
 func (api *API) Close() {
 	if api.rateLimiter != nil {
 		api.rateLimiter.Close()
 	}
+	if api.seriesCancel != nil {
+		api.seriesCancel()
+	}
+	if api.sweepCancel != nil {
+		api.sweepCancel()
+	}
+	if api.hubCancel != nil {
+		api.hubCancel()
+	}
 }
 
 func Register(r *gin.Engine, api *API) {
 	r.GET("/healthz", func(c *gin.Context) { c.String(200, "ok") })
 	// WebRTC WebSocket endpoint
 	r.GET("/ws", api.ServeWebSocket)
+	// Calendar feeds authenticate with the token in their URL
+	r.GET("/ical/users/:file", api.UserICalHandler)
+	r.GET("/ical/guilds/:file", api.GuildICalHandler)
 
 	v1 := r.Group("/v1")
 	v1.Use(api.AuthMiddleware())
 	{
 		// API Tools Handlers
-		v1.POST("/mark-accent", api.MarkAccentHandler)
-		v1.POST("/mark-furigana", api.MarkFuriganaHandler)
-		v1.POST("/usage-query/headwords", api.UsageQueryHeadWordsHandler)
-		v1.POST("/usage-query/url", api.UsageQueryURLHandler)
-		v1.POST("/usage-query/id-details", api.UsageQueryIDDetailsHandler)
-		v1.POST("/dict-query", api.DictQueryHandler)
-		v1.POST("/sentence-query", api.SentenceQueryHandler)
+		v1.POST("/mark-accent", api.authorize(allowAuthenticated), api.MarkAccentHandler)
+		v1.POST("/mark-furigana", api.authorize(allowAuthenticated), api.MarkFuriganaHandler)
+		v1.POST("/usage-query/headwords", api.authorize(allowAuthenticated), api.UsageQueryHeadWordsHandler)
+		v1.POST("/usage-query/url", api.authorize(allowAuthenticated), api.UsageQueryURLHandler)
+		v1.POST("/usage-query/id-details", api.authorize(allowAuthenticated), api.UsageQueryIDDetailsHandler)
+		v1.POST("/dict-query", api.authorize(allowAuthenticated), api.DictQueryHandler)
+		v1.POST("/sentence-query", api.authorize(allowAuthenticated), api.SentenceQueryHandler)
 
 		// Mistakes
 		mistakes := v1.Group("/mistakes")
 		{
-			mistakes.POST("", api.MistakeCreateHandler)
-			mistakes.GET("/:id", api.MistakeGetHandler)
-			mistakes.PUT("/:id", api.MistakeUpdateHandler)
-			mistakes.DELETE("/:id", api.MistakeDeleteHandler)
-			mistakes.GET("/event/:event_id", api.MistakeGetByEventHandler)
-			mistakes.GET("/user/:user_id", api.MistakeGetByUserHandler)
+			mistakes.POST("", api.authorize(api.requireBodyEventAttendance()), api.MistakeCreateHandler)
+			mistakes.GET("/:id", api.authorize(api.requireMistake(accessRead)), api.MistakeGetHandler)
+			mistakes.PUT("/:id", api.authorize(api.requireMistake(accessOwn)), api.MistakeUpdateHandler)
+			mistakes.DELETE("/:id", api.authorize(api.requireMistake(accessOwn)), api.MistakeDeleteHandler)
+			mistakes.GET("/event/:event_id", api.authorize(api.requireEventParam("event_id", accessRead)), api.MistakeGetByEventHandler)
+			mistakes.GET("/user/:user_id", api.authorize(requireSelf("user_id")), api.MistakeGetByUserHandler)
 		}
 
 		// Practices (keep old route for backward compatibility)
 		practices := v1.Group("/practices")
 		{
-			practices.POST("", api.PracticeCreateHandler)
-			practices.GET("/:id", api.PracticeGetHandler)
-			practices.PUT("/:id", api.PracticeUpdateHandler)
-			practices.DELETE("/:id", api.PracticeDeleteHandler)
-			practices.GET("/user/:user_id", api.PracticeGetByUserHandler)
+			practices.POST("", api.authorize(api.requireEventCreate()), api.PracticeCreateHandler)
+			practices.GET("/:id", api.authorize(api.requireEventParam("id", accessRead)), api.PracticeGetHandler)
+			practices.PUT("/:id", api.authorize(api.requireEventUpdate("id")), api.PracticeUpdateHandler)
+			// Practices always have an emcee attendee, so DELETE cancels them
+			practices.DELETE("/:id", api.authorize(api.requireEventCancel("id")), api.PracticeCancelHandler)
+			practices.GET("/user/:user_id", api.authorize(requireSelf("user_id")), api.PracticeGetByUserHandler)
+			practices.POST("/:id/start", api.authorize(api.requireEventHost("id")), api.PracticeStartHandler)
+			practices.POST("/:id/end", api.authorize(api.requireEventHost("id")), api.PracticeEndHandler)
+			practices.POST("/:id/cancel", api.authorize(api.requireEventCancel("id")), api.PracticeCancelHandler)
+			practices.POST("/:id/review", api.authorize(api.requireEventHost("id")), api.PracticeReviewHandler)
+			practices.GET("/:id/attendance", api.authorize(api.requireEventParam("id", accessRead)), api.PracticeAttendanceHandler)
+			practices.GET("/:id/agenda", api.authorize(api.requireEventParam("id", accessRead)), api.PracticeAgendaHandler)
+			practices.GET("/:id/messages", api.authorize(api.requireEventParam("id", accessRead)), api.PracticeMessagesHandler)
+			practices.PUT("/:id/speaking-order", api.authorize(api.requireEventHost("id")), api.PracticeSpeakingOrderHandler)
+		}
+
+		// Recurring practices
+		eventSeries := v1.Group("/event-series")
+		{
+			eventSeries.POST("", api.authorize(api.requireEventCreate()), api.EventSeriesCreateHandler)
+			eventSeries.GET("/:id", api.authorize(api.requireEventSeries(accessRead)), api.EventSeriesGetHandler)
+			eventSeries.PUT("/:id", api.authorize(api.requireEventSeries(accessManage)), api.EventSeriesUpdateHandler)
+			eventSeries.DELETE("/:id", api.authorize(api.requireEventSeries(accessManage)), api.EventSeriesDeleteHandler)
+			eventSeries.GET("/:id/events", api.authorize(api.requireEventSeries(accessRead)), api.EventSeriesEventListHandler)
 		}
 
 		// Guilds
 		guilds := v1.Group("/guilds")
 		{
-			guilds.POST("", api.GuildCreateHandler)
-			guilds.GET("/:id", api.GuildGetHandler)
-			guilds.PUT("/:id", api.GuildUpdateHandler)
-			guilds.DELETE("/:id", api.GuildDeleteHandler)
+			guilds.GET("", api.authorize(allowAuthenticated), api.GuildListHandler)
+			guilds.POST("", api.authorize(allowAuthenticated), api.GuildCreateHandler)
+			guilds.GET("/:id", api.authorize(allowAuthenticated), api.GuildGetHandler)
+			guilds.PUT("/:id", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildUpdateHandler)
+			guilds.DELETE("/:id", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildDeleteHandler)
+			guilds.GET("/:id/leaderboard", api.authorize(api.requireGuildParam("id", accessRead)), api.GuildLeaderboardHandler)
+			guilds.GET("/:id/events", api.authorize(api.requireGuildParam("id", accessRead)), api.GuildEventListHandler)
+			guilds.GET("/:id/calendar", api.authorize(api.requireGuildParam("id", accessRead)), api.GuildCalendarHandler)
+			guilds.POST("/:id/invites", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildInviteCreateHandler)
+			guilds.GET("/:id/invites", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildInviteListHandler)
+			guilds.DELETE("/:id/invites/:invite_id", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildInviteRevokeHandler)
+			guilds.POST("/:id/join-requests", api.authorize(allowAuthenticated), api.GuildJoinRequestCreateHandler)
+			guilds.GET("/:id/join-requests", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildJoinRequestListHandler)
+			guilds.POST("/:id/join-requests/:request_id/approve", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildJoinRequestApproveHandler)
+			guilds.POST("/:id/join-requests/:request_id/reject", api.authorize(api.requireGuildParam("id", accessManage)), api.GuildJoinRequestRejectHandler)
 		}
 
+		// Guild invites
+		v1.POST("/guild-invites/:code/accept", api.authorize(allowAuthenticated), api.GuildInviteAcceptHandler)
+
 		// Guild Attendees
 		guildAttendees := v1.Group("/guild-attendees")
 		{
-			guildAttendees.POST("", api.GuildAttendeeCreateHandler)
-			guildAttendees.GET("/:id", api.GuildAttendeeGetHandler)
-			guildAttendees.PUT("/:id", api.GuildAttendeeUpdateHandler)
-			guildAttendees.DELETE("/:id", api.GuildAttendeeDeleteHandler)
-			guildAttendees.GET("/guild/:guild_id", api.GuildAttendeeGetByGuildHandler)
-			guildAttendees.GET("/user/:user_id", api.GuildAttendeeGetByUserHandler)
+			// Members join through invites and join requests; direct creation is for admins
+			guildAttendees.POST("", api.authorize(requirePrivileged), api.GuildAttendeeCreateHandler)
+			guildAttendees.GET("/:id", api.authorize(api.requireGuildAttendee(accessRead)), api.GuildAttendeeGetHandler)
+			guildAttendees.PUT("/:id", api.authorize(api.requireGuildAttendee(accessManage)), api.GuildAttendeeUpdateHandler)
+			guildAttendees.DELETE("/:id", api.authorize(api.requireGuildAttendee(accessOwn)), api.GuildAttendeeDeleteHandler)
+			guildAttendees.GET("/guild/:guild_id", api.authorize(api.requireGuildParam("guild_id", accessRead)), api.GuildAttendeeGetByGuildHandler)
+			guildAttendees.GET("/user/:user_id", api.authorize(requireSelf("user_id")), api.GuildAttendeeGetByUserHandler)
 		}
 
 		// Transcripts
 		transcripts := v1.Group("/transcripts")
 		{
-			transcripts.POST("", api.TranscriptCreateHandler)
-			transcripts.GET("/:id", api.TranscriptGetHandler)
-			transcripts.PUT("/:id", api.TranscriptUpdateHandler)
-			transcripts.DELETE("/:id", api.TranscriptDeleteHandler)
-			transcripts.GET("/event/:event_id", api.TranscriptGetByEventHandler)
-			transcripts.GET("/user/:user_id", api.TranscriptGetByUserHandler)
+			transcripts.POST("", api.authorize(api.requireBodyEventAttendance()), api.TranscriptCreateHandler)
+			transcripts.GET("/:id", api.authorize(api.requireTranscript(accessRead)), api.TranscriptGetHandler)
+			transcripts.PUT("/:id", api.authorize(api.requireTranscript(accessOwn)), api.TranscriptUpdateHandler)
+			transcripts.DELETE("/:id", api.authorize(api.requireTranscript(accessOwn)), api.TranscriptDeleteHandler)
+			transcripts.GET("/event/:event_id", api.authorize(api.requireEventParam("event_id", accessRead)), api.TranscriptGetByEventHandler)
+			transcripts.GET("/user/:user_id", api.authorize(requireSelf("user_id")), api.TranscriptGetByUserHandler)
 		}
 
 		// Event Attendees
 		eventAttendees := v1.Group("/event-attendees")
 		{
-			eventAttendees.POST("", api.EventAttendeeCreateHandler)
-			eventAttendees.GET("/:id", api.EventAttendeeGetHandler)
-			eventAttendees.PUT("/:id", api.EventAttendeeUpdateHandler)
-			eventAttendees.DELETE("/:id", api.EventAttendeeDeleteHandler)
-			eventAttendees.GET("/event/:event_id", api.EventAttendeeGetByEventHandler)
-			eventAttendees.GET("/user/:user_id", api.EventAttendeeGetByUserHandler)
+			eventAttendees.POST("", api.authorize(api.requireEventAttendeeCreate()), api.EventAttendeeCreateHandler)
+			eventAttendees.GET("/:id", api.authorize(api.requireEventAttendee(accessRead)), api.EventAttendeeGetHandler)
+			eventAttendees.PUT("/:id", api.authorize(api.requireEventAttendee(accessManage)), api.EventAttendeeUpdateHandler)
+			eventAttendees.DELETE("/:id", api.authorize(api.requireEventAttendee(accessOwn)), api.EventAttendeeDeleteHandler)
+			eventAttendees.GET("/event/:event_id", api.authorize(api.requireEventParam("event_id", accessRead)), api.EventAttendeeGetByEventHandler)
+			eventAttendees.GET("/user/:user_id", api.authorize(requireSelf("user_id")), api.EventAttendeeGetByUserHandler)
+		}
+
+		// Current user
+		me := v1.Group("/me")
+		{
+			me.GET("", api.authorize(allowAuthenticated), api.MeGetHandler)
+			me.PATCH("", api.authorize(allowAuthenticated), api.MeUpdateHandler)
+			me.GET("/calendar", api.authorize(allowAuthenticated), api.MeCalendarHandler)
+		}
+
+		// WebRTC
+		webrtc := v1.Group("/webrtc")
+		{
+			webrtc.GET("/ice-servers", api.authorize(allowAuthenticated), api.ICEServersHandler)
 		}
 
 		// Users
 		users := v1.Group("/users")
 		{
-			users.POST("", api.UserCreateHandler)
-			users.GET("/:id", api.UserGetHandler)
-			users.PUT("/:id", api.UserUpdateHandler)
-			users.DELETE("/:id", api.UserDeleteHandler)
-			users.GET("/name/:name", api.UserGetByNameHandler)
-			users.GET("/email/:email", api.UserGetByEmailHandler)
+			users.POST("", api.authorize(requirePrivileged), api.UserCreateHandler)
+			users.GET("/:id", api.authorize(allowAuthenticated), api.UserGetHandler)
+			users.PUT("/:id", api.authorize(requireSelf("id")), api.UserUpdateHandler)
+			users.DELETE("/:id", api.authorize(requireSelf("id")), api.UserDeleteHandler)
+			users.GET("/name/:name", api.authorize(allowAuthenticated), api.UserGetByNameHandler)
+			users.GET("/email/:email", api.authorize(allowAuthenticated), api.UserGetByEmailHandler)
+			users.GET("/:id/attendance", api.authorize(requireSelf("id")), api.UserAttendanceHandler)
+			users.GET("/:id/points", api.authorize(requireSelf("id")), api.UserPointsHandler)
 		}
+
+		// Leaderboard
+		v1.GET("/leaderboard", api.authorize(allowAuthenticated), api.LeaderboardHandler)
+
+		// Points
+		points := v1.Group("/points")
+		{
+			points.POST("/recompute", api.authorize(requirePrivileged), api.PointsRecomputeHandler)
+		}
+
+		// Runtime metrics
+		v1.GET("/debug/vars", api.authorize(requirePrivileged), gin.WrapH(expvar.Handler()))
 	}
 }
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// attendanceTimeline is the attendance of one event: the first join and last
+// leave per attendee, and every join/leave in chronological order.
+type attendanceTimeline struct {
+	EventID   uuid.UUID               `json:"event_id"`
+	Attendees []*domain.EventAttendee `json:"attendees"`
+	Timeline  []*domain.AttendanceLog `json:"timeline"`
+}
+
+func (a *API) PracticeAttendanceHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	attendees, err := a.eventAttendeeRepo.GetByEventID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	timeline, err := a.attendanceRepo.ListByEventID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendanceTimeline{EventID: id, Attendees: attendees, Timeline: timeline})
+}
+
+func (a *API) UserAttendanceHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.attendanceRepo.ListRecordsByUserID(c.Request.Context(), id, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+	"jpcorrect-backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attendee returns the EventAttendee row of c's user for the event.
+func attendee(t *testing.T, s *fakeStore, eventID uuid.UUID, c *domain.Client) *domain.EventAttendee {
+	t.Helper()
+	a, err := fakeEventAttendeeRepo{s}.GetByEventAndUser(t.Context(), eventID, c.UserID)
+	require.NoError(t, err)
+	return a
+}
+
+func actions(entries []*domain.AttendanceLog) []domain.AttendanceAction {
+	out := make([]domain.AttendanceAction, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Action)
+	}
+	return out
+}
+
+func TestHandleWebRTCMessage_RecordsAttendance(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventA, eventB := uuid.New(), uuid.New()
+	s.events[eventA] = &domain.Event{ID: eventA, Status: domain.EventStatusScheduled}
+	s.events[eventB] = &domain.Event{ID: eventB, Status: domain.EventStatusScheduled}
+
+	alice := newTestClient("alice")
+	a.webrtcHub.AddClient(alice)
+	attend(s, eventA, alice)
+	attend(s, eventB, alice)
+
+	t.Run("ReconnectKeepsFirstJoinAndLastLeave", func(t *testing.T) {
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+		firstJoin := attendee(t, s, eventA, alice).JoinedAt
+		require.NotNil(t, firstJoin)
+
+		sendMessage(t, a, alice, "leave-room", nil)
+		firstLeave := attendee(t, s, eventA, alice).LeftAt
+		require.NotNil(t, firstLeave)
+
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+		sendMessage(t, a, alice, "leave-room", nil)
+
+		got := attendee(t, s, eventA, alice)
+		assert.Equal(t, *firstJoin, *got.JoinedAt)
+		assert.False(t, got.LeftAt.Before(*firstLeave))
+
+		entries, err := a.attendanceRepo.ListByEventID(t.Context(), eventA)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.AttendanceAction{
+			domain.AttendanceActionJoin, domain.AttendanceActionLeave,
+			domain.AttendanceActionJoin, domain.AttendanceActionLeave,
+		}, actions(entries))
+	})
+
+	t.Run("SwitchRoomLeavesPrevious", func(t *testing.T) {
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+		before := len(s.attendanceLogs)
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventB.String()})
+
+		assert.Len(t, s.attendanceLogs, before+2)
+		assert.NotNil(t, attendee(t, s, eventB, alice).JoinedAt)
+
+		// Re-joining the current room is not a new attendance
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventB.String()})
+		assert.Len(t, s.attendanceLogs, before+2)
+	})
+}
+
+func TestPracticeEndHandler(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+
+	emcee, member := newTestClient("emcee"), newTestClient("member")
+	tokens := map[*domain.Client]string{}
+	for _, c := range []*domain.Client{emcee, member} {
+		s.users[c.UserID] = &domain.User{ID: c.UserID, Name: c.Name, Email: c.ID + "@example.com", Role: domain.UserRoleUser}
+		tokens[c] = signTestToken(t, jwt.RegisteredClaims{
+			Subject:   c.UserID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+		a.webrtcHub.AddClient(c)
+	}
+
+	newPractice := func() uuid.UUID {
+		id := uuid.New()
+		s.events[id] = &domain.Event{ID: id, Title: "practice", ExpectedDuration: 60, Status: domain.EventStatusScheduled}
+		ea := uuid.New()
+		s.eventAttendees[ea] = &domain.EventAttendee{ID: ea, EventID: id, UserID: emcee.UserID, Role: domain.EventAttendeeRoleEmcee}
+		attend(s, id, member)
+		return id
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		eventID := newPractice()
+		w := doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/start", tokens[emcee], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		sendMessage(t, a, member, "join-room", JoinPayload{EventID: eventID.String()})
+		drain(t, member)
+		startedAt := time.Now().Add(-30 * time.Minute)
+		s.events[eventID].StartedAt = &startedAt
+
+		w = doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/end", tokens[emcee], "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var got domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, domain.EventStatusEnded, got.Status)
+		require.NotNil(t, got.EndedAt)
+		require.NotNil(t, got.ActualDuration)
+		assert.InDelta(t, 30, *got.ActualDuration, 1)
+
+		// Everyone still in the room is removed and their leave recorded
+		assert.Equal(t, []string{"session-ended"}, messageTypes(drain(t, member)))
+		assert.Empty(t, member.RoomID)
+		assert.Empty(t, a.webrtcHub.ListRoomUsers(eventID.String()))
+		assert.NotNil(t, attendee(t, s, eventID, member).LeftAt)
+
+		// and nobody can rejoin an ended event
+		sendMessage(t, a, member, "join-room", JoinPayload{EventID: eventID.String()})
+		msgs := drain(t, member)
+		require.Equal(t, []string{"error"}, messageTypes(msgs))
+		assert.JSONEq(t, `{"code":"event_closed","message":"event has ended"}`, string(msgs[0].Payload))
+
+		w = doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/end", tokens[emcee], "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("NotStarted", func(t *testing.T) {
+		eventID := newPractice()
+
+		w := doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/end", tokens[emcee], "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Nil(t, s.events[eventID].ActualDuration)
+	})
+
+	t.Run("MemberForbidden", func(t *testing.T) {
+		eventID := newPractice()
+
+		w := doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/end", tokens[member], "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestPracticeAttendanceHandler(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+
+	alice := newTestClient("alice")
+	s.users[alice.UserID] = &domain.User{ID: alice.UserID, Name: alice.Name, Email: "alice@example.com", Role: domain.UserRoleUser}
+	token := signTestToken(t, jwt.RegisteredClaims{
+		Subject:   alice.UserID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	a.webrtcHub.AddClient(alice)
+
+	eventID := uuid.New()
+	s.events[eventID] = &domain.Event{ID: eventID, Status: domain.EventStatusScheduled}
+	attend(s, eventID, alice)
+	sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventID.String()})
+	sendMessage(t, a, alice, "leave-room", nil)
+
+	w := doRequest(t, router, http.MethodGet, "/v1/practices/"+eventID.String()+"/attendance", token, "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got attendanceTimeline
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, eventID, got.EventID)
+	require.Len(t, got.Attendees, 1)
+	assert.NotNil(t, got.Attendees[0].JoinedAt)
+	assert.NotNil(t, got.Attendees[0].LeftAt)
+	assert.Equal(t, []domain.AttendanceAction{domain.AttendanceActionJoin, domain.AttendanceActionLeave}, actions(got.Timeline))
+
+	// Non-attendees cannot read the timeline
+	outsider := uuid.New()
+	s.users[outsider] = &domain.User{ID: outsider, Email: "outsider@example.com", Role: domain.UserRoleUser}
+	w = doRequest(t, router, http.MethodGet, "/v1/practices/"+eventID.String()+"/attendance", signTestToken(t, jwt.RegisteredClaims{
+		Subject:   outsider.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}), "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestPracticeEndHandler_UpdatesLateStreak(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+
+	emcee, punctual, late := newTestClient("emcee"), newTestClient("punctual"), newTestClient("late")
+	for _, c := range []*domain.Client{emcee, punctual, late} {
+		s.users[c.UserID] = &domain.User{ID: c.UserID, Name: c.Name, Email: c.ID + "@example.com", Role: domain.UserRoleUser, LateStreak: 2}
+		a.webrtcHub.AddClient(c)
+	}
+	token := func(c *domain.Client) string {
+		return signTestToken(t, jwt.RegisteredClaims{
+			Subject:   c.UserID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+	}
+
+	// Started 20 minutes ago; the late attendee joins only now
+	eventID := uuid.New()
+	startedAt := time.Now().Add(-20 * time.Minute)
+	s.events[eventID] = &domain.Event{ID: eventID, Title: "practice", StartTime: startedAt, Status: domain.EventStatusLive, StartedAt: &startedAt}
+	emceeEA := uuid.New()
+	s.eventAttendees[emceeEA] = &domain.EventAttendee{ID: emceeEA, EventID: eventID, UserID: emcee.UserID, Role: domain.EventAttendeeRoleEmcee}
+	attend(s, eventID, punctual)
+	attend(s, eventID, late)
+	sendMessage(t, a, emcee, "join-room", JoinPayload{EventID: eventID.String()})
+	sendMessage(t, a, late, "join-room", JoinPayload{EventID: eventID.String()})
+	for _, c := range []*domain.Client{emcee, punctual} {
+		joinedAt := time.Now().Add(-21 * time.Minute)
+		s.eventAttendees[attendee(t, s, eventID, c).ID].JoinedAt = &joinedAt
+	}
+
+	w := doRequest(t, router, http.MethodPost, "/v1/practices/"+eventID.String()+"/end", token(emcee), "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, 0, s.users[emcee.UserID].LateStreak)
+	assert.Equal(t, 0, s.users[punctual.UserID].LateStreak)
+	assert.Equal(t, 3, s.users[late.UserID].LateStreak)
+
+	// Everyone attended, the emcee hosted
+	rules := service.DefaultPointRules
+	assert.Equal(t, rules.AttendedEvent+rules.Emcee, s.users[emcee.UserID].Points)
+	assert.Equal(t, rules.AttendedEvent, s.users[punctual.UserID].Points)
+	assert.Equal(t, rules.AttendedEvent, s.users[late.UserID].Points)
+
+	t.Run("PointsHistory", func(t *testing.T) {
+		w := doRequest(t, router, http.MethodGet, "/v1/users/"+emcee.UserID.String()+"/points", token(emcee), "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page domain.Page[*domain.PointTransaction]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Items, 2)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		w := doRequest(t, router, http.MethodGet, "/v1/users/"+late.UserID.String()+"/attendance", token(late), "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page domain.Page[*domain.AttendanceRecord]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, eventID, page.Items[0].EventID)
+		assert.Equal(t, domain.PunctualityLate, page.Items[0].Punctuality)
+	})
+
+	t.Run("HistoryOfOthersForbidden", func(t *testing.T) {
+		w := doRequest(t, router, http.MethodGet, "/v1/users/"+late.UserID.String()+"/attendance", token(punctual), "")
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -63,10 +63,24 @@ func (a *API) ShutdownJWKS() {
 	a.jwksCache = nil
 }
 
-// AuthMiddleware returns a Gin middleware that validates JWT tokens
+// tokenClaims are the JWT claims read by the API. The profile claims seed the
+// caller's user record the first time they are seen.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// AuthMiddleware returns a Gin middleware that validates JWT tokens and loads
+// (or provisions) the caller's user record
 func (a *API) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		err := a.validateToken(c)
+		if err == nil {
+			err = a.loadUser(c)
+		}
 		if err != nil {
 			a.respondAuthError(c, err)
 			c.Abort()
@@ -123,11 +137,24 @@ func (a *API) validateToken(c *gin.Context) error {
 		)
 	}
 
+	claims, err := a.parseToken(tokenString)
+	if err != nil {
+		return err
+	}
+
+	// Store the claims in the context for loadUser
+	c.Set(claimsKey, claims)
+
+	return nil
+}
+
+// parseToken validates a raw JWT with the JWKS keyfunc and returns its claims
+func (a *API) parseToken(tokenString string) (*tokenClaims, error) {
 	// Check if JWKS is initialized
 	a.jwksMutex.Lock()
 	if a.jwksErr != nil || a.jwksCache == nil {
 		a.jwksMutex.Unlock()
-		return domain.NewAuthError(
+		return nil, domain.NewAuthError(
 			http.StatusInternalServerError,
 			"JWKS not initialized",
 			"",
@@ -137,10 +164,10 @@ func (a *API) validateToken(c *gin.Context) error {
 	a.jwksMutex.Unlock()
 
 	// Parse and validate the token
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, kf.Keyfunc)
+	token, err := jwt.ParseWithClaims(tokenString, &tokenClaims{}, kf.Keyfunc)
 	if err != nil {
 		log.Printf("invalid token error: %v", err)
-		return domain.NewAuthError(
+		return nil, domain.NewAuthError(
 			http.StatusUnauthorized,
 			"invalid token",
 			"",
@@ -148,7 +175,7 @@ func (a *API) validateToken(c *gin.Context) error {
 	}
 
 	if !token.Valid {
-		return domain.NewAuthError(
+		return nil, domain.NewAuthError(
 			http.StatusUnauthorized,
 			"invalid token",
 			"",
@@ -156,17 +183,14 @@ func (a *API) validateToken(c *gin.Context) error {
 	}
 
 	// Extract claims
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
-	if !ok {
-		return domain.NewAuthError(
+	claims, ok := token.Claims.(*tokenClaims)
+	if !ok || claims.Subject == "" {
+		return nil, domain.NewAuthError(
 			http.StatusUnauthorized,
 			"invalid token claims",
 			"",
 		)
 	}
 
-	// Store the user ID (subject) in the context for downstream handlers
-	c.Set("userID", claims.Subject)
-
-	return nil
+	return claims, nil
 }
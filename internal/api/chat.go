@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// chatHistoryLimit bounds the messages replayed to clients joining a room
+	chatHistoryLimit = 100
+	// A client may send chatBurst chat messages at once, and chatRate per
+	// second after that
+	chatBurst = 5
+	chatRate  = 1
+)
+
+// ChatPayload is sent with chat-message, and with edit-chat-message and
+// delete-chat-message naming the message by MessageID.
+type ChatPayload struct {
+	MessageID uuid.UUID `json:"messageId,omitempty"`
+	Content   string    `json:"content,omitempty"`
+}
+
+// ChatDeleted tells the room a chat message was deleted.
+type ChatDeleted struct {
+	MessageID uuid.UUID `json:"messageId"`
+}
+
+// chatContent checks the content of a chat message.
+func chatContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return signalError(CodeInvalidPayload, "missing content")
+	}
+	if utf8.RuneCountInString(content) > domain.MaxEventMessageLength {
+		return signalError(CodeInvalidPayload, fmt.Sprintf("content must be at most %d characters", domain.MaxEventMessageLength))
+	}
+	return nil
+}
+
+// sendChatHistory replays the recent chat of the room to c, if there is any.
+func (api *API) sendChatHistory(ctx context.Context, c *domain.Client, eventID uuid.UUID) {
+	history, err := api.eventMessageRepo.ListRecent(ctx, eventID, chatHistoryLimit)
+	if err != nil {
+		log.Printf("查詢聊天紀錄失敗 (event: %s): %v", eventID, err)
+		return
+	}
+	if len(history) == 0 {
+		return
+	}
+	if err := sendToClient(c, msgChatHistory, history); err != nil {
+		log.Printf("傳送聊天紀錄失敗 (user: %s): %v", c.ID, err)
+	}
+}
+
+// ownChatMessage loads the chat message p names if c sent it in its
+// current room.
+func (api *API) ownChatMessage(ctx context.Context, c *domain.Client, eventID uuid.UUID, p *ChatPayload) (*domain.EventMessageView, error) {
+	if p.MessageID == uuid.Nil {
+		return nil, signalError(CodeInvalidPayload, "missing messageId")
+	}
+	message, err := api.eventMessageRepo.GetByID(ctx, p.MessageID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && message.EventID != eventID) {
+		return nil, signalError(CodeMessageNotFound, "message not found")
+	}
+	if err != nil {
+		log.Printf("查詢聊天訊息失敗 (message: %s): %v", p.MessageID, err)
+		return nil, errInternal
+	}
+	if message.UserID != c.UserID {
+		return nil, signalError(CodeForbidden, "only the author can change this message")
+	}
+	return message, nil
+}
+
+// handleChatMessage saves a chat message sent in c's room, or an edit or
+// deletion of one of c's messages, and broadcasts it to the room, c
+// included.
+func (api *API) handleChatMessage(ctx context.Context, c *domain.Client, msgType string, p *ChatPayload) error {
+	roomID := api.webrtcHub.RoomOf(c)
+	eventID, err := uuid.Parse(roomID)
+	if err != nil {
+		return signalError(CodeNotInRoom, "not in a room")
+	}
+	if !c.Chat.Take(time.Now(), chatRate, chatBurst) {
+		return signalError(CodeRateLimited, "sending chat messages too fast")
+	}
+
+	switch msgType {
+	case msgChatMessage:
+		if err := chatContent(p.Content); err != nil {
+			return err
+		}
+		message := domain.EventMessage{EventID: eventID, UserID: c.UserID, Content: p.Content}
+		if err := api.eventMessageRepo.Create(ctx, &message); err != nil {
+			log.Printf("儲存聊天訊息失敗 (event: %s, user: %s): %v", eventID, c.UserID, err)
+			return errInternal
+		}
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgType, domain.EventMessageView{EventMessage: message, UserName: c.Name})
+
+	case msgEditChatMessage:
+		if err := chatContent(p.Content); err != nil {
+			return err
+		}
+		message, err := api.ownChatMessage(ctx, c, eventID, p)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		message.Content, message.EditedAt = p.Content, &now
+		if err := api.eventMessageRepo.Update(ctx, &message.EventMessage); err != nil {
+			log.Printf("更新聊天訊息失敗 (message: %s): %v", message.ID, err)
+			return errInternal
+		}
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgType, message)
+
+	case msgDeleteChatMessage:
+		message, err := api.ownChatMessage(ctx, c, eventID, p)
+		if err != nil {
+			return err
+		}
+		if err := api.eventMessageRepo.Delete(ctx, message.ID); err != nil {
+			log.Printf("刪除聊天訊息失敗 (message: %s): %v", message.ID, err)
+			return errInternal
+		}
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgType, ChatDeleted{MessageID: message.ID})
+	}
+	return nil
+}
+
+// PracticeMessagesHandler pages through the chat of the practice, oldest
+// first by default.
+func (a *API) PracticeMessagesHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.eventMessageRepo.ListByEventID(c.Request.Context(), id, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatRoom puts alice and bob in the room of a new event.
+func chatRoom(t *testing.T) (*API, *fakeStore, uuid.UUID, *domain.Client, *domain.Client) {
+	t.Helper()
+	a, s := newTestAPI(t)
+	eventID := uuid.New()
+	s.events[eventID] = &domain.Event{ID: eventID, Status: domain.EventStatusLive}
+	alice, bob := newTestClient("alice"), newTestClient("bob")
+	for _, c := range []*domain.Client{alice, bob} {
+		s.users[c.UserID] = &domain.User{ID: c.UserID, Name: c.Name}
+		a.webrtcHub.AddClient(c)
+		attend(s, eventID, c)
+		sendMessage(t, a, c, "join-room", JoinPayload{EventID: eventID.String()})
+	}
+	drain(t, alice)
+	drain(t, bob)
+	return a, s, eventID, alice, bob
+}
+
+func chatMessages(t *testing.T, msgs []Message) []domain.EventMessageView {
+	t.Helper()
+	var out []domain.EventMessageView
+	for _, m := range msgs {
+		var v domain.EventMessageView
+		require.NoError(t, json.Unmarshal(m.Payload, &v))
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestChatMessage_Broadcast(t *testing.T) {
+	a, s, eventID, alice, bob := chatRoom(t)
+
+	sendMessage(t, a, alice, "chat-message", ChatPayload{Content: "「は」じゃなくて「が」です"})
+
+	for _, c := range []*domain.Client{alice, bob} {
+		msgs := drain(t, c)
+		require.Equal(t, []string{"chat-message"}, messageTypes(msgs), c.ID)
+		got := chatMessages(t, msgs)[0]
+		assert.Equal(t, "「は」じゃなくて「が」です", got.Content)
+		assert.Equal(t, alice.UserID, got.UserID)
+		assert.Equal(t, "Alice", got.UserName)
+		assert.Nil(t, got.EditedAt)
+	}
+	require.Len(t, s.messages, 1)
+	for _, m := range s.messages {
+		assert.Equal(t, eventID, m.EventID)
+		assert.Equal(t, alice.UserID, m.UserID)
+	}
+}
+
+func TestChatMessage_Rejected(t *testing.T) {
+	a, s, _, alice, _ := chatRoom(t)
+	outsider := newTestClient("carol")
+	a.webrtcHub.AddClient(outsider)
+
+	for name, tt := range map[string]struct {
+		c    *domain.Client
+		p    ChatPayload
+		want string
+	}{
+		"NotInRoom": {c: outsider, p: ChatPayload{Content: "hi"}, want: `{"code":"not_in_room","message":"not in a room"}`},
+		"Blank":     {c: alice, p: ChatPayload{Content: " \n"}, want: `{"code":"invalid_payload","message":"missing content"}`},
+		"TooLong":   {c: alice, p: ChatPayload{Content: strings.Repeat("あ", domain.MaxEventMessageLength+1)}, want: `{"code":"invalid_payload","message":"content must be at most 2000 characters"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sendMessage(t, a, tt.c, "chat-message", tt.p)
+
+			msgs := drain(t, tt.c)
+			require.Equal(t, []string{"error"}, messageTypes(msgs))
+			assert.JSONEq(t, tt.want, string(msgs[0].Payload))
+		})
+	}
+	assert.Empty(t, s.messages)
+}
+
+func TestChatMessage_RateLimited(t *testing.T) {
+	a, s, _, alice, bob := chatRoom(t)
+
+	for i := 0; i < chatBurst; i++ {
+		sendMessage(t, a, alice, "chat-message", ChatPayload{Content: "hi"})
+	}
+	assert.Len(t, chatMessages(t, drain(t, bob)), chatBurst)
+	drain(t, alice)
+
+	sendMessage(t, a, alice, "chat-message", ChatPayload{Content: "hi"})
+	msgs := drain(t, alice)
+	require.Equal(t, []string{"error"}, messageTypes(msgs))
+	assert.JSONEq(t, `{"code":"rate_limited","message":"sending chat messages too fast"}`, string(msgs[0].Payload))
+	assert.Empty(t, drain(t, bob))
+	assert.Len(t, s.messages, chatBurst)
+
+	// Other clients have buckets of their own
+	sendMessage(t, a, bob, "chat-message", ChatPayload{Content: "hi"})
+	assert.Equal(t, []string{"chat-message"}, messageTypes(drain(t, alice)))
+}
+
+func TestChatMessage_EditAndDelete(t *testing.T) {
+	a, s, _, alice, bob := chatRoom(t)
+	sendMessage(t, a, alice, "chat-message", ChatPayload{Content: "typo"})
+	sent := chatMessages(t, drain(t, alice))[0]
+	drain(t, bob)
+
+	t.Run("OnlyByAuthor", func(t *testing.T) {
+		for _, msgType := range []string{"edit-chat-message", "delete-chat-message"} {
+			sendMessage(t, a, bob, msgType, ChatPayload{MessageID: sent.ID, Content: "mine now"})
+
+			msgs := drain(t, bob)
+			require.Equal(t, []string{"error"}, messageTypes(msgs), msgType)
+			assert.JSONEq(t, `{"code":"forbidden","message":"only the author can change this message"}`, string(msgs[0].Payload))
+		}
+		assert.Empty(t, drain(t, alice))
+	})
+
+	t.Run("UnknownMessage", func(t *testing.T) {
+		sendMessage(t, a, alice, "delete-chat-message", ChatPayload{MessageID: uuid.New()})
+
+		msgs := drain(t, alice)
+		require.Equal(t, []string{"error"}, messageTypes(msgs))
+		assert.JSONEq(t, `{"code":"message_not_found","message":"message not found"}`, string(msgs[0].Payload))
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		sendMessage(t, a, alice, "edit-chat-message", ChatPayload{MessageID: sent.ID, Content: "fixed"})
+
+		msgs := drain(t, bob)
+		require.Equal(t, []string{"edit-chat-message"}, messageTypes(msgs))
+		got := chatMessages(t, msgs)[0]
+		assert.Equal(t, sent.ID, got.ID)
+		assert.Equal(t, "fixed", got.Content)
+		assert.NotNil(t, got.EditedAt)
+		assert.Equal(t, "fixed", s.messages[sent.ID].Content)
+		drain(t, alice)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		sendMessage(t, a, alice, "delete-chat-message", ChatPayload{MessageID: sent.ID})
+
+		msgs := drain(t, bob)
+		require.Equal(t, []string{"delete-chat-message"}, messageTypes(msgs))
+		assert.JSONEq(t, `{"messageId":"`+sent.ID.String()+`"}`, string(msgs[0].Payload))
+		assert.Empty(t, s.messages)
+	})
+}
+
+func TestJoinRoom_ReplaysChatHistory(t *testing.T) {
+	a, s, eventID, alice, _ := chatRoom(t)
+	for _, content := range []string{"first", "second"} {
+		sendMessage(t, a, alice, "chat-message", ChatPayload{Content: content})
+		time.Sleep(time.Millisecond)
+	}
+	carol := newTestClient("carol")
+	s.users[carol.UserID] = &domain.User{ID: carol.UserID, Name: carol.Name}
+	a.webrtcHub.AddClient(carol)
+	attend(s, eventID, carol)
+
+	sendMessage(t, a, carol, "join-room", JoinPayload{EventID: eventID.String()})
+
+	msgs := drain(t, carol)
+	require.Equal(t, []string{"current-users", "chat-history"}, messageTypes(msgs))
+	var history []domain.EventMessageView
+	require.NoError(t, json.Unmarshal(msgs[1].Payload, &history))
+	require.Len(t, history, 2)
+	assert.Equal(t, "first", history[0].Content)
+	assert.Equal(t, "second", history[1].Content)
+	assert.Equal(t, "Alice", history[1].UserName)
+}
+
+func TestPracticeMessagesHandler(t *testing.T) {
+	a, s, eventID, alice, bob := chatRoom(t)
+	router := gin.New()
+	Register(router, a)
+	sendMessage(t, a, alice, "chat-message", ChatPayload{Content: "https://example.com/notes"})
+	sendMessage(t, a, alice, "leave-room", nil)
+	sendMessage(t, a, bob, "leave-room", nil)
+	token := signTestToken(t, jwt.RegisteredClaims{
+		Subject:   bob.UserID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	s.users[bob.UserID].Email = "bob@example.com"
+	s.users[bob.UserID].Role = domain.UserRoleUser
+
+	w := doRequest(t, router, http.MethodGet, "/v1/practices/"+eventID.String()+"/messages", token, "")
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got domain.Page[*domain.EventMessageView]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, "https://example.com/notes", got.Items[0].Content)
+	assert.Equal(t, "Alice", got.Items[0].UserName)
+}
@@ -38,6 +38,9 @@ func (a *API) EventAttendeeCreateHandler(c *gin.Context) {
 		return
 	}
 
+	// Attendance, punctuality and speaking order are never set by hand
+	attendee.JoinedAt, attendee.LeftAt = nil, nil
+	attendee.Punctuality, attendee.SpeakingOrder = nil, nil
 	if err := a.eventAttendeeRepo.Create(c.Request.Context(), &attendee); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "EventAttendee already exists"})
@@ -46,10 +49,16 @@ func (a *API) EventAttendeeCreateHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	a.pushAgenda(c.Request.Context(), attendee.EventID)
 
 	c.JSON(http.StatusCreated, attendee)
 }
 
+// eventAttendeeRoleRequest is what an emcee may change on an attendee.
+type eventAttendeeRoleRequest struct {
+	Role domain.EventAttendeeRole `json:"role" binding:"required,oneof=member emcee"`
+}
+
 func (a *API) EventAttendeeUpdateHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -58,7 +67,7 @@ func (a *API) EventAttendeeUpdateHandler(c *gin.Context) {
 		return
 	}
 
-	_, err = a.eventAttendeeRepo.GetByID(c.Request.Context(), id)
+	existing, err := a.eventAttendeeRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "EventAttendee not found"})
@@ -68,18 +77,18 @@ func (a *API) EventAttendeeUpdateHandler(c *gin.Context) {
 		return
 	}
 
-	var attendee domain.EventAttendee
-	if err := c.ShouldBindJSON(&attendee); err != nil {
+	var req eventAttendeeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	attendee.ID = id
+	// Only the role changes here: the event and user are fixed, attendance
+	// and punctuality come from the room and the speaking order through
+	// PUT /v1/practices/:id/speaking-order
+	attendee := *existing
+	attendee.Role = req.Role
 	if err := a.eventAttendeeRepo.Update(c.Request.Context(), &attendee); err != nil {
-		if errors.Is(err, domain.ErrDuplicateEntry) {
-			c.JSON(http.StatusConflict, gin.H{"error": "EventAttendee already exists"})
-			return
-		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -89,6 +98,7 @@ func (a *API) EventAttendeeUpdateHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	a.pushAgenda(c.Request.Context(), updated.EventID)
 
 	c.JSON(http.StatusOK, updated)
 }
@@ -101,7 +111,7 @@ func (a *API) EventAttendeeDeleteHandler(c *gin.Context) {
 		return
 	}
 
-	_, err = a.eventAttendeeRepo.GetByID(c.Request.Context(), id)
+	attendee, err := a.eventAttendeeRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "EventAttendee not found"})
@@ -119,6 +129,7 @@ func (a *API) EventAttendeeDeleteHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	a.pushAgenda(c.Request.Context(), attendee.EventID)
 
 	c.Status(http.StatusNoContent)
 }
@@ -131,13 +142,19 @@ func (a *API) EventAttendeeGetByEventHandler(c *gin.Context) {
 		return
 	}
 
-	attendees, err := a.eventAttendeeRepo.GetByEventID(c.Request.Context(), eventID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, attendees)
+	page, err := a.eventAttendeeRepo.ListByEventID(c.Request.Context(), eventID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
 
 func (a *API) EventAttendeeGetByUserHandler(c *gin.Context) {
@@ -148,11 +165,17 @@ func (a *API) EventAttendeeGetByUserHandler(c *gin.Context) {
 		return
 	}
 
-	attendees, err := a.eventAttendeeRepo.GetByUserID(c.Request.Context(), userID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.eventAttendeeRepo.ListByUserID(c.Request.Context(), userID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, attendees)
+	c.JSON(http.StatusOK, page)
 }
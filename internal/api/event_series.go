@@ -0,0 +1,198 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// eventSeriesRequest is the template and schedule of a series. Only the
+// fields of eventSeriesUpdateRequest can change after creation.
+type eventSeriesRequest struct {
+	eventSeriesUpdateRequest
+	GuildID   *uuid.UUID `json:"guild_id"`
+	StartTime time.Time  `json:"start_time" binding:"required"`
+	// Timezone defaults to the creator's timezone
+	Timezone string `json:"timezone"`
+}
+
+type eventSeriesUpdateRequest struct {
+	Title            string           `json:"title"`
+	Description      *string          `json:"description"`
+	ExpectedDuration float64          `json:"expected_duration"`
+	Mode             domain.EventMode `json:"mode"`
+	Note             *string          `json:"note"`
+	RRule            string           `json:"rrule" binding:"required"`
+	ExDates          []time.Time      `json:"exdates"`
+}
+
+// apply copies the request onto series.
+func (r *eventSeriesUpdateRequest) apply(series *domain.EventSeries) {
+	series.Title = r.Title
+	series.Description = r.Description
+	series.ExpectedDuration = r.ExpectedDuration
+	series.Mode = r.Mode
+	if series.Mode == "" {
+		series.Mode = domain.EventModeReport
+	}
+	series.Note = r.Note
+	series.RRule = r.RRule
+	series.ExDates = r.ExDates
+}
+
+// respondSeriesError maps errors of the event series service.
+func respondSeriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event series not found"})
+	case errors.Is(err, domain.ErrInvalidRecurrence):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (a *API) EventSeriesCreateHandler(c *gin.Context) {
+	var req eventSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.GuildID != nil {
+		if _, err := a.guildRepo.GetByID(c.Request.Context(), *req.GuildID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Guild not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	actor := actorFrom(c)
+	if req.Timezone == "" {
+		if user, err := a.userRepo.GetByID(c.Request.Context(), actor.UserID); err == nil {
+			req.Timezone = user.Timezone
+		}
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	series := domain.EventSeries{
+		GuildID:   req.GuildID,
+		StartTime: req.StartTime,
+		Timezone:  req.Timezone,
+	}
+	req.apply(&series)
+
+	// The creator hosts every occurrence
+	if err := a.eventSeries.Create(c.Request.Context(), &series, actor.UserID); err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+func (a *API) EventSeriesGetHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	series, err := a.eventSeriesRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// EventSeriesUpdateHandler edits the whole series. Single occurrences are
+// edited through PUT /v1/practices/:id instead.
+func (a *API) EventSeriesUpdateHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	existing, err := a.eventSeriesRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	var req eventSeriesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated := *existing
+	req.apply(&updated)
+	if err := a.eventSeries.Update(c.Request.Context(), existing, &updated); err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// EventSeriesDeleteHandler ends the series and removes its upcoming
+// occurrences. Past occurrences and occurrences edited on their own remain.
+func (a *API) EventSeriesDeleteHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	if _, err := a.eventSeriesRepo.GetByID(c.Request.Context(), id); err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	if err := a.eventSeries.Delete(c.Request.Context(), id); err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (a *API) EventSeriesEventListHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	if _, err := a.eventSeriesRepo.GetByID(c.Request.Context(), id); err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.eventRepo.ListBySeriesID(c.Request.Context(), id, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSeries(t *testing.T) {
+	f := newPolicyFixture(t)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+
+	body := fmt.Sprintf(`{"title":"weekly","start_time":%q,"timezone":"UTC","rrule":"FREQ=WEEKLY;COUNT=4","guild_id":%q}`,
+		start.Format(time.RFC3339), f.guild)
+	w := doRequest(t, f.router, http.MethodPost, "/v1/event-series", f.tokens["master"], body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var series domain.EventSeries
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &series))
+	seriesPath := "/v1/event-series/" + series.ID.String()
+
+	occurrences := func(t *testing.T) []*domain.Event {
+		t.Helper()
+		w := doRequest(t, f.router, http.MethodGet, seriesPath+"/events", f.tokens["member"], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page domain.Page[*domain.Event]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Items
+	}
+	titles := func(events []*domain.Event) map[string]string {
+		out := map[string]string{}
+		for _, e := range events {
+			out[e.StartTime.UTC().Format(time.DateOnly)] = e.Title
+		}
+		return out
+	}
+	day := func(weeks int) string {
+		return start.AddDate(0, 0, 7*weeks).Format(time.DateOnly)
+	}
+
+	events := occurrences(t)
+	require.Len(t, events, 4)
+	for _, e := range events {
+		assert.Equal(t, f.guild, *e.GuildID)
+		assert.Equal(t, series.ID, *e.SeriesID)
+	}
+
+	t.Run("EditOccurrence", func(t *testing.T) {
+		second := events[1]
+		body := fmt.Sprintf(`{"title":"special","start_time":%q}`, second.StartTime.Format(time.RFC3339))
+		w := doRequest(t, f.router, http.MethodPut, "/v1/practices/"+second.ID.String(), f.tokens["master"], body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.True(t, event.Detached)
+		assert.Equal(t, series.ID, *event.SeriesID)
+	})
+
+	t.Run("EditSeries", func(t *testing.T) {
+		// Dropping the third week and renaming the rest spares the edited occurrence
+		body := fmt.Sprintf(`{"title":"renamed","rrule":"FREQ=WEEKLY;COUNT=4","exdates":[%q]}`,
+			start.AddDate(0, 0, 14).Format(time.RFC3339))
+		w := doRequest(t, f.router, http.MethodPut, seriesPath, f.tokens["master"], body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, map[string]string{
+			day(0): "renamed",
+			day(1): "special",
+			day(3): "renamed",
+		}, titles(occurrences(t)))
+	})
+
+	t.Run("InvalidRule", func(t *testing.T) {
+		w := doRequest(t, f.router, http.MethodPut, seriesPath, f.tokens["master"], `{"title":"daily","rrule":"FREQ=DAILY"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		body := fmt.Sprintf(`{"title":"x","start_time":%q,"timezone":"Nowhere/Land","rrule":"FREQ=WEEKLY"}`, start.Format(time.RFC3339))
+		w = doRequest(t, f.router, http.MethodPost, "/v1/event-series", f.tokens["outsider"], body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		w := doRequest(t, f.router, http.MethodDelete, seriesPath, f.tokens["master"], "")
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+		w = doRequest(t, f.router, http.MethodGet, seriesPath, f.tokens["master"], "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// Only the occurrence edited on its own remains
+		w = doRequest(t, f.router, http.MethodGet, "/v1/practices/"+events[1].ID.String(), f.tokens["master"], "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = doRequest(t, f.router, http.MethodGet, "/v1/practices/"+events[0].ID.String(), f.tokens["master"], "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
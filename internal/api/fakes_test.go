@@ -0,0 +1,960 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+	"jpcorrect-backend/internal/service"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// fakeStore is an in-memory backing store shared by the fake repositories.
+type fakeStore struct {
+	mu             sync.Mutex
+	users          map[uuid.UUID]*domain.User
+	guilds         map[uuid.UUID]*domain.Guild
+	guildAttendees map[uuid.UUID]*domain.GuildAttendee
+	events         map[uuid.UUID]*domain.Event
+	eventAttendees map[uuid.UUID]*domain.EventAttendee
+	transcripts    map[uuid.UUID]*domain.Transcript
+	mistakes       map[uuid.UUID]*domain.Mistake
+	attendanceLogs map[uuid.UUID]*domain.AttendanceLog
+	points         map[uuid.UUID]*domain.PointTransaction
+	invites        map[uuid.UUID]*domain.GuildInvite
+	joinRequests   map[uuid.UUID]*domain.GuildJoinRequest
+	series         map[uuid.UUID]*domain.EventSeries
+	messages       map[uuid.UUID]*domain.EventMessage
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:          map[uuid.UUID]*domain.User{},
+		guilds:         map[uuid.UUID]*domain.Guild{},
+		guildAttendees: map[uuid.UUID]*domain.GuildAttendee{},
+		events:         map[uuid.UUID]*domain.Event{},
+		eventAttendees: map[uuid.UUID]*domain.EventAttendee{},
+		transcripts:    map[uuid.UUID]*domain.Transcript{},
+		mistakes:       map[uuid.UUID]*domain.Mistake{},
+		attendanceLogs: map[uuid.UUID]*domain.AttendanceLog{},
+		points:         map[uuid.UUID]*domain.PointTransaction{},
+		invites:        map[uuid.UUID]*domain.GuildInvite{},
+		joinRequests:   map[uuid.UUID]*domain.GuildJoinRequest{},
+		series:         map[uuid.UUID]*domain.EventSeries{},
+		messages:       map[uuid.UUID]*domain.EventMessage{},
+	}
+}
+
+func getFake[T any](s *fakeStore, m map[uuid.UUID]*T, id uuid.UUID) (*T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := m[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	cp := *v
+	return &cp, nil
+}
+
+func putFake[T any](s *fakeStore, m map[uuid.UUID]*T, id *uuid.UUID, v *T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+	cp := *v
+	m[*id] = &cp
+}
+
+// defaultEventStatus applies the database default of event.status.
+func defaultEventStatus(e *domain.Event) {
+	if e.Status == "" {
+		e.Status = domain.EventStatusScheduled
+	}
+}
+
+func deleteFake[T any](s *fakeStore, m map[uuid.UUID]*T, id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(m, id)
+}
+
+func filterFake[T any](s *fakeStore, m map[uuid.UUID]*T, keep func(*T) bool) []*T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []*T{}
+	for _, v := range m {
+		if keep(v) {
+			cp := *v
+			out = append(out, &cp)
+		}
+	}
+	return out
+}
+
+func pageOf[T any](items []*T) *domain.Page[*T] {
+	return &domain.Page[*T]{Items: items}
+}
+
+type fakeUserRepo struct{ s *fakeStore }
+
+func (r fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	u, err := getFake(r.s, r.s.users, id)
+	if err == nil && u.DeletedAt.Valid {
+		return nil, domain.ErrNotFound
+	}
+	return u, err
+}
+func (r fakeUserRepo) GetDeletedByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	u, err := getFake(r.s, r.s.users, id)
+	if err == nil && !u.DeletedAt.Valid {
+		return nil, domain.ErrNotFound
+	}
+	return u, err
+}
+func (r fakeUserRepo) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	found := filterFake(r.s, r.s.users, func(u *domain.User) bool { return u.Email == email })
+	if len(found) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return found[0], nil
+}
+func (r fakeUserRepo) GetByName(_ context.Context, name string) ([]*domain.User, error) {
+	return filterFake(r.s, r.s.users, func(u *domain.User) bool { return u.Name == name }), nil
+}
+func (r fakeUserRepo) ListByName(ctx context.Context, name string, _ domain.ListOptions) (*domain.Page[*domain.User], error) {
+	users, _ := r.GetByName(ctx, name)
+	return pageOf(users), nil
+}
+func (r fakeUserRepo) Create(_ context.Context, u *domain.User) error {
+	putFake(r.s, r.s.users, &u.ID, u)
+	return nil
+}
+func (r fakeUserRepo) Update(_ context.Context, u *domain.User) error {
+	if existing, err := getFake(r.s, r.s.users, u.ID); err == nil {
+		u.LateStreak, u.Points, u.Level = existing.LateStreak, existing.Points, existing.Level
+	}
+	putFake(r.s, r.s.users, &u.ID, u)
+	return nil
+}
+func (r fakeUserRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.users, id)
+	return nil
+}
+
+type fakeGuildRepo struct{ s *fakeStore }
+
+func (r fakeGuildRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Guild, error) {
+	return getFake(r.s, r.s.guilds, id)
+}
+func (r fakeGuildRepo) List(_ context.Context, opts domain.ListOptions) (*domain.Page[*domain.GuildSummary], error) {
+	q := strings.ToLower(opts.Filters["q"])
+	guilds := filterFake(r.s, r.s.guilds, func(g *domain.Guild) bool {
+		return strings.Contains(strings.ToLower(g.Name+" "+g.Description), q)
+	})
+	members := filterFake(r.s, r.s.guildAttendees, func(a *domain.GuildAttendee) bool { return a.LeftAt == nil })
+	items := make([]*domain.GuildSummary, 0, len(guilds))
+	for _, g := range guilds {
+		summary := &domain.GuildSummary{Guild: *g}
+		for _, a := range members {
+			if a.GuildID == g.ID {
+				summary.MemberCount++
+			}
+		}
+		items = append(items, summary)
+	}
+	return pageOf(items), nil
+}
+func (r fakeGuildRepo) Create(_ context.Context, g *domain.Guild) error {
+	putFake(r.s, r.s.guilds, &g.ID, g)
+	return nil
+}
+func (r fakeGuildRepo) CreateWithMaster(ctx context.Context, g *domain.Guild, masterID uuid.UUID) error {
+	putFake(r.s, r.s.guilds, &g.ID, g)
+	now := time.Now()
+	ga := &domain.GuildAttendee{GuildID: g.ID, UserID: masterID, Role: domain.GuildAttendeeRoleMaster, JoinedAt: &now}
+	putFake(r.s, r.s.guildAttendees, &ga.ID, ga)
+	return nil
+}
+func (r fakeGuildRepo) Update(_ context.Context, g *domain.Guild) error {
+	if existing, err := getFake(r.s, r.s.guilds, g.ID); err == nil {
+		g.Level = existing.Level
+	}
+	putFake(r.s, r.s.guilds, &g.ID, g)
+	return nil
+}
+func (r fakeGuildRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.guilds, id)
+	return nil
+}
+
+type fakeGuildAttendeeRepo struct{ s *fakeStore }
+
+func (r fakeGuildAttendeeRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.GuildAttendee, error) {
+	return getFake(r.s, r.s.guildAttendees, id)
+}
+func (r fakeGuildAttendeeRepo) GetByGuildID(_ context.Context, guildID uuid.UUID) ([]*domain.GuildAttendee, error) {
+	return filterFake(r.s, r.s.guildAttendees, func(a *domain.GuildAttendee) bool { return a.GuildID == guildID }), nil
+}
+func (r fakeGuildAttendeeRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*domain.GuildAttendee, error) {
+	return filterFake(r.s, r.s.guildAttendees, func(a *domain.GuildAttendee) bool { return a.UserID == userID }), nil
+}
+func (r fakeGuildAttendeeRepo) GetByGuildAndUser(_ context.Context, guildID, userID uuid.UUID) (*domain.GuildAttendee, error) {
+	found := filterFake(r.s, r.s.guildAttendees, func(a *domain.GuildAttendee) bool {
+		return a.GuildID == guildID && a.UserID == userID
+	})
+	if len(found) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return found[0], nil
+}
+func (r fakeGuildAttendeeRepo) ListByGuildID(ctx context.Context, guildID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.GuildAttendee], error) {
+	items, _ := r.GetByGuildID(ctx, guildID)
+	return pageOf(items), nil
+}
+func (r fakeGuildAttendeeRepo) ListByUserID(ctx context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.GuildAttendee], error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	return pageOf(items), nil
+}
+func (r fakeGuildAttendeeRepo) Create(_ context.Context, a *domain.GuildAttendee) error {
+	putFake(r.s, r.s.guildAttendees, &a.ID, a)
+	return nil
+}
+func (r fakeGuildAttendeeRepo) Update(_ context.Context, a *domain.GuildAttendee) error {
+	putFake(r.s, r.s.guildAttendees, &a.ID, a)
+	return nil
+}
+func (r fakeGuildAttendeeRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.guildAttendees, id)
+	return nil
+}
+
+type fakeEventRepo struct{ s *fakeStore }
+
+func (r fakeEventRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Event, error) {
+	return getFake(r.s, r.s.events, id)
+}
+func (r fakeEventRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*domain.Event, error) {
+	attending := filterFake(r.s, r.s.eventAttendees, func(a *domain.EventAttendee) bool { return a.UserID == userID })
+	ids := map[uuid.UUID]bool{}
+	for _, a := range attending {
+		ids[a.EventID] = true
+	}
+	return filterFake(r.s, r.s.events, func(e *domain.Event) bool { return ids[e.ID] }), nil
+}
+func (r fakeEventRepo) ListByUserID(ctx context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	return pageOf(items), nil
+}
+func (r fakeEventRepo) ListByGuildID(_ context.Context, guildID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	after, _ := time.Parse(time.RFC3339, opts.Filters["start_after"])
+	before, _ := time.Parse(time.RFC3339, opts.Filters["start_before"])
+	items := filterFake(r.s, r.s.events, func(e *domain.Event) bool {
+		return e.GuildID != nil && *e.GuildID == guildID &&
+			!e.StartTime.Before(after) && (before.IsZero() || e.StartTime.Before(before))
+	})
+	sort.Slice(items, func(i, j int) bool { return items[i].StartTime.Before(items[j].StartTime) })
+	return pageOf(items), nil
+}
+func (r fakeEventRepo) ListBySeriesID(_ context.Context, seriesID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	items := filterFake(r.s, r.s.events, func(e *domain.Event) bool { return e.SeriesID != nil && *e.SeriesID == seriesID })
+	sort.Slice(items, func(i, j int) bool { return items[i].StartTime.Before(items[j].StartTime) })
+	return pageOf(items), nil
+}
+func (r fakeEventRepo) ListFeedByUserID(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Event, error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	items = slices.DeleteFunc(items, func(e *domain.Event) bool { return e.StartTime.Before(since) })
+	sort.Slice(items, func(i, j int) bool { return items[i].StartTime.Before(items[j].StartTime) })
+	return items, nil
+}
+func (r fakeEventRepo) ListFeedByGuildID(_ context.Context, guildID uuid.UUID, since time.Time) ([]*domain.Event, error) {
+	items := filterFake(r.s, r.s.events, func(e *domain.Event) bool {
+		return e.GuildID != nil && *e.GuildID == guildID && !e.StartTime.Before(since)
+	})
+	sort.Slice(items, func(i, j int) bool { return items[i].StartTime.Before(items[j].StartTime) })
+	return items, nil
+}
+func (r fakeEventRepo) Create(_ context.Context, e *domain.Event) error {
+	defaultEventStatus(e)
+	putFake(r.s, r.s.events, &e.ID, e)
+	return nil
+}
+func (r fakeEventRepo) CreateWithEmcee(_ context.Context, e *domain.Event, emceeID uuid.UUID) error {
+	defaultEventStatus(e)
+	putFake(r.s, r.s.events, &e.ID, e)
+	ea := &domain.EventAttendee{EventID: e.ID, UserID: emceeID, Role: domain.EventAttendeeRoleEmcee}
+	putFake(r.s, r.s.eventAttendees, &ea.ID, ea)
+	return nil
+}
+func (r fakeEventRepo) Update(_ context.Context, e *domain.Event) error {
+	putFake(r.s, r.s.events, &e.ID, e)
+	return nil
+}
+func (r fakeEventRepo) Transition(_ context.Context, e *domain.Event, from domain.EventStatus) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.transition(e, from)
+}
+
+// transition mirrors the compare-and-set of the repository; the caller holds
+// the lock.
+func (r fakeEventRepo) transition(e *domain.Event, from domain.EventStatus) error {
+	stored, ok := r.s.events[e.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if stored.Status != from {
+		return domain.ErrInvalidTransition
+	}
+	stored.Status = e.Status
+	stored.StartedAt, stored.EndedAt, stored.ReviewedAt, stored.CancelledAt = e.StartedAt, e.EndedAt, e.ReviewedAt, e.CancelledAt
+	stored.ActualDuration = e.ActualDuration
+	stored.RecordLink = e.RecordLink
+	return nil
+}
+func (r fakeEventRepo) Close(_ context.Context, e *domain.Event, arrivals []domain.Arrival) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.transition(e, domain.EventStatusLive); err != nil {
+		return err
+	}
+	for _, arrival := range arrivals {
+		for _, a := range r.s.eventAttendees {
+			if a.EventID != e.ID || a.UserID != arrival.UserID || a.Punctuality != nil {
+				continue
+			}
+			p := arrival.Punctuality
+			a.Punctuality = &p
+			if u, ok := r.s.users[arrival.UserID]; ok {
+				if p == domain.PunctualityOnTime {
+					u.LateStreak = 0
+				} else {
+					u.LateStreak++
+				}
+			}
+		}
+	}
+	return nil
+}
+func (r fakeEventRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.events, id)
+	return nil
+}
+
+type fakeEventAttendeeRepo struct{ s *fakeStore }
+
+func (r fakeEventAttendeeRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.EventAttendee, error) {
+	return getFake(r.s, r.s.eventAttendees, id)
+}
+func (r fakeEventAttendeeRepo) GetByEventID(_ context.Context, eventID uuid.UUID) ([]*domain.EventAttendee, error) {
+	return filterFake(r.s, r.s.eventAttendees, func(a *domain.EventAttendee) bool { return a.EventID == eventID }), nil
+}
+func (r fakeEventAttendeeRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*domain.EventAttendee, error) {
+	return filterFake(r.s, r.s.eventAttendees, func(a *domain.EventAttendee) bool { return a.UserID == userID }), nil
+}
+func (r fakeEventAttendeeRepo) GetByEventAndUser(_ context.Context, eventID, userID uuid.UUID) (*domain.EventAttendee, error) {
+	found := filterFake(r.s, r.s.eventAttendees, func(a *domain.EventAttendee) bool {
+		return a.EventID == eventID && a.UserID == userID
+	})
+	if len(found) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return found[0], nil
+}
+func (r fakeEventAttendeeRepo) ListByEventID(ctx context.Context, eventID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.EventAttendee], error) {
+	items, _ := r.GetByEventID(ctx, eventID)
+	return pageOf(items), nil
+}
+func (r fakeEventAttendeeRepo) ListByUserID(ctx context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.EventAttendee], error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	return pageOf(items), nil
+}
+func (r fakeEventAttendeeRepo) Create(_ context.Context, a *domain.EventAttendee) error {
+	putFake(r.s, r.s.eventAttendees, &a.ID, a)
+	return nil
+}
+func (r fakeEventAttendeeRepo) Update(_ context.Context, a *domain.EventAttendee) error {
+	putFake(r.s, r.s.eventAttendees, &a.ID, a)
+	return nil
+}
+func (r fakeEventAttendeeRepo) SetSpeakingOrder(_ context.Context, eventID uuid.UUID, userIDs []uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	attending := map[uuid.UUID]*domain.EventAttendee{}
+	for _, a := range r.s.eventAttendees {
+		if a.EventID == eventID {
+			attending[a.UserID] = a
+			a.SpeakingOrder = nil
+		}
+	}
+	for i, id := range userIDs {
+		a, ok := attending[id]
+		if !ok {
+			return domain.ErrNotFound
+		}
+		n := i + 1
+		a.SpeakingOrder = &n
+	}
+	return nil
+}
+func (r fakeEventAttendeeRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.eventAttendees, id)
+	return nil
+}
+
+type fakeTranscriptRepo struct{ s *fakeStore }
+
+func (r fakeTranscriptRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Transcript, error) {
+	return getFake(r.s, r.s.transcripts, id)
+}
+func (r fakeTranscriptRepo) GetByEventID(_ context.Context, eventID uuid.UUID) ([]*domain.Transcript, error) {
+	return filterFake(r.s, r.s.transcripts, func(t *domain.Transcript) bool { return t.EventID == eventID }), nil
+}
+func (r fakeTranscriptRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*domain.Transcript, error) {
+	return filterFake(r.s, r.s.transcripts, func(t *domain.Transcript) bool { return t.UserID == userID }), nil
+}
+func (r fakeTranscriptRepo) ListByEventID(ctx context.Context, eventID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Transcript], error) {
+	items, _ := r.GetByEventID(ctx, eventID)
+	return pageOf(items), nil
+}
+func (r fakeTranscriptRepo) ListByUserID(ctx context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Transcript], error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	return pageOf(items), nil
+}
+func (r fakeTranscriptRepo) Create(_ context.Context, t *domain.Transcript) error {
+	putFake(r.s, r.s.transcripts, &t.ID, t)
+	return nil
+}
+func (r fakeTranscriptRepo) Update(_ context.Context, t *domain.Transcript) error {
+	putFake(r.s, r.s.transcripts, &t.ID, t)
+	return nil
+}
+func (r fakeTranscriptRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.transcripts, id)
+	return nil
+}
+
+type fakeMistakeRepo struct{ s *fakeStore }
+
+func (r fakeMistakeRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Mistake, error) {
+	return getFake(r.s, r.s.mistakes, id)
+}
+func (r fakeMistakeRepo) GetByEventID(_ context.Context, eventID uuid.UUID) ([]*domain.Mistake, error) {
+	return filterFake(r.s, r.s.mistakes, func(m *domain.Mistake) bool { return m.EventID == eventID }), nil
+}
+func (r fakeMistakeRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*domain.Mistake, error) {
+	return filterFake(r.s, r.s.mistakes, func(m *domain.Mistake) bool { return m.UserID == userID }), nil
+}
+func (r fakeMistakeRepo) ListByEventID(ctx context.Context, eventID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Mistake], error) {
+	items, _ := r.GetByEventID(ctx, eventID)
+	return pageOf(items), nil
+}
+func (r fakeMistakeRepo) ListByUserID(ctx context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.Mistake], error) {
+	items, _ := r.GetByUserID(ctx, userID)
+	return pageOf(items), nil
+}
+func (r fakeMistakeRepo) Create(_ context.Context, m *domain.Mistake) error {
+	putFake(r.s, r.s.mistakes, &m.ID, m)
+	return nil
+}
+func (r fakeMistakeRepo) Update(_ context.Context, m *domain.Mistake) error {
+	putFake(r.s, r.s.mistakes, &m.ID, m)
+	return nil
+}
+func (r fakeMistakeRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.mistakes, id)
+	return nil
+}
+
+type fakeAttendanceRepo struct{ s *fakeStore }
+
+func (r fakeAttendanceRepo) Record(_ context.Context, e *domain.AttendanceLog) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, a := range r.s.eventAttendees {
+		if a.EventID != e.EventID || a.UserID != e.UserID {
+			continue
+		}
+		at := e.At
+		switch e.Action {
+		case domain.AttendanceActionJoin:
+			if a.JoinedAt == nil {
+				a.JoinedAt = &at
+			}
+		case domain.AttendanceActionLeave:
+			if a.LeftAt == nil || at.After(*a.LeftAt) {
+				a.LeftAt = &at
+			}
+		}
+		if e.ID == uuid.Nil {
+			e.ID = uuid.New()
+		}
+		cp := *e
+		r.s.attendanceLogs[e.ID] = &cp
+		return nil
+	}
+	return domain.ErrNotFound
+}
+func (r fakeAttendanceRepo) ListByEventID(_ context.Context, eventID uuid.UUID) ([]*domain.AttendanceLog, error) {
+	entries := filterFake(r.s, r.s.attendanceLogs, func(e *domain.AttendanceLog) bool { return e.EventID == eventID })
+	sort.Slice(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	return entries, nil
+}
+
+func (r fakeAttendanceRepo) ListRecordsByUserID(_ context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.AttendanceRecord], error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	records := []*domain.AttendanceRecord{}
+	for _, a := range r.s.eventAttendees {
+		e, ok := r.s.events[a.EventID]
+		if a.UserID != userID || a.Punctuality == nil || !ok {
+			continue
+		}
+		records = append(records, &domain.AttendanceRecord{
+			ID: a.ID, EventID: a.EventID, UserID: a.UserID, Role: a.Role,
+			Title: e.Title, StartTime: e.StartTime,
+			JoinedAt: a.JoinedAt, LeftAt: a.LeftAt, Punctuality: *a.Punctuality,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].StartTime.After(records[j].StartTime) })
+	if opts.Limit > 0 && len(records) > opts.Limit {
+		records = records[:opts.Limit]
+	}
+	return pageOf(records), nil
+}
+
+// fakeLeaderboardRepo ranks by all time User.Points regardless of the window.
+type fakeLeaderboardRepo struct{ s *fakeStore }
+
+func (r fakeLeaderboardRepo) ListGlobal(_ context.Context, _ *time.Time, _ domain.ListOptions) (*domain.Page[*domain.LeaderboardEntry], error) {
+	return r.rank(func(*domain.User) bool { return true }), nil
+}
+
+func (r fakeLeaderboardRepo) ListByGuildID(_ context.Context, guildID uuid.UUID, _ *time.Time, _ domain.ListOptions) (*domain.Page[*domain.LeaderboardEntry], error) {
+	members := filterFake(r.s, r.s.guildAttendees, func(ga *domain.GuildAttendee) bool {
+		return ga.GuildID == guildID && ga.LeftAt == nil
+	})
+	return r.rank(func(u *domain.User) bool {
+		return slices.ContainsFunc(members, func(ga *domain.GuildAttendee) bool { return ga.UserID == u.ID })
+	}), nil
+}
+
+func (r fakeLeaderboardRepo) rank(keep func(*domain.User) bool) *domain.Page[*domain.LeaderboardEntry] {
+	users := filterFake(r.s, r.s.users, func(u *domain.User) bool { return u.Points > 0 && keep(u) })
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Points != users[j].Points {
+			return users[i].Points > users[j].Points
+		}
+		return users[i].ID.String() < users[j].ID.String()
+	})
+	entries := []*domain.LeaderboardEntry{}
+	for i, u := range users {
+		rank := i + 1
+		if i > 0 && u.Points == users[i-1].Points {
+			rank = entries[i-1].Rank
+		}
+		entries = append(entries, &domain.LeaderboardEntry{
+			Rank: rank, UserID: u.ID, Name: u.Name, Level: u.Level, Points: u.Points, Position: i + 1,
+		})
+	}
+	return pageOf(entries)
+}
+
+// fakePointRepo keeps user points and levels in step with the ledger; guild
+// levels are not derived.
+type fakePointRepo struct{ s *fakeStore }
+
+func (r fakePointRepo) Award(_ context.Context, entries []*domain.PointTransaction, curves domain.LevelCurves) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, entry := range entries {
+		duplicate := false
+		for _, p := range r.s.points {
+			if p.UserID == entry.UserID && p.Reason == entry.Reason && *p.EventID == *entry.EventID {
+				duplicate = true
+			}
+		}
+		if duplicate {
+			continue
+		}
+		entry.ID = uuid.New()
+		cp := *entry
+		r.s.points[entry.ID] = &cp
+		if u, ok := r.s.users[entry.UserID]; ok {
+			u.Points += entry.Amount
+			u.Level = curves.User.Level(u.Points)
+		}
+	}
+	return nil
+}
+
+func (r fakePointRepo) Recompute(_ context.Context, curves domain.LevelCurves) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		u.Points = 0
+		for _, p := range r.s.points {
+			if p.UserID == u.ID {
+				u.Points += p.Amount
+			}
+		}
+		u.Level = curves.User.Level(u.Points)
+	}
+	return nil
+}
+
+func (r fakePointRepo) HasReason(_ context.Context, userID uuid.UUID, reason domain.PointReason, eventIDs []uuid.UUID) (bool, error) {
+	found := filterFake(r.s, r.s.points, func(p *domain.PointTransaction) bool {
+		return p.UserID == userID && p.Reason == reason && slices.Contains(eventIDs, *p.EventID)
+	})
+	return len(found) > 0, nil
+}
+
+func (r fakePointRepo) ListByUserID(_ context.Context, userID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.PointTransaction], error) {
+	return pageOf(filterFake(r.s, r.s.points, func(p *domain.PointTransaction) bool { return p.UserID == userID })), nil
+}
+
+type fakeGuildMembershipRepo struct{ s *fakeStore }
+
+func (r fakeGuildMembershipRepo) CreateInvite(_ context.Context, i *domain.GuildInvite) error {
+	putFake(r.s, r.s.invites, &i.ID, i)
+	return nil
+}
+func (r fakeGuildMembershipRepo) GetInviteByID(_ context.Context, id uuid.UUID) (*domain.GuildInvite, error) {
+	return getFake(r.s, r.s.invites, id)
+}
+func (r fakeGuildMembershipRepo) GetInviteByCode(_ context.Context, code string) (*domain.GuildInvite, error) {
+	found := filterFake(r.s, r.s.invites, func(i *domain.GuildInvite) bool { return i.Code == code })
+	if len(found) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return found[0], nil
+}
+func (r fakeGuildMembershipRepo) ListInvitesByGuildID(_ context.Context, guildID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.GuildInvite], error) {
+	return pageOf(filterFake(r.s, r.s.invites, func(i *domain.GuildInvite) bool { return i.GuildID == guildID })), nil
+}
+func (r fakeGuildMembershipRepo) RevokeInvite(_ context.Context, id uuid.UUID, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	i, ok := r.s.invites[id]
+	if !ok || i.RevokedAt != nil {
+		return domain.ErrNotFound
+	}
+	i.RevokedAt = &at
+	return nil
+}
+func (r fakeGuildMembershipRepo) RedeemInvite(_ context.Context, inviteID, userID uuid.UUID, at time.Time) (*domain.GuildAttendee, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	i, ok := r.s.invites[inviteID]
+	if !ok || !i.Usable(at) {
+		return nil, domain.ErrInviteUnavailable
+	}
+	i.Uses++
+	return r.join(i.GuildID, userID, at)
+}
+func (r fakeGuildMembershipRepo) CreateJoinRequest(_ context.Context, req *domain.GuildJoinRequest) error {
+	pending := filterFake(r.s, r.s.joinRequests, func(j *domain.GuildJoinRequest) bool {
+		return j.GuildID == req.GuildID && j.UserID == req.UserID && j.Status == domain.GuildJoinRequestStatusPending
+	})
+	if len(pending) > 0 {
+		return domain.ErrDuplicateEntry
+	}
+	putFake(r.s, r.s.joinRequests, &req.ID, req)
+	return nil
+}
+func (r fakeGuildMembershipRepo) GetJoinRequestByID(_ context.Context, id uuid.UUID) (*domain.GuildJoinRequest, error) {
+	return getFake(r.s, r.s.joinRequests, id)
+}
+func (r fakeGuildMembershipRepo) ListJoinRequestsByGuildID(_ context.Context, guildID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.GuildJoinRequest], error) {
+	return pageOf(filterFake(r.s, r.s.joinRequests, func(j *domain.GuildJoinRequest) bool { return j.GuildID == guildID })), nil
+}
+func (r fakeGuildMembershipRepo) ResolveJoinRequest(_ context.Context, req *domain.GuildJoinRequest) (*domain.GuildAttendee, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	stored, ok := r.s.joinRequests[req.ID]
+	if !ok || stored.Status != domain.GuildJoinRequestStatusPending {
+		return nil, domain.ErrRequestResolved
+	}
+	cp := *req
+	r.s.joinRequests[req.ID] = &cp
+	if req.Status != domain.GuildJoinRequestStatusApproved {
+		return nil, nil
+	}
+	return r.join(req.GuildID, req.UserID, *req.ReviewedAt)
+}
+func (r fakeGuildMembershipRepo) Leave(_ context.Context, attendeeID uuid.UUID, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.guildAttendees[attendeeID]
+	if !ok || a.LeftAt != nil {
+		return domain.ErrNotFound
+	}
+	if a.Role == domain.GuildAttendeeRoleMaster && !r.hasOtherMaster(a) {
+		return domain.ErrLastMaster
+	}
+	a.LeftAt = &at
+	return nil
+}
+func (r fakeGuildMembershipRepo) ChangeRole(_ context.Context, attendeeID uuid.UUID, role domain.GuildAttendeeRole) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.guildAttendees[attendeeID]
+	if !ok || a.LeftAt != nil {
+		return domain.ErrNotFound
+	}
+	if a.Role == domain.GuildAttendeeRoleMaster && role != domain.GuildAttendeeRoleMaster && !r.hasOtherMaster(a) {
+		return domain.ErrLastMaster
+	}
+	a.Role = role
+	return nil
+}
+
+// join and hasOtherMaster expect r.s.mu to be held.
+func (r fakeGuildMembershipRepo) join(guildID, userID uuid.UUID, at time.Time) (*domain.GuildAttendee, error) {
+	for _, a := range r.s.guildAttendees {
+		if a.GuildID != guildID || a.UserID != userID {
+			continue
+		}
+		if a.LeftAt == nil {
+			return nil, domain.ErrAlreadyMember
+		}
+		a.Role = domain.GuildAttendeeRoleMember
+		a.JoinedAt = &at
+		a.LeftAt = nil
+		cp := *a
+		return &cp, nil
+	}
+	a := &domain.GuildAttendee{ID: uuid.New(), GuildID: guildID, UserID: userID, Role: domain.GuildAttendeeRoleMember, JoinedAt: &at}
+	r.s.guildAttendees[a.ID] = a
+	cp := *a
+	return &cp, nil
+}
+func (r fakeGuildMembershipRepo) hasOtherMaster(a *domain.GuildAttendee) bool {
+	for _, other := range r.s.guildAttendees {
+		if other.GuildID == a.GuildID && other.ID != a.ID && other.Role == domain.GuildAttendeeRoleMaster && other.LeftAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeEventSeriesRepo struct{ s *fakeStore }
+
+func (r fakeEventSeriesRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.EventSeries, error) {
+	return getFake(r.s, r.s.series, id)
+}
+func (r fakeEventSeriesRepo) ListDue(_ context.Context, before time.Time) ([]*domain.EventSeries, error) {
+	return filterFake(r.s, r.s.series, func(es *domain.EventSeries) bool {
+		return es.MaterializedUntil == nil || es.MaterializedUntil.Before(before)
+	}), nil
+}
+func (r fakeEventSeriesRepo) Create(_ context.Context, es *domain.EventSeries) error {
+	putFake(r.s, r.s.series, &es.ID, es)
+	return nil
+}
+func (r fakeEventSeriesRepo) Materialize(_ context.Context, es *domain.EventSeries, occurrences []*domain.Event, until time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, o := range occurrences {
+		if r.hasOccurrence(es.ID, *o.OccurrenceStart) {
+			continue
+		}
+		o.ID = uuid.New()
+		defaultEventStatus(o)
+		cp := *o
+		r.s.events[o.ID] = &cp
+		ea := &domain.EventAttendee{ID: uuid.New(), EventID: o.ID, UserID: es.CreatedBy, Role: domain.EventAttendeeRoleEmcee}
+		r.s.eventAttendees[ea.ID] = ea
+	}
+	r.s.series[es.ID].MaterializedUntil = &until
+	return nil
+}
+func (r fakeEventSeriesRepo) Update(_ context.Context, es *domain.EventSeries, from time.Time, removed []time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	cp := *es
+	r.s.series[es.ID] = &cp
+	for id, e := range r.s.events {
+		if e.SeriesID == nil || *e.SeriesID != es.ID || e.Detached || e.StartTime.Before(from) {
+			continue
+		}
+		if slices.ContainsFunc(removed, e.OccurrenceStart.Equal) {
+			delete(r.s.events, id)
+			continue
+		}
+		e.Title, e.Description, e.ExpectedDuration, e.Mode, e.Note = es.Title, es.Description, es.ExpectedDuration, es.Mode, es.Note
+	}
+	return nil
+}
+func (r fakeEventSeriesRepo) Delete(_ context.Context, id uuid.UUID, from time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for eventID, e := range r.s.events {
+		if e.SeriesID != nil && *e.SeriesID == id && !e.Detached && !e.StartTime.Before(from) {
+			delete(r.s.events, eventID)
+		}
+	}
+	delete(r.s.series, id)
+	return nil
+}
+
+// hasOccurrence expects s.mu to be held.
+func (r fakeEventSeriesRepo) hasOccurrence(seriesID uuid.UUID, start time.Time) bool {
+	for _, e := range r.s.events {
+		if e.SeriesID != nil && *e.SeriesID == seriesID && e.OccurrenceStart.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeEventMessageRepo struct{ s *fakeStore }
+
+// view expects s.mu to be held.
+func (r fakeEventMessageRepo) view(m *domain.EventMessage) *domain.EventMessageView {
+	v := &domain.EventMessageView{EventMessage: *m}
+	if u, ok := r.s.users[m.UserID]; ok {
+		v.UserName = u.Name
+	}
+	return v
+}
+
+// views returns the messages of the event, oldest first.
+func (r fakeEventMessageRepo) views(eventID uuid.UUID) []*domain.EventMessageView {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := []*domain.EventMessageView{}
+	for _, m := range r.s.messages {
+		if m.EventID == eventID {
+			out = append(out, r.view(m))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (r fakeEventMessageRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.EventMessageView, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	m, ok := r.s.messages[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return r.view(m), nil
+}
+func (r fakeEventMessageRepo) ListRecent(_ context.Context, eventID uuid.UUID, limit int) ([]*domain.EventMessageView, error) {
+	out := r.views(eventID)
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+func (r fakeEventMessageRepo) ListByEventID(_ context.Context, eventID uuid.UUID, _ domain.ListOptions) (*domain.Page[*domain.EventMessageView], error) {
+	return pageOf(r.views(eventID)), nil
+}
+func (r fakeEventMessageRepo) Create(_ context.Context, m *domain.EventMessage) error {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	putFake(r.s, r.s.messages, &m.ID, m)
+	return nil
+}
+func (r fakeEventMessageRepo) Update(_ context.Context, m *domain.EventMessage) error {
+	putFake(r.s, r.s.messages, &m.ID, m)
+	return nil
+}
+func (r fakeEventMessageRepo) Delete(_ context.Context, id uuid.UUID) error {
+	deleteFake(r.s, r.s.messages, id)
+	return nil
+}
+
+var testJWTSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestAPI builds an API backed by fake repositories whose JWKS trusts
+// tokens signed by signTestToken.
+func newTestAPI(t *testing.T) (*API, *fakeStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	set := fmt.Sprintf(`{"keys":[{"kty":"oct","kid":"test","alg":"HS256","k":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(testJWTSecret))
+	kf, err := keyfunc.NewJWKSetJSON(json.RawMessage(set))
+	if err != nil {
+		t.Fatalf("failed to build test JWKS: %v", err)
+	}
+
+	s := newFakeStore()
+	a := &API{
+		jwksCache:           kf,
+		userRepo:            fakeUserRepo{s},
+		guildRepo:           fakeGuildRepo{s},
+		guildAttendeeRepo:   fakeGuildAttendeeRepo{s},
+		eventRepo:           fakeEventRepo{s},
+		eventAttendeeRepo:   fakeEventAttendeeRepo{s},
+		transcriptRepo:      fakeTranscriptRepo{s},
+		mistakeRepo:         fakeMistakeRepo{s},
+		attendanceRepo:      fakeAttendanceRepo{s},
+		pointRepo:           fakePointRepo{s},
+		leaderboardRepo:     fakeLeaderboardRepo{s},
+		guildMembershipRepo: fakeGuildMembershipRepo{s},
+		eventSeriesRepo:     fakeEventSeriesRepo{s},
+		eventMessageRepo:    fakeEventMessageRepo{s},
+		icalSecret:          []byte("test-ical-secret"),
+		punctuality:         service.NewPunctualityService(fakeEventRepo{s}, fakeEventAttendeeRepo{s}, service.DefaultLateGrace),
+		points:              service.NewPointsService(fakePointRepo{s}, fakeEventAttendeeRepo{s}, fakeMistakeRepo{s}, fakeAttendanceRepo{s}, service.DefaultPointRules, service.DefaultLevelCurves),
+		guildMembership:     service.NewGuildMembershipService(fakeGuildMembershipRepo{s}, fakeGuildAttendeeRepo{s}),
+		eventSeries:         service.NewEventSeriesService(fakeEventSeriesRepo{s}, service.DefaultSeriesHorizon),
+		agenda:              service.NewAgendaService(fakeEventRepo{s}, fakeEventAttendeeRepo{s}, fakeUserRepo{s}, fakeMistakeRepo{s}),
+		webrtcHub:           NewHub(),
+		rateLimiter:         NewRateLimiter(10*time.Second, 15),
+		pongWait:            wsPongWait,
+		pingPeriod:          wsPingPeriod,
+		sessions:            newResumeRegistry(),
+		resumeGrace:         wsResumeGrace,
+	}
+	t.Cleanup(a.Close)
+	return a, s
+}
+
+func signTestToken(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(testJWTSecret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func doRequest(t *testing.T, r http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
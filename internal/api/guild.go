@@ -31,6 +31,24 @@ func (a *API) GuildGetHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, guild)
 }
 
+// GuildListHandler searches guilds to join, see GuildRepository.List for
+// the filters and sorts.
+func (a *API) GuildListHandler(c *gin.Context) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.guildRepo.List(c.Request.Context(), opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (a *API) GuildCreateHandler(c *gin.Context) {
 	var guild domain.Guild
 	if err := c.ShouldBindJSON(&guild); err != nil {
@@ -38,7 +56,11 @@ func (a *API) GuildCreateHandler(c *gin.Context) {
 		return
 	}
 
-	if err := a.guildRepo.Create(c.Request.Context(), &guild); err != nil {
+	// Derived from the members' points, see PointsService
+	guild.Level = 0
+
+	// The creator leads the guild
+	if err := a.guildRepo.CreateWithMaster(c.Request.Context(), &guild, actorFrom(c).UserID); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Guild already exists"})
 			return
@@ -163,6 +185,12 @@ func (a *API) GuildAttendeeCreateHandler(c *gin.Context) {
 	c.JSON(http.StatusCreated, attendee)
 }
 
+// guildAttendeeRoleRequest is the only change PUT /v1/guild-attendees/:id
+// accepts; joining and leaving go through invites, join requests and DELETE.
+type guildAttendeeRoleRequest struct {
+	Role domain.GuildAttendeeRole `json:"role" binding:"required,oneof=member master"`
+}
+
 func (a *API) GuildAttendeeUpdateHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -171,29 +199,14 @@ func (a *API) GuildAttendeeUpdateHandler(c *gin.Context) {
 		return
 	}
 
-	_, err = a.guildAttendeeRepo.GetByID(c.Request.Context(), id)
-	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "GuildAttendee not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var attendee domain.GuildAttendee
-	if err := c.ShouldBindJSON(&attendee); err != nil {
+	var req guildAttendeeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	attendee.ID = id
-	if err := a.guildAttendeeRepo.Update(c.Request.Context(), &attendee); err != nil {
-		if errors.Is(err, domain.ErrDuplicateEntry) {
-			c.JSON(http.StatusConflict, gin.H{"error": "GuildAttendee already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := a.guildMembership.ChangeRole(c.Request.Context(), id, req.Role); err != nil {
+		respondMembershipError(c, err, "GuildAttendee not found")
 		return
 	}
 
@@ -206,6 +219,8 @@ func (a *API) GuildAttendeeUpdateHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, updated)
 }
 
+// GuildAttendeeDeleteHandler ends the membership: the member leaves or a
+// master removes them. The record is kept with LeftAt set.
 func (a *API) GuildAttendeeDeleteHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -214,22 +229,8 @@ func (a *API) GuildAttendeeDeleteHandler(c *gin.Context) {
 		return
 	}
 
-	_, err = a.guildAttendeeRepo.GetByID(c.Request.Context(), id)
-	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "GuildAttendee not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if err := a.guildAttendeeRepo.Delete(c.Request.Context(), id); err != nil {
-		if errors.Is(err, domain.ErrHasRelatedRecords) {
-			c.JSON(http.StatusConflict, gin.H{"error": "cannot delete guild attendee: has related records"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := a.guildMembership.Leave(c.Request.Context(), id); err != nil {
+		respondMembershipError(c, err, "GuildAttendee not found")
 		return
 	}
 
@@ -244,13 +245,19 @@ func (a *API) GuildAttendeeGetByGuildHandler(c *gin.Context) {
 		return
 	}
 
-	attendees, err := a.guildAttendeeRepo.GetByGuildID(c.Request.Context(), guildID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.guildAttendeeRepo.ListByGuildID(c.Request.Context(), guildID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, attendees)
+	c.JSON(http.StatusOK, page)
 }
 
 func (a *API) GuildAttendeeGetByUserHandler(c *gin.Context) {
@@ -261,11 +268,17 @@ func (a *API) GuildAttendeeGetByUserHandler(c *gin.Context) {
 		return
 	}
 
-	attendees, err := a.guildAttendeeRepo.GetByUserID(c.Request.Context(), userID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.guildAttendeeRepo.ListByUserID(c.Request.Context(), userID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, attendees)
+	c.JSON(http.StatusOK, page)
 }
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type guildInviteRequest struct {
+	// ExpiresAt defaults to service.DefaultInviteTTL from now
+	ExpiresAt *time.Time `json:"expires_at"`
+	// MaxUses is unlimited when omitted
+	MaxUses *int `json:"max_uses" binding:"omitempty,min=1"`
+}
+
+type guildJoinRequestRequest struct {
+	Message string `json:"message"`
+}
+
+// respondMembershipError maps errors of the guild membership service.
+func respondMembershipError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, domain.ErrInviteUnavailable):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyMember),
+		errors.Is(err, domain.ErrRequestResolved),
+		errors.Is(err, domain.ErrLastMaster):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDuplicateEntry):
+		c.JSON(http.StatusConflict, gin.H{"error": "join request already pending"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (a *API) GuildInviteCreateHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	var req guildInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	invite := &domain.GuildInvite{
+		GuildID:   id,
+		CreatedBy: actorFrom(c).UserID,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+	}
+	if err := a.guildMembership.CreateInvite(c.Request.Context(), invite); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+func (a *API) GuildInviteListHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.guildMembershipRepo.ListInvitesByGuildID(c.Request.Context(), id, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (a *API) GuildInviteRevokeHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+	inviteID, err := uuid.Parse(c.Param("invite_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	if err := a.guildMembership.RevokeInvite(c.Request.Context(), id, inviteID); err != nil {
+		respondMembershipError(c, err, "Invite not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GuildInviteAcceptHandler joins the caller to the invite's guild.
+func (a *API) GuildInviteAcceptHandler(c *gin.Context) {
+	attendee, err := a.guildMembership.AcceptInvite(c.Request.Context(), c.Param("code"), actorFrom(c).UserID)
+	if err != nil {
+		respondMembershipError(c, err, "Invite not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, attendee)
+}
+
+func (a *API) GuildJoinRequestCreateHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	var req guildJoinRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := a.guildRepo.GetByID(c.Request.Context(), id); err != nil {
+		respondMembershipError(c, err, "Guild not found")
+		return
+	}
+
+	request, err := a.guildMembership.RequestToJoin(c.Request.Context(), id, actorFrom(c).UserID, req.Message)
+	if err != nil {
+		respondMembershipError(c, err, "Guild not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+func (a *API) GuildJoinRequestListHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.guildMembershipRepo.ListJoinRequestsByGuildID(c.Request.Context(), id, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (a *API) GuildJoinRequestApproveHandler(c *gin.Context) {
+	a.resolveJoinRequest(c, true)
+}
+
+func (a *API) GuildJoinRequestRejectHandler(c *gin.Context) {
+	a.resolveJoinRequest(c, false)
+}
+
+func (a *API) resolveJoinRequest(c *gin.Context, approve bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+	requestID, err := uuid.Parse(c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	request, _, err := a.guildMembership.ResolveJoinRequest(c.Request.Context(), id, requestID, actorFrom(c).UserID, approve)
+	if err != nil {
+		respondMembershipError(c, err, "Join request not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
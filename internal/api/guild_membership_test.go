@@ -0,0 +1,302 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type membershipFixture struct {
+	router   *gin.Engine
+	store    *fakeStore
+	guild    uuid.UUID
+	masterGA uuid.UUID
+	users    map[string]uuid.UUID
+	tokens   map[string]string
+}
+
+func newMembershipFixture(t *testing.T) *membershipFixture {
+	t.Helper()
+	a, s := newTestAPI(t)
+	f := &membershipFixture{
+		router: gin.New(),
+		store:  s,
+		guild:  uuid.New(),
+		users:  map[string]uuid.UUID{},
+		tokens: map[string]string{},
+	}
+	Register(f.router, a)
+
+	for _, name := range []string{"master", "alice", "bob"} {
+		u := &domain.User{ID: uuid.New(), Name: name, Email: name + "@example.com", Role: domain.UserRoleUser}
+		s.users[u.ID] = u
+		f.users[name] = u.ID
+		f.tokens[name] = signTestToken(t, jwt.RegisteredClaims{
+			Subject:   u.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+	}
+	s.guilds[f.guild] = &domain.Guild{ID: f.guild, Name: "guild"}
+	f.masterGA = uuid.New()
+	s.guildAttendees[f.masterGA] = &domain.GuildAttendee{ID: f.masterGA, GuildID: f.guild, UserID: f.users["master"], Role: domain.GuildAttendeeRoleMaster}
+	return f
+}
+
+func (f *membershipFixture) path(rest string) string {
+	return "/v1/guilds/" + f.guild.String() + rest
+}
+
+func (f *membershipFixture) createInvite(t *testing.T, body string) *domain.GuildInvite {
+	t.Helper()
+	w := doRequest(t, f.router, http.MethodPost, f.path("/invites"), f.tokens["master"], body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var invite domain.GuildInvite
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invite))
+	return &invite
+}
+
+func TestGuildInvite(t *testing.T) {
+	t.Run("CreateDefaults", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		invite := f.createInvite(t, "")
+
+		assert.Len(t, invite.Code, 12)
+		assert.Equal(t, f.users["master"], invite.CreatedBy)
+		require.NotNil(t, invite.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *invite.ExpiresAt, time.Minute)
+		assert.Nil(t, invite.MaxUses)
+	})
+
+	t.Run("CreateRejectsPastExpiry", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		body := fmt.Sprintf(`{"expires_at":%q}`, time.Now().Add(-time.Hour).Format(time.RFC3339))
+
+		w := doRequest(t, f.router, http.MethodPost, f.path("/invites"), f.tokens["master"], body)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("AcceptJoinsAsMember", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		invite := f.createInvite(t, `{"max_uses":1}`)
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["alice"], "")
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var attendee domain.GuildAttendee
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attendee))
+		assert.Equal(t, f.guild, attendee.GuildID)
+		assert.Equal(t, f.users["alice"], attendee.UserID)
+		assert.Equal(t, domain.GuildAttendeeRoleMember, attendee.Role)
+		assert.NotNil(t, attendee.JoinedAt)
+		assert.Equal(t, 1, f.store.invites[invite.ID].Uses)
+	})
+
+	t.Run("MaxUsesReached", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		invite := f.createInvite(t, `{"max_uses":1}`)
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["alice"], "")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["bob"], "")
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		invite := f.createInvite(t, "")
+		past := time.Now().Add(-time.Minute)
+		f.store.invites[invite.ID].ExpiresAt = &past
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["alice"], "")
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		invite := f.createInvite(t, "")
+		w := doRequest(t, f.router, http.MethodDelete, f.path("/invites/"+invite.ID.String()), f.tokens["master"], "")
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+		w = doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["alice"], "")
+
+		assert.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("AlreadyMember", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		invite := f.createInvite(t, "")
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["master"], "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, 0, f.store.invites[invite.ID].Uses)
+	})
+
+	t.Run("UnknownCode", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/unknown/accept", f.tokens["alice"], "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGuildJoinRequest(t *testing.T) {
+	request := func(t *testing.T, f *membershipFixture, actor string) *domain.GuildJoinRequest {
+		t.Helper()
+		w := doRequest(t, f.router, http.MethodPost, f.path("/join-requests"), f.tokens[actor], `{"message":"let me in"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var req domain.GuildJoinRequest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &req))
+		return &req
+	}
+
+	t.Run("Approve", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		req := request(t, f, "alice")
+		assert.Equal(t, domain.GuildJoinRequestStatusPending, req.Status)
+
+		w := doRequest(t, f.router, http.MethodPost, f.path("/join-requests/"+req.ID.String()+"/approve"), f.tokens["master"], "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resolved domain.GuildJoinRequest
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resolved))
+		assert.Equal(t, domain.GuildJoinRequestStatusApproved, resolved.Status)
+		assert.Equal(t, f.users["master"], *resolved.ReviewedBy)
+		attendee, err := fakeGuildAttendeeRepo{f.store}.GetByGuildAndUser(t.Context(), f.guild, f.users["alice"])
+		require.NoError(t, err)
+		assert.Equal(t, domain.GuildAttendeeRoleMember, attendee.Role)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		req := request(t, f, "alice")
+
+		w := doRequest(t, f.router, http.MethodPost, f.path("/join-requests/"+req.ID.String()+"/reject"), f.tokens["master"], "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		_, err := fakeGuildAttendeeRepo{f.store}.GetByGuildAndUser(t.Context(), f.guild, f.users["alice"])
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+
+		w = doRequest(t, f.router, http.MethodPost, f.path("/join-requests/"+req.ID.String()+"/approve"), f.tokens["master"], "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("DuplicatePending", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		request(t, f, "alice")
+
+		w := doRequest(t, f.router, http.MethodPost, f.path("/join-requests"), f.tokens["alice"], "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		w := doRequest(t, f.router, http.MethodPost, f.path("/join-requests"), f.tokens["master"], "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("UnknownGuild", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guilds/"+uuid.NewString()+"/join-requests", f.tokens["alice"], "")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("StatusFilter", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		request(t, f, "alice")
+
+		w := doRequest(t, f.router, http.MethodGet, f.path("/join-requests?status=pending"), f.tokens["master"], "")
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page domain.Page[*domain.GuildJoinRequest]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Items, 1)
+	})
+}
+
+func TestGuildAttendeeLeave(t *testing.T) {
+	t.Run("MemberLeaves", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		ga := uuid.New()
+		f.store.guildAttendees[ga] = &domain.GuildAttendee{ID: ga, GuildID: f.guild, UserID: f.users["alice"], Role: domain.GuildAttendeeRoleMember}
+
+		w := doRequest(t, f.router, http.MethodDelete, "/v1/guild-attendees/"+ga.String(), f.tokens["alice"], "")
+
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		require.Contains(t, f.store.guildAttendees, ga)
+		assert.NotNil(t, f.store.guildAttendees[ga].LeftAt)
+
+		w = doRequest(t, f.router, http.MethodGet, f.path("/leaderboard"), f.tokens["alice"], "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("LastMaster", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		w := doRequest(t, f.router, http.MethodDelete, "/v1/guild-attendees/"+f.masterGA.String(), f.tokens["master"], "")
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Nil(t, f.store.guildAttendees[f.masterGA].LeftAt)
+	})
+
+	t.Run("LastMasterDemoted", func(t *testing.T) {
+		f := newMembershipFixture(t)
+
+		w := doRequest(t, f.router, http.MethodPut, "/v1/guild-attendees/"+f.masterGA.String(), f.tokens["master"], `{"role":"member"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("RejoinAfterLeaving", func(t *testing.T) {
+		f := newMembershipFixture(t)
+		ga := uuid.New()
+		left := time.Now().Add(-time.Hour)
+		f.store.guildAttendees[ga] = &domain.GuildAttendee{ID: ga, GuildID: f.guild, UserID: f.users["alice"], Role: domain.GuildAttendeeRoleMaster, LeftAt: &left}
+		invite := f.createInvite(t, "")
+
+		w := doRequest(t, f.router, http.MethodPost, "/v1/guild-invites/"+invite.Code+"/accept", f.tokens["alice"], "")
+
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Nil(t, f.store.guildAttendees[ga].LeftAt)
+		assert.Equal(t, domain.GuildAttendeeRoleMember, f.store.guildAttendees[ga].Role)
+	})
+}
+
+func TestGuildAttendeeUpdateHandler_RoleOnly(t *testing.T) {
+	f := newMembershipFixture(t)
+	ga := uuid.New()
+	f.store.guildAttendees[ga] = &domain.GuildAttendee{ID: ga, GuildID: f.guild, UserID: f.users["alice"], Role: domain.GuildAttendeeRoleMember}
+
+	t.Run("Promote", func(t *testing.T) {
+		w := doRequest(t, f.router, http.MethodPut, "/v1/guild-attendees/"+ga.String(), f.tokens["master"], `{"role":"master"}`)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, domain.GuildAttendeeRoleMaster, f.store.guildAttendees[ga].Role)
+	})
+
+	t.Run("InvalidRole", func(t *testing.T) {
+		w := doRequest(t, f.router, http.MethodPut, "/v1/guild-attendees/"+ga.String(), f.tokens["master"], `{"role":"owner"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// icalHistory is how far back calendar feeds reach.
+const icalHistory = 90 * 24 * time.Hour
+
+const (
+	icalFeedUser  = "users"
+	icalFeedGuild = "guilds"
+)
+
+// icalCalendar is an iCalendar (RFC 5545) feed of events, shown in Location.
+type icalCalendar struct {
+	Name     string
+	Location *time.Location
+	Events   []*domain.Event
+}
+
+// icalToken signs the feed of kind for id. Feeds are read by calendar apps
+// that cannot send a JWT, so the token in the URL is their credential.
+func (a *API) icalToken(kind string, id uuid.UUID) string {
+	mac := hmac.New(sha256.New, a.icalSecret)
+	mac.Write([]byte(kind + ":" + id.String()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// icalURL is the absolute URL of the feed of kind for id.
+func (a *API) icalURL(c *gin.Context, kind string, id uuid.UUID) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/ical/%s/%s.ics?token=%s", scheme, c.Request.Host, kind, id, a.icalToken(kind, id))
+}
+
+// icalFeedID parses the :file param of a feed route and checks its token.
+func (a *API) icalFeedID(c *gin.Context, kind string) (uuid.UUID, bool) {
+	if len(a.icalSecret) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar feeds are disabled"})
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(strings.TrimSuffix(c.Param("file"), ".ics"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return uuid.Nil, false
+	}
+	if !hmac.Equal([]byte(c.Query("token")), []byte(a.icalToken(kind, id))) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "details": "invalid feed token"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (a *API) respondICal(c *gin.Context, cal *icalCalendar) {
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", cal.Encode())
+}
+
+// UserICalHandler serves the events a user attends, in the user's timezone.
+func (a *API) UserICalHandler(c *gin.Context) {
+	id, ok := a.icalFeedID(c, icalFeedUser)
+	if !ok {
+		return
+	}
+
+	user, err := a.userRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := a.eventRepo.ListFeedByUserID(c.Request.Context(), id, time.Now().Add(-icalHistory))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	a.respondICal(c, &icalCalendar{Name: user.Name, Location: userLocation(user), Events: events})
+}
+
+// GuildICalHandler serves a guild's calendar. Without a viewer it is shown in
+// UTC; calendar apps convert it to their own timezone.
+func (a *API) GuildICalHandler(c *gin.Context) {
+	id, ok := a.icalFeedID(c, icalFeedGuild)
+	if !ok {
+		return
+	}
+
+	guild, err := a.guildRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Guild not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := a.eventRepo.ListFeedByGuildID(c.Request.Context(), id, time.Now().Add(-icalHistory))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	a.respondICal(c, &icalCalendar{Name: guild.Name, Location: time.UTC, Events: events})
+}
+
+// MeCalendarHandler returns the URL of the current user's calendar feed.
+func (a *API) MeCalendarHandler(c *gin.Context) {
+	if len(a.icalSecret) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar feeds are disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": a.icalURL(c, icalFeedUser, actorFrom(c).UserID)})
+}
+
+// GuildCalendarHandler returns the URL of a guild's calendar feed.
+func (a *API) GuildCalendarHandler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+	if len(a.icalSecret) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "calendar feeds are disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": a.icalURL(c, icalFeedGuild, id)})
+}
+
+// userLocation is the user's timezone, UTC if it is unknown.
+func userLocation(user *domain.User) *time.Location {
+	if loc, err := time.LoadLocation(user.Timezone); err == nil && user.Timezone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// Encode renders the calendar with CRLF line endings and lines folded at 75
+// octets.
+func (cal *icalCalendar) Encode() []byte {
+	w := &icalWriter{}
+	utc := cal.Location == time.UTC
+	tzid := cal.Location.String()
+
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//jpcorrect//Practice Calendar//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", icalText(cal.Name))
+	w.line("X-WR-TIMEZONE", tzid)
+	if !utc && len(cal.Events) > 0 {
+		from := cal.Events[0].StartTime
+		to := from
+		for _, e := range cal.Events {
+			if end := icalEnd(e); end.After(to) {
+				to = end
+			}
+		}
+		w.timezone(cal.Location, from, to)
+	}
+
+	for _, e := range cal.Events {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", e.ID.String()+"@jpcorrect")
+		w.line("DTSTAMP", icalUTC(e.UpdatedAt))
+		if utc {
+			w.line("DTSTART", icalUTC(e.StartTime))
+			w.line("DTEND", icalUTC(icalEnd(e)))
+		} else {
+			w.line("DTSTART;TZID="+tzid, icalLocal(e.StartTime.In(cal.Location)))
+			w.line("DTEND;TZID="+tzid, icalLocal(icalEnd(e).In(cal.Location)))
+		}
+		w.line("SUMMARY", icalText(e.Title))
+		if e.Description != nil && *e.Description != "" {
+			w.line("DESCRIPTION", icalText(*e.Description))
+		}
+		w.line("CATEGORIES", icalText(string(e.Mode)))
+		if e.Status == domain.EventStatusCancelled || e.DeletedAt.Valid {
+			w.line("STATUS", "CANCELLED")
+		} else {
+			w.line("STATUS", "CONFIRMED")
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.buf.Bytes()
+}
+
+// timezone writes a VTIMEZONE for loc covering from to to, with one
+// observance per offset change in between.
+func (w *icalWriter) timezone(loc *time.Location, from, to time.Time) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", loc.String())
+
+	start := from.In(loc).Truncate(time.Second)
+	_, offset := start.Zone()
+	w.observance(start, offset)
+	for t := start; t.Before(to); {
+		next := t.Add(24 * time.Hour)
+		if _, o := next.Zone(); o == offset {
+			t = next
+			continue
+		}
+		// Offsets change on whole seconds; find the first one
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+			if _, o := mid.Zone(); o == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		w.observance(hi, offset)
+		_, offset = hi.Zone()
+		t = hi
+	}
+
+	w.line("END", "VTIMEZONE")
+}
+
+// observance writes the STANDARD or DAYLIGHT time starting at t, which
+// follows offsetFrom.
+func (w *icalWriter) observance(t time.Time, offsetFrom int) {
+	name, offset := t.Zone()
+	kind := "STANDARD"
+	if t.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN", kind)
+	// DTSTART is the local time just before the change
+	w.line("DTSTART", icalLocal(t.UTC().Add(time.Duration(offsetFrom)*time.Second)))
+	w.line("TZOFFSETFROM", icalOffset(offsetFrom))
+	w.line("TZOFFSETTO", icalOffset(offset))
+	w.line("TZNAME", icalText(name))
+	w.line("END", kind)
+}
+
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// line writes a content line, folding it so no line exceeds 75 octets
+// without splitting a UTF-8 sequence.
+func (w *icalWriter) line(name, value string) {
+	s := name + ":" + value
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space
+		limit = 74
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+// icalEnd is when the event is expected to end; ExpectedDuration is in
+// minutes.
+func icalEnd(e *domain.Event) time.Time {
+	return e.StartTime.Add(time.Duration(e.ExpectedDuration * float64(time.Minute)))
+}
+
+func icalUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func icalLocal(t time.Time) string {
+	return t.Format("20060102T150405")
+}
+
+func icalOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// icalText escapes a TEXT value.
+func icalText(s string) string {
+	return icalEscaper.Replace(s)
+}
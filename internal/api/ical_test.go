@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+func TestICalCalendar_Encode(t *testing.T) {
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	stamp := time.Date(2025, 2, 20, 8, 30, 0, 0, time.UTC)
+	description := "準備：自己紹介、今週の出来事；三分以内で話してください。\nBring notes, a pen; and questions."
+	event := func(n int, start time.Time, minutes float64) *domain.Event {
+		return &domain.Event{
+			ID:               uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", n)),
+			Title:            "Weekly practice",
+			StartTime:        start,
+			ExpectedDuration: minutes,
+			Mode:             domain.EventModeReport,
+			UpdatedAt:        stamp,
+		}
+	}
+	described := event(1, time.Date(2025, 3, 4, 11, 0, 0, 0, time.UTC), 90)
+	described.Title = "發表練習, part 1"
+	described.Description = &description
+	cancelled := event(2, time.Date(2025, 3, 11, 11, 0, 0, 0, time.UTC), 60)
+	cancelled.DeletedAt = gorm.DeletedAt{Time: stamp, Valid: true}
+	conversation := event(3, time.Date(2025, 11, 4, 0, 30, 0, 0, time.UTC), 45)
+	conversation.Mode = domain.EventModeConversation
+
+	tests := []struct {
+		golden string
+		cal    icalCalendar
+	}{
+		{golden: "taipei.ics", cal: icalCalendar{Name: "alice", Location: taipei, Events: []*domain.Event{described, cancelled}}},
+		// Spans both daylight saving changes of 2025
+		{golden: "new_york.ics", cal: icalCalendar{Name: "bob", Location: newYork, Events: []*domain.Event{described, cancelled, conversation}}},
+		{golden: "utc.ics", cal: icalCalendar{Name: "Kansai; Osaka", Location: time.UTC, Events: []*domain.Event{described, conversation}}},
+		{golden: "empty.ics", cal: icalCalendar{Name: "carol", Location: taipei}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got := tt.cal.Encode()
+
+			path := filepath.Join("testdata", "ical", tt.golden)
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, got, 0o644))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "run go test with -update to create golden files")
+			assert.Equal(t, string(want), string(got))
+
+			for _, line := range strings.Split(string(got), "\r\n") {
+				assert.LessOrEqual(t, len(line), 75, line)
+			}
+		})
+	}
+}
+
+func TestICalFeeds(t *testing.T) {
+	f := newPolicyFixture(t)
+	start := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	create := func(t *testing.T, actor, body string) string {
+		t.Helper()
+		w := doRequest(t, f.router, http.MethodPost, "/v1/practices", f.tokens[actor], body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return event.ID.String()
+	}
+	own := create(t, "owner", fmt.Sprintf(`{"title":"mine","start_time":%q}`, start))
+	guildEvent := create(t, "master", fmt.Sprintf(`{"title":"guild night","start_time":%q,"guild_id":%q}`, start, f.guild))
+
+	feedURL := func(t *testing.T, path, actor string) string {
+		t.Helper()
+		w := doRequest(t, f.router, http.MethodGet, path, f.tokens[actor], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body struct {
+			URL string `json:"url"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		u, err := url.Parse(body.URL)
+		require.NoError(t, err)
+		return u.RequestURI()
+	}
+
+	t.Run("User", func(t *testing.T) {
+		feed := feedURL(t, "/v1/me/calendar", "owner")
+		assert.Contains(t, feed, "/ical/users/"+f.users["owner"].String()+".ics?token=")
+
+		w := doRequest(t, f.router, http.MethodGet, feed, "", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "UID:"+own+"@jpcorrect\r\n")
+		assert.NotContains(t, w.Body.String(), guildEvent)
+	})
+
+	t.Run("Guild", func(t *testing.T) {
+		feed := feedURL(t, "/v1/guilds/"+f.guild.String()+"/calendar", "member")
+
+		w := doRequest(t, f.router, http.MethodGet, feed, "", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "UID:"+guildEvent+"@jpcorrect\r\n")
+		assert.NotContains(t, w.Body.String(), own)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		userFeed := feedURL(t, "/v1/me/calendar", "owner")
+		guildFeed := feedURL(t, "/v1/guilds/"+f.guild.String()+"/calendar", "member")
+
+		// A token only opens the feed it was issued for
+		other := "/ical/users/" + f.users["outsider"].String() + ".ics?" + strings.SplitN(userFeed, "?", 2)[1]
+		asUser := "/ical/users/" + f.guild.String() + ".ics?" + strings.SplitN(guildFeed, "?", 2)[1]
+		for _, path := range []string{other, asUser, "/ical/users/" + f.users["owner"].String() + ".ics"} {
+			w := doRequest(t, f.router, http.MethodGet, path, "", "")
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+		}
+	})
+
+	t.Run("OutsiderGuildURL", func(t *testing.T) {
+		w := doRequest(t, f.router, http.MethodGet, "/v1/guilds/"+f.guild.String()+"/calendar", f.tokens["outsider"], "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DefaultTURNCredentialTTL is how long issued TURN credentials stay valid
+const DefaultTURNCredentialTTL = time.Hour
+
+// ICEConfig lists the STUN and TURN servers handed to WebRTC clients.
+// TURNSecret is the static-auth-secret shared with the TURN server; TURN
+// servers are only handed out when it is set.
+type ICEConfig struct {
+	STUNURLs      []string
+	TURNURLs      []string
+	TURNSecret    string
+	CredentialTTL time.Duration
+}
+
+// ICEServer is an RTCIceServer entry of an RTCPeerConnection configuration.
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+type iceServersResponse struct {
+	ICEServers []ICEServer `json:"ice_servers"`
+	// ExpiresAt is when the TURN credentials stop working; clients fetch
+	// new ones before creating connections after it
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// turnCredential derives TURN credentials for userID valid until expires,
+// following the TURN REST API scheme coturn implements with
+// use-auth-secret: the username is "<expiry unix time>:<user ID>" and the
+// password the base64 HMAC-SHA1 of the username keyed by the shared secret.
+// The TURN server checks both without calling back the API.
+func turnCredential(secret string, userID uuid.UUID, expires time.Time) (username, credential string) {
+	username = fmt.Sprintf("%d:%s", expires.Unix(), userID)
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ICEServersHandler returns the ICE servers for the caller's
+// RTCPeerConnections, with TURN credentials bound to the caller.
+func (a *API) ICEServersHandler(c *gin.Context) {
+	resp := iceServersResponse{ICEServers: []ICEServer{}}
+	if len(a.ice.STUNURLs) > 0 {
+		resp.ICEServers = append(resp.ICEServers, ICEServer{URLs: a.ice.STUNURLs})
+	}
+	if len(a.ice.TURNURLs) > 0 && a.ice.TURNSecret != "" {
+		expires := time.Now().Add(a.ice.CredentialTTL).Truncate(time.Second)
+		username, credential := turnCredential(a.ice.TURNSecret, actorFrom(c).UserID, expires)
+		resp.ICEServers = append(resp.ICEServers, ICEServer{URLs: a.ice.TURNURLs, Username: username, Credential: credential})
+		resp.ExpiresAt = &expires
+	}
+	// Credentials must not outlive their response in caches
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTURNCredential(t *testing.T) {
+	userID := uuid.MustParse("6f1c1a3e-8a4b-4c2d-9e5f-0123456789ab")
+	expires := time.Unix(1700000000, 0)
+
+	username, credential := turnCredential("north", userID, expires)
+
+	assert.Equal(t, "1700000000:6f1c1a3e-8a4b-4c2d-9e5f-0123456789ab", username)
+	// base64(HMAC-SHA1("north", username)), as coturn computes it
+	assert.Equal(t, "WLC8NTsNJCfoMm6GEt+35bgI+Qg=", credential)
+
+	_, other := turnCredential("south", userID, expires)
+	assert.NotEqual(t, credential, other, "the secret keys the credential")
+	_, later := turnCredential("north", userID, expires.Add(time.Second))
+	assert.NotEqual(t, credential, later, "the expiry is signed")
+}
+
+func TestICEServersHandler(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+	user := &domain.User{ID: uuid.New(), Name: "Hanako", Email: "hanako@example.com", Role: domain.UserRoleUser}
+	s.users[user.ID] = user
+	token := signTestToken(t, jwt.RegisteredClaims{
+		Subject:   user.ID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	get := func(t *testing.T) iceServersResponse {
+		t.Helper()
+		w := doRequest(t, router, http.MethodGet, "/v1/webrtc/ice-servers", token, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var resp iceServersResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("STUNAndTURN", func(t *testing.T) {
+		a.ice = ICEConfig{
+			STUNURLs:      []string{"stun:stun.example.com:3478"},
+			TURNURLs:      []string{"turn:turn.example.com:3478?transport=udp", "turns:turn.example.com:5349"},
+			TURNSecret:    "north",
+			CredentialTTL: 10 * time.Minute,
+		}
+
+		resp := get(t)
+
+		require.Len(t, resp.ICEServers, 2)
+		assert.Equal(t, ICEServer{URLs: a.ice.STUNURLs}, resp.ICEServers[0])
+		turn := resp.ICEServers[1]
+		assert.Equal(t, a.ice.TURNURLs, turn.URLs)
+		expiry, userID, ok := strings.Cut(turn.Username, ":")
+		require.True(t, ok, turn.Username)
+		assert.Equal(t, user.ID.String(), userID)
+		unix, err := strconv.ParseInt(expiry, 10, 64)
+		require.NoError(t, err)
+		require.NotNil(t, resp.ExpiresAt)
+		assert.Equal(t, resp.ExpiresAt.Unix(), unix)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), *resp.ExpiresAt, 5*time.Second)
+		_, credential := turnCredential("north", user.ID, *resp.ExpiresAt)
+		assert.Equal(t, credential, turn.Credential)
+	})
+
+	t.Run("STUNOnly", func(t *testing.T) {
+		a.ice = ICEConfig{STUNURLs: []string{"stun:stun.example.com:3478"}, TURNURLs: []string{"turn:turn.example.com"}}
+
+		resp := get(t)
+
+		assert.Equal(t, []ICEServer{{URLs: a.ice.STUNURLs}}, resp.ICEServers)
+		assert.Nil(t, resp.ExpiresAt)
+	})
+
+	t.Run("Unconfigured", func(t *testing.T) {
+		a.ice = ICEConfig{}
+
+		assert.Empty(t, get(t).ICEServers)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := doRequest(t, router, http.MethodGet, "/v1/webrtc/ice-servers", "", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
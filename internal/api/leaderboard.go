@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// leaderboardSince takes the window query parameter (week, month or all;
+// default all) out of the list options and returns where the window starts.
+func leaderboardSince(opts *domain.ListOptions) (*time.Time, error) {
+	window := domain.LeaderboardWindowAllTime
+	if w, ok := opts.Filters["window"]; ok {
+		window = domain.LeaderboardWindow(w)
+		delete(opts.Filters, "window")
+	}
+	return window.Since(time.Now())
+}
+
+func (a *API) LeaderboardHandler(c *gin.Context) {
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since, err := leaderboardSince(&opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.leaderboardRepo.ListGlobal(c.Request.Context(), since, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (a *API) GuildLeaderboardHandler(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	since, err := leaderboardSince(&opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.leaderboardRepo.ListByGuildID(c.Request.Context(), id, since, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
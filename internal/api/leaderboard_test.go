@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardHandler(t *testing.T) {
+	a, s := newTestAPI(t)
+	router := gin.New()
+	Register(router, a)
+
+	points := map[string]int{"first": 500, "tiedA": 300, "tiedB": 300, "idle": 0}
+	ids := map[string]uuid.UUID{}
+	for name, p := range points {
+		u := &domain.User{ID: uuid.New(), Name: name, Email: name + "@example.com", Role: domain.UserRoleUser, Points: p}
+		s.users[u.ID] = u
+		ids[name] = u.ID
+	}
+	token := signTestToken(t, jwt.RegisteredClaims{
+		Subject:   ids["idle"].String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+
+	guildID := uuid.New()
+	s.guilds[guildID] = &domain.Guild{ID: guildID, Name: "guild"}
+	for _, name := range []string{"tiedA", "idle"} {
+		ga := uuid.New()
+		s.guildAttendees[ga] = &domain.GuildAttendee{ID: ga, GuildID: guildID, UserID: ids[name], Role: domain.GuildAttendeeRoleMember}
+	}
+
+	list := func(t *testing.T, path string) []*domain.LeaderboardEntry {
+		t.Helper()
+		w := doRequest(t, router, http.MethodGet, path, token, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page domain.Page[*domain.LeaderboardEntry]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Items
+	}
+
+	t.Run("Global", func(t *testing.T) {
+		entries := list(t, "/v1/leaderboard?window=week")
+
+		require.Len(t, entries, 3)
+		assert.Equal(t, ids["first"], entries[0].UserID)
+		assert.Equal(t, 1, entries[0].Rank)
+		assert.Equal(t, 2, entries[1].Rank)
+		assert.Equal(t, 2, entries[2].Rank)
+	})
+
+	t.Run("Guild", func(t *testing.T) {
+		entries := list(t, "/v1/guilds/"+guildID.String()+"/leaderboard?window=month")
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, ids["tiedA"], entries[0].UserID)
+		assert.Equal(t, 1, entries[0].Rank)
+	})
+
+	t.Run("InvalidWindow", func(t *testing.T) {
+		w := doRequest(t, router, http.MethodGet, "/v1/leaderboard?window=year", token, "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLeaderboardWindow_Since(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	week, err := domain.LeaderboardWindowWeek.Since(now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), *week)
+
+	month, err := domain.LeaderboardWindowMonth.Since(now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -30), *month)
+
+	all, err := domain.LeaderboardWindowAllTime.Since(now)
+	require.NoError(t, err)
+	assert.Nil(t, all)
+
+	_, err = domain.LeaderboardWindow("year").Since(now)
+	assert.ErrorIs(t, err, domain.ErrInvalidWindow)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const claimsKey = "claims"
+
+// subjectUserID maps a token subject to a user ID. Subjects that are already
+// UUIDs are used as is; any other subject is mapped to a stable name-based
+// UUID scoped by the token issuer.
+func subjectUserID(claims *tokenClaims) uuid.UUID {
+	if id, err := uuid.Parse(claims.Subject); err == nil {
+		return id
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(claims.Issuer+"#"+claims.Subject))
+}
+
+// loadUser resolves the validated token to an active user. The user ID and
+// the actor are stored in the context for downstream handlers.
+func (a *API) loadUser(c *gin.Context) error {
+	claims := c.MustGet(claimsKey).(*tokenClaims)
+
+	user, err := a.activeUser(c.Request.Context(), claims)
+	if err != nil {
+		return err
+	}
+
+	c.Set("userID", user.ID.String())
+	c.Set(actorKey, &Actor{UserID: user.ID, Role: user.Role})
+	return nil
+}
+
+// activeUser returns the user for the token claims, creating it on first
+// sight, and rejects banned or suspended accounts.
+func (a *API) activeUser(ctx context.Context, claims *tokenClaims) (*domain.User, error) {
+	user, err := a.provisionUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	switch user.Status {
+	case domain.UserStatusBanned:
+		return nil, domain.NewAuthError(http.StatusForbidden, "account banned", "")
+	case domain.UserStatusSuspended:
+		return nil, domain.NewAuthError(http.StatusForbidden, "account suspended", "")
+	}
+	return user, nil
+}
+
+// provisionUser returns the user for the token subject. Unknown subjects get
+// a new user seeded from the token's profile claims; existing profiles are
+// never overwritten, so edits made through /v1/me stick.
+func (a *API) provisionUser(ctx context.Context, claims *tokenClaims) (*domain.User, error) {
+	id := subjectUserID(claims)
+
+	user, err := a.userRepo.GetByID(ctx, id)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	// The deleted row still holds the ID, so it cannot be provisioned again
+	_, err = a.userRepo.GetDeletedByID(ctx, id)
+	if err == nil {
+		return nil, domain.NewAuthError(
+			http.StatusGone,
+			"account deleted",
+			"this account was deleted; contact an administrator to restore it",
+		)
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" {
+		return nil, domain.NewAuthError(
+			http.StatusUnauthorized,
+			"invalid token claims",
+			"email claim is required to create a user",
+		)
+	}
+
+	user = &domain.User{
+		ID:              id,
+		Email:           claims.Email,
+		Name:            claims.Name,
+		IsEmailVerified: claims.EmailVerified,
+		Role:            domain.UserRoleUser,
+		Status:          domain.UserStatusActive,
+	}
+	if user.Name == "" {
+		user.Name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if claims.Picture != "" {
+		picture := claims.Picture
+		user.AvatarURL = &picture
+	}
+
+	err = a.userRepo.Create(ctx, user)
+	if errors.Is(err, domain.ErrDuplicateEntry) {
+		// A concurrent request may have provisioned the same subject
+		if existing, getErr := a.userRepo.GetByID(ctx, id); getErr == nil {
+			return existing, nil
+		}
+		return nil, domain.NewAuthError(
+			http.StatusConflict,
+			"account conflict",
+			"email is already linked to another user",
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// meUpdateRequest lists the profile fields a user may change on their own
+// account. Email, role and status are managed elsewhere.
+type meUpdateRequest struct {
+	Name      *string `json:"name"`
+	AvatarURL *string `json:"avatar_url"`
+	Timezone  *string `json:"timezone"`
+}
+
+// apply sets the fields of the request on user.
+func (r *meUpdateRequest) apply(user *domain.User) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		user.Name = name
+	}
+	if r.AvatarURL != nil {
+		if *r.AvatarURL == "" {
+			user.AvatarURL = nil
+		} else {
+			user.AvatarURL = r.AvatarURL
+		}
+	}
+	if r.Timezone != nil {
+		if _, err := time.LoadLocation(*r.Timezone); err != nil || *r.Timezone == "" {
+			return errors.New("invalid timezone")
+		}
+		user.Timezone = *r.Timezone
+	}
+	return nil
+}
+
+// userAdminUpdateRequest adds the account fields admins and staff manage to
+// the profile fields. Deletion goes through DELETE /v1/users/:id.
+type userAdminUpdateRequest struct {
+	meUpdateRequest
+	Email           *string            `json:"email" binding:"omitempty,email"`
+	IsEmailVerified *bool              `json:"is_email_verified"`
+	Role            *domain.UserRole   `json:"role" binding:"omitempty,oneof=user admin staff"`
+	Status          *domain.UserStatus `json:"status" binding:"omitempty,oneof=active banned suspended"`
+}
+
+// apply sets the fields of the request on user.
+func (r *userAdminUpdateRequest) apply(user *domain.User) error {
+	if err := r.meUpdateRequest.apply(user); err != nil {
+		return err
+	}
+	if r.Email != nil {
+		user.Email = *r.Email
+	}
+	if r.IsEmailVerified != nil {
+		user.IsEmailVerified = *r.IsEmailVerified
+	}
+	if r.Role != nil {
+		user.Role = *r.Role
+	}
+	if r.Status != nil {
+		user.Status = *r.Status
+	}
+	return nil
+}
+
+func (a *API) MeGetHandler(c *gin.Context) {
+	user, err := a.userRepo.GetByID(c.Request.Context(), actorFrom(c).UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (a *API) MeUpdateHandler(c *gin.Context) {
+	var req meUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := a.userRepo.GetByID(c.Request.Context(), actorFrom(c).UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.apply(user); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := a.userRepo.Update(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
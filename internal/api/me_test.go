@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newMeRouter(t *testing.T) (*gin.Engine, *fakeStore) {
+	t.Helper()
+	a, s := newTestAPI(t)
+	r := gin.New()
+	Register(r, a)
+	return r, s
+}
+
+func profileToken(t *testing.T, subject, email string) string {
+	t.Helper()
+	return signTestToken(t, tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://auth.example.com",
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Email:         email,
+		EmailVerified: true,
+		Name:          "Hanako",
+		Picture:       "https://example.com/hanako.png",
+	})
+}
+
+func TestAuthMiddleware_ProvisionsUser(t *testing.T) {
+	t.Run("NonUUIDSubject", func(t *testing.T) {
+		r, s := newMeRouter(t)
+		token := profileToken(t, "google-oauth2|1234", "hanako@example.com")
+
+		w := doRequest(t, r, http.MethodGet, "/v1/me", token, "")
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var got domain.User
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, "hanako@example.com", got.Email)
+		assert.Equal(t, "Hanako", got.Name)
+		assert.True(t, got.IsEmailVerified)
+		if assert.NotNil(t, got.AvatarURL) {
+			assert.Equal(t, "https://example.com/hanako.png", *got.AvatarURL)
+		}
+		assert.Equal(t, domain.UserRoleUser, got.Role)
+		assert.Equal(t, domain.UserStatusActive, got.Status)
+		assert.Len(t, s.users, 1)
+
+		// The same subject maps to the same user on later requests
+		w = doRequest(t, r, http.MethodGet, "/v1/me", token, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), got.ID.String())
+		assert.Len(t, s.users, 1)
+	})
+
+	t.Run("UUIDSubject", func(t *testing.T) {
+		r, s := newMeRouter(t)
+		id := uuid.New()
+
+		w := doRequest(t, r, http.MethodGet, "/v1/me", profileToken(t, id.String(), "taro@example.com"), "")
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, s.users, id)
+	})
+
+	t.Run("ExistingProfileKept", func(t *testing.T) {
+		r, s := newMeRouter(t)
+		id := uuid.New()
+		s.users[id] = &domain.User{ID: id, Email: "old@example.com", Name: "Custom", Role: domain.UserRoleStaff, Status: domain.UserStatusActive}
+
+		w := doRequest(t, r, http.MethodGet, "/v1/me", profileToken(t, id.String(), "new@example.com"), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"Custom"`)
+		assert.Contains(t, w.Body.String(), `"role":"staff"`)
+	})
+
+	t.Run("DeletedUser", func(t *testing.T) {
+		r, s := newMeRouter(t)
+		id := uuid.New()
+		s.users[id] = &domain.User{ID: id, Email: "gone@example.com", Status: domain.UserStatusActive, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+
+		w := doRequest(t, r, http.MethodGet, "/v1/me", profileToken(t, id.String(), "gone@example.com"), "")
+
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Contains(t, w.Body.String(), "account deleted")
+		assert.Len(t, s.users, 1)
+	})
+
+	t.Run("MissingEmail", func(t *testing.T) {
+		r, s := newMeRouter(t)
+
+		w := doRequest(t, r, http.MethodGet, "/v1/me", profileToken(t, uuid.NewString(), ""), "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Empty(t, s.users)
+	})
+}
+
+func TestAuthMiddleware_RejectsInactiveUsers(t *testing.T) {
+	for _, status := range []domain.UserStatus{domain.UserStatusBanned, domain.UserStatusSuspended} {
+		t.Run(string(status), func(t *testing.T) {
+			r, s := newMeRouter(t)
+			id := uuid.New()
+			s.users[id] = &domain.User{ID: id, Email: "x@example.com", Role: domain.UserRoleUser, Status: status}
+
+			w := doRequest(t, r, http.MethodGet, "/v1/me", profileToken(t, id.String(), "x@example.com"), "")
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), "account "+string(status))
+		})
+	}
+}
+
+func TestMeUpdateHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		r, s := newMeRouter(t)
+		id := uuid.New()
+		token := profileToken(t, id.String(), "taro@example.com")
+		body := `{"name":"Taro","timezone":"Asia/Tokyo","avatar_url":"","role":"admin","email":"evil@example.com"}`
+
+		w := doRequest(t, r, http.MethodPatch, "/v1/me", token, body)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		user := s.users[id]
+		assert.Equal(t, "Taro", user.Name)
+		assert.Equal(t, "Asia/Tokyo", user.Timezone)
+		assert.Nil(t, user.AvatarURL)
+		assert.Equal(t, domain.UserRoleUser, user.Role)
+		assert.Equal(t, "taro@example.com", user.Email)
+	})
+
+	t.Run("InvalidTimezone", func(t *testing.T) {
+		r, _ := newMeRouter(t)
+
+		w := doRequest(t, r, http.MethodPatch, "/v1/me", profileToken(t, uuid.NewString(), "a@example.com"), `{"timezone":"Mars/Olympus"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("EmptyName", func(t *testing.T) {
+		r, _ := newMeRouter(t)
+
+		w := doRequest(t, r, http.MethodPatch, "/v1/me", profileToken(t, uuid.NewString(), "a@example.com"), `{"name":"  "}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
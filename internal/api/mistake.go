@@ -59,7 +59,7 @@ func (a *API) MistakeUpdateHandler(c *gin.Context) {
 	}
 
 	// Check if record exists first
-	_, err = a.mistakeRepo.GetByID(c.Request.Context(), id)
+	existing, err := a.mistakeRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Mistake not found"})
@@ -75,7 +75,11 @@ func (a *API) MistakeUpdateHandler(c *gin.Context) {
 		return
 	}
 
+	// The policy checked ownership against the stored record, so it stays
+	// in its event with its user
 	mistake.ID = id
+	mistake.EventID, mistake.UserID = existing.EventID, existing.UserID
+	mistake.CreatedAt = existing.CreatedAt
 	if err := a.mistakeRepo.Update(c.Request.Context(), &mistake); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Mistake already exists"})
@@ -134,13 +138,19 @@ func (a *API) MistakeGetByEventHandler(c *gin.Context) {
 		return
 	}
 
-	mistakes, err := a.mistakeRepo.GetByEventID(c.Request.Context(), eventID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.mistakeRepo.ListByEventID(c.Request.Context(), eventID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, mistakes)
+	c.JSON(http.StatusOK, page)
 }
 
 func (a *API) MistakeGetByUserHandler(c *gin.Context) {
@@ -151,11 +161,17 @@ func (a *API) MistakeGetByUserHandler(c *gin.Context) {
 		return
 	}
 
-	mistakes, err := a.mistakeRepo.GetByUserID(c.Request.Context(), userID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.mistakeRepo.ListByUserID(c.Request.Context(), userID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, mistakes)
+	c.JSON(http.StatusOK, page)
 }
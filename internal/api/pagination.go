@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseListOptions reads the limit, cursor and sort query parameters. Every
+// other query parameter is passed through as a filter and validated against
+// the repository's whitelist.
+func parseListOptions(c *gin.Context) (domain.ListOptions, error) {
+	opts := domain.ListOptions{
+		Cursor:  c.Query("cursor"),
+		Sort:    c.Query("sort"),
+		Filters: map[string]string{},
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return opts, errors.New("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		switch key {
+		case "limit", "cursor", "sort":
+			continue
+		}
+		if len(values) > 0 {
+			opts.Filters[key] = values[0]
+		}
+	}
+
+	return opts, nil
+}
+
+// respondListError maps errors returned by paginated repository methods.
+func respondListError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidCursor) ||
+		errors.Is(err, domain.ErrInvalidSort) ||
+		errors.Is(err, domain.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
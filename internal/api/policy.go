@@ -0,0 +1,433 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Actor is the authenticated caller of a /v1 request.
+type Actor struct {
+	UserID uuid.UUID
+	Role   domain.UserRole
+}
+
+// IsPrivileged reports whether the actor is an admin or staff member. Those
+// roles bypass resource-level checks.
+func (ac *Actor) IsPrivileged() bool {
+	return ac.Role == domain.UserRoleAdmin || ac.Role == domain.UserRoleStaff
+}
+
+// access is the level of access a route requires on its target resource.
+type access int
+
+const (
+	// accessRead is granted to every attendee of the event (or member of the guild).
+	accessRead access = iota
+	// accessOwn is granted to the record's user and to the event emcee / guild master.
+	accessOwn
+	// accessManage is granted to the event emcee / guild master only.
+	accessManage
+)
+
+// policy decides whether the actor may perform the current request. It
+// returns nil to grant access and a *domain.AuthError to deny it. Returning
+// errDeferToHandler (or domain.ErrNotFound for the target resource) lets the
+// request through so the handler reports the malformed input or missing
+// record itself.
+type policy func(c *gin.Context, actor *Actor) error
+
+const actorKey = "actor"
+
+var errDeferToHandler = errors.New("defer to handler")
+
+func forbidden(details string) error {
+	return domain.NewAuthError(http.StatusForbidden, "forbidden", details)
+}
+
+// authorize returns a middleware that evaluates p against the actor loaded
+// by AuthMiddleware before the handler runs.
+func (a *API) authorize(p policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := actorFrom(c)
+		if actor == nil {
+			a.respondAuthError(c, domain.NewAuthError(http.StatusUnauthorized, "unauthenticated", ""))
+			c.Abort()
+			return
+		}
+
+		if !actor.IsPrivileged() {
+			if err := p(c, actor); err != nil &&
+				!errors.Is(err, errDeferToHandler) && !errors.Is(err, domain.ErrNotFound) {
+				a.respondAuthError(c, err)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// actorFrom returns the actor loaded by AuthMiddleware, or nil outside /v1.
+func actorFrom(c *gin.Context) *Actor {
+	v, ok := c.Get(actorKey)
+	if !ok {
+		return nil
+	}
+	return v.(*Actor)
+}
+
+func paramUUID(c *gin.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		return uuid.Nil, errDeferToHandler
+	}
+	return id, nil
+}
+
+// peekJSON decodes the request body into v and restores it so the handler
+// can bind it again.
+func peekJSON(c *gin.Context, v interface{}) error {
+	if c.Request.Body == nil {
+		return errDeferToHandler
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return errDeferToHandler
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err := json.Unmarshal(body, v); err != nil {
+		return errDeferToHandler
+	}
+	return nil
+}
+
+// checkEvent verifies the actor's attendance of an event. For accessOwn the
+// actor also passes when they are ownerID.
+func (a *API) checkEvent(ctx context.Context, eventID uuid.UUID, actor *Actor, level access, ownerID uuid.UUID) error {
+	if level == accessOwn && ownerID == actor.UserID {
+		return nil
+	}
+	attendee, err := a.eventAttendeeRepo.GetByEventAndUser(ctx, eventID, actor.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return forbidden("not an attendee of this event")
+	}
+	if err != nil {
+		return err
+	}
+	if level != accessRead && attendee.Role != domain.EventAttendeeRoleEmcee {
+		return forbidden("requires the event emcee")
+	}
+	return nil
+}
+
+// checkGuild verifies the actor's active membership of a guild. For
+// accessOwn the actor also passes when they are ownerID.
+func (a *API) checkGuild(ctx context.Context, guildID uuid.UUID, actor *Actor, level access, ownerID uuid.UUID) error {
+	if level == accessOwn && ownerID == actor.UserID {
+		return nil
+	}
+	attendee, err := a.guildAttendeeRepo.GetByGuildAndUser(ctx, guildID, actor.UserID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && attendee.LeftAt != nil) {
+		return forbidden("not a member of this guild")
+	}
+	if err != nil {
+		return err
+	}
+	if level != accessRead && attendee.Role != domain.GuildAttendeeRoleMaster {
+		return forbidden("requires the guild master")
+	}
+	return nil
+}
+
+// allowAuthenticated grants access to every authenticated user.
+func allowAuthenticated(*gin.Context, *Actor) error {
+	return nil
+}
+
+// requireSelf grants access when the path parameter is the actor's user ID.
+func requireSelf(param string) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		if id != actor.UserID {
+			return forbidden("can only access your own records")
+		}
+		return nil
+	}
+}
+
+// requireEventParam checks the actor's role in the event named by a path parameter.
+func (a *API) requireEventParam(param string, level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		eventID, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		if _, err := a.eventRepo.GetByID(c.Request.Context(), eventID); err != nil {
+			return err
+		}
+		return a.checkEvent(c.Request.Context(), eventID, actor, level, uuid.Nil)
+	}
+}
+
+// requireGuildParam checks the actor's role in the guild named by a path parameter.
+func (a *API) requireGuildParam(param string, level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		guildID, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		if _, err := a.guildRepo.GetByID(c.Request.Context(), guildID); err != nil {
+			return err
+		}
+		return a.checkGuild(c.Request.Context(), guildID, actor, level, uuid.Nil)
+	}
+}
+
+// checkReviewOf lets the actor point a review event only at an event they
+// attended, since the review agenda lists that event's mistakes. Unknown
+// events are left to the handler's validation.
+func (a *API) checkReviewOf(ctx context.Context, reviewOfID *uuid.UUID, actor *Actor) error {
+	if reviewOfID == nil {
+		return nil
+	}
+	if _, err := a.eventRepo.GetByID(ctx, *reviewOfID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return a.checkEvent(ctx, *reviewOfID, actor, accessRead, uuid.Nil)
+}
+
+// requireEventCreate lets any user create a personal event or series and
+// only the masters of the guild referenced by the request body's guild_id
+// create a guild one.
+func (a *API) requireEventCreate() policy {
+	return func(c *gin.Context, actor *Actor) error {
+		var body struct {
+			GuildID    *uuid.UUID `json:"guild_id"`
+			ReviewOfID *uuid.UUID `json:"review_of_id"`
+		}
+		if err := peekJSON(c, &body); err != nil {
+			return err
+		}
+		if err := a.checkReviewOf(c.Request.Context(), body.ReviewOfID, actor); err != nil {
+			return err
+		}
+		if body.GuildID == nil {
+			return nil
+		}
+		if _, err := a.guildRepo.GetByID(c.Request.Context(), *body.GuildID); err != nil {
+			return err
+		}
+		return a.checkGuild(c.Request.Context(), *body.GuildID, actor, accessManage, uuid.Nil)
+	}
+}
+
+// requireEventUpdate lets the emcee edit the event named by a path
+// parameter. Pointing it at another event to review follows checkReviewOf.
+func (a *API) requireEventUpdate(param string) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		eventID, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		event, err := a.eventRepo.GetByID(c.Request.Context(), eventID)
+		if err != nil {
+			return err
+		}
+		if err := a.checkEvent(c.Request.Context(), eventID, actor, accessManage, uuid.Nil); err != nil {
+			return err
+		}
+		var body struct {
+			ReviewOfID *uuid.UUID `json:"review_of_id"`
+		}
+		if err := peekJSON(c, &body); err != nil {
+			return err
+		}
+		if body.ReviewOfID == nil || (event.ReviewOfID != nil && *event.ReviewOfID == *body.ReviewOfID) {
+			return nil
+		}
+		return a.checkReviewOf(c.Request.Context(), body.ReviewOfID, actor)
+	}
+}
+
+// requireEventCancel lets the guild masters cancel a guild event and the
+// emcee cancel any other event named by a path parameter.
+func (a *API) requireEventCancel(param string) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		eventID, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		event, err := a.eventRepo.GetByID(c.Request.Context(), eventID)
+		if err != nil {
+			return err
+		}
+		if event.GuildID != nil {
+			return a.checkGuild(c.Request.Context(), *event.GuildID, actor, accessManage, uuid.Nil)
+		}
+		return a.checkEvent(c.Request.Context(), eventID, actor, accessManage, uuid.Nil)
+	}
+}
+
+// requireEventHost lets the emcee of the event named by a path parameter run
+// its session, and for a guild event the guild masters as well.
+func (a *API) requireEventHost(param string) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		eventID, err := paramUUID(c, param)
+		if err != nil {
+			return err
+		}
+		event, err := a.eventRepo.GetByID(c.Request.Context(), eventID)
+		if err != nil {
+			return err
+		}
+		err = a.checkEvent(c.Request.Context(), eventID, actor, accessManage, uuid.Nil)
+		if err == nil || event.GuildID == nil {
+			return err
+		}
+		if a.checkGuild(c.Request.Context(), *event.GuildID, actor, accessManage, uuid.Nil) == nil {
+			return nil
+		}
+		return err
+	}
+}
+
+// requireEventSeries checks access to the event series named by the :id
+// parameter. Guild series follow the guild roles; other series are only
+// accessible to their creator.
+func (a *API) requireEventSeries(level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, "id")
+		if err != nil {
+			return err
+		}
+		series, err := a.eventSeriesRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return err
+		}
+		if series.GuildID != nil {
+			return a.checkGuild(c.Request.Context(), *series.GuildID, actor, level, uuid.Nil)
+		}
+		if series.CreatedBy != actor.UserID {
+			return forbidden("requires the series creator")
+		}
+		return nil
+	}
+}
+
+// requireMistake checks access to the mistake named by the :id parameter.
+func (a *API) requireMistake(level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, "id")
+		if err != nil {
+			return err
+		}
+		mistake, err := a.mistakeRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return err
+		}
+		return a.checkEvent(c.Request.Context(), mistake.EventID, actor, level, mistake.UserID)
+	}
+}
+
+// requireTranscript checks access to the transcript named by the :id parameter.
+func (a *API) requireTranscript(level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, "id")
+		if err != nil {
+			return err
+		}
+		transcript, err := a.transcriptRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return err
+		}
+		return a.checkEvent(c.Request.Context(), transcript.EventID, actor, level, transcript.UserID)
+	}
+}
+
+// requireEventAttendee checks access to the event attendee named by the :id parameter.
+func (a *API) requireEventAttendee(level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, "id")
+		if err != nil {
+			return err
+		}
+		attendee, err := a.eventAttendeeRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return err
+		}
+		return a.checkEvent(c.Request.Context(), attendee.EventID, actor, level, attendee.UserID)
+	}
+}
+
+// requireGuildAttendee checks access to the guild attendee named by the :id parameter.
+func (a *API) requireGuildAttendee(level access) policy {
+	return func(c *gin.Context, actor *Actor) error {
+		id, err := paramUUID(c, "id")
+		if err != nil {
+			return err
+		}
+		attendee, err := a.guildAttendeeRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return err
+		}
+		return a.checkGuild(c.Request.Context(), attendee.GuildID, actor, level, attendee.UserID)
+	}
+}
+
+// requireBodyEventAttendance grants access when the actor attends the event
+// referenced by the request body's event_id.
+func (a *API) requireBodyEventAttendance() policy {
+	return func(c *gin.Context, actor *Actor) error {
+		var body struct {
+			EventID uuid.UUID `json:"event_id"`
+		}
+		if err := peekJSON(c, &body); err != nil {
+			return err
+		}
+		return a.checkEvent(c.Request.Context(), body.EventID, actor, accessRead, uuid.Nil)
+	}
+}
+
+// requireEventAttendeeCreate lets the emcee add anyone to an event. Guild
+// members may also add themselves as a member of their guild's events;
+// everyone else has to be added by the emcee.
+func (a *API) requireEventAttendeeCreate() policy {
+	return func(c *gin.Context, actor *Actor) error {
+		var body domain.EventAttendee
+		if err := peekJSON(c, &body); err != nil {
+			return err
+		}
+		if body.UserID == actor.UserID && (body.Role == "" || body.Role == domain.EventAttendeeRoleMember) {
+			event, err := a.eventRepo.GetByID(c.Request.Context(), body.EventID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return err
+			}
+			if err == nil && event.GuildID != nil {
+				return a.checkGuild(c.Request.Context(), *event.GuildID, actor, accessRead, uuid.Nil)
+			}
+		}
+		return a.checkEvent(c.Request.Context(), body.EventID, actor, accessManage, uuid.Nil)
+	}
+}
+
+// requirePrivileged grants access to admins and staff only. Those roles
+// bypass policies, so it denies everyone who reaches it.
+func requirePrivileged(*gin.Context, *Actor) error {
+	return forbidden("requires admin or staff")
+}
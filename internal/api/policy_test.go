@@ -0,0 +1,496 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type policyFixture struct {
+	router   *gin.Engine
+	store    *fakeStore
+	tokens   map[string]string
+	users    map[string]uuid.UUID
+	event    uuid.UUID
+	mistake  uuid.UUID
+	script   uuid.UUID
+	ownerEA  uuid.UUID
+	guild    uuid.UUID
+	memberGA uuid.UUID
+	// guildEvent belongs to guild and is hosted by emcee, who is not a member
+	guildEvent uuid.UUID
+	// series is created by owner; guildSeries belongs to guild
+	series      uuid.UUID
+	guildSeries uuid.UUID
+}
+
+func newPolicyFixture(t *testing.T) *policyFixture {
+	t.Helper()
+	a, s := newTestAPI(t)
+	f := &policyFixture{
+		store:  s,
+		tokens: map[string]string{},
+		users:  map[string]uuid.UUID{},
+	}
+
+	roles := map[string]domain.UserRole{
+		"owner":    domain.UserRoleUser,
+		"emcee":    domain.UserRoleUser,
+		"outsider": domain.UserRoleUser,
+		"master":   domain.UserRoleUser,
+		"member":   domain.UserRoleUser,
+		"staff":    domain.UserRoleStaff,
+		"admin":    domain.UserRoleAdmin,
+	}
+	for name, role := range roles {
+		u := &domain.User{ID: uuid.New(), Name: name, Email: name + "@example.com", Role: role}
+		s.users[u.ID] = u
+		f.users[name] = u.ID
+		f.tokens[name] = signTestToken(t, jwt.RegisteredClaims{
+			Subject:   u.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+	}
+
+	f.event = uuid.New()
+	s.events[f.event] = &domain.Event{ID: f.event, Title: "practice", Status: domain.EventStatusScheduled}
+	emceeEA := uuid.New()
+	s.eventAttendees[emceeEA] = &domain.EventAttendee{ID: emceeEA, EventID: f.event, UserID: f.users["emcee"], Role: domain.EventAttendeeRoleEmcee}
+	f.ownerEA = uuid.New()
+	s.eventAttendees[f.ownerEA] = &domain.EventAttendee{ID: f.ownerEA, EventID: f.event, UserID: f.users["owner"], Role: domain.EventAttendeeRoleMember}
+
+	f.mistake = uuid.New()
+	s.mistakes[f.mistake] = &domain.Mistake{ID: f.mistake, EventID: f.event, UserID: f.users["owner"]}
+	f.script = uuid.New()
+	s.transcripts[f.script] = &domain.Transcript{ID: f.script, EventID: f.event, UserID: f.users["owner"]}
+
+	f.guild = uuid.New()
+	s.guilds[f.guild] = &domain.Guild{ID: f.guild, Name: "guild"}
+	masterGA := uuid.New()
+	s.guildAttendees[masterGA] = &domain.GuildAttendee{ID: masterGA, GuildID: f.guild, UserID: f.users["master"], Role: domain.GuildAttendeeRoleMaster}
+	f.memberGA = uuid.New()
+	s.guildAttendees[f.memberGA] = &domain.GuildAttendee{ID: f.memberGA, GuildID: f.guild, UserID: f.users["member"], Role: domain.GuildAttendeeRoleMember}
+
+	f.guildEvent = uuid.New()
+	s.events[f.guildEvent] = &domain.Event{ID: f.guildEvent, GuildID: &f.guild, Title: "guild practice", Status: domain.EventStatusScheduled}
+	guildEmceeEA := uuid.New()
+	s.eventAttendees[guildEmceeEA] = &domain.EventAttendee{ID: guildEmceeEA, EventID: f.guildEvent, UserID: f.users["emcee"], Role: domain.EventAttendeeRoleEmcee}
+
+	f.series = uuid.New()
+	s.series[f.series] = &domain.EventSeries{ID: f.series, CreatedBy: f.users["owner"], Title: "series", StartTime: time.Now(), Timezone: "UTC", RRule: "FREQ=WEEKLY"}
+	f.guildSeries = uuid.New()
+	s.series[f.guildSeries] = &domain.EventSeries{ID: f.guildSeries, GuildID: &f.guild, CreatedBy: f.users["master"], Title: "guild series", StartTime: time.Now(), Timezone: "UTC", RRule: "FREQ=WEEKLY"}
+
+	f.router = gin.New()
+	Register(f.router, a)
+	return f
+}
+
+func TestAuthorization(t *testing.T) {
+	type routeCase struct {
+		name      string
+		method    string
+		path      func(f *policyFixture) string
+		body      func(f *policyFixture) string
+		actor     string
+		forbidden bool
+	}
+
+	fixed := func(p string) func(*policyFixture) string { return func(*policyFixture) string { return p } }
+	mistakePath := func(f *policyFixture) string { return "/v1/mistakes/" + f.mistake.String() }
+	scriptPath := func(f *policyFixture) string { return "/v1/transcripts/" + f.script.String() }
+	practicePath := func(f *policyFixture) string { return "/v1/practices/" + f.event.String() }
+	ownerEAPath := func(f *policyFixture) string { return "/v1/event-attendees/" + f.ownerEA.String() }
+	guildPath := func(f *policyFixture) string { return "/v1/guilds/" + f.guild.String() }
+	memberGAPath := func(f *policyFixture) string { return "/v1/guild-attendees/" + f.memberGA.String() }
+	suffix := func(path func(*policyFixture) string, rest string) func(*policyFixture) string {
+		return func(f *policyFixture) string { return path(f) + rest }
+	}
+	userPath := func(name string) func(*policyFixture) string {
+		return func(f *policyFixture) string { return "/v1/users/" + f.users[name].String() }
+	}
+	byEvent := func(prefix string) func(*policyFixture) string {
+		return func(f *policyFixture) string { return prefix + "/event/" + f.event.String() }
+	}
+	byUser := func(prefix, name string) func(*policyFixture) string {
+		return func(f *policyFixture) string { return prefix + "/user/" + f.users[name].String() }
+	}
+	eventBody := func(f *policyFixture) string {
+		return fmt.Sprintf(`{"event_id":%q,"user_id":%q}`, f.event, f.users["owner"])
+	}
+	attendeeBody := func(user, role string) func(*policyFixture) string {
+		return func(f *policyFixture) string {
+			return fmt.Sprintf(`{"event_id":%q,"user_id":%q,"role":%q}`, f.event, f.users[user], role)
+		}
+	}
+	guildEventAttendeeBody := func(user string) func(*policyFixture) string {
+		return func(f *policyFixture) string {
+			return fmt.Sprintf(`{"event_id":%q,"user_id":%q,"role":"member"}`, f.guildEvent, f.users[user])
+		}
+	}
+	guildAttendeeBody := func(user, role string) func(*policyFixture) string {
+		return func(f *policyFixture) string {
+			return fmt.Sprintf(`{"guild_id":%q,"user_id":%q,"role":%q}`, f.guild, f.users[user], role)
+		}
+	}
+	guildEventPath := func(f *policyFixture) string { return "/v1/practices/" + f.guildEvent.String() }
+	guildEventBody := func(f *policyFixture) string {
+		return fmt.Sprintf(`{"title":"guild night","guild_id":%q}`, f.guild)
+	}
+	seriesPath := func(f *policyFixture) string { return "/v1/event-series/" + f.series.String() }
+	guildSeriesPath := func(f *policyFixture) string { return "/v1/event-series/" + f.guildSeries.String() }
+	seriesBody := fixed(`{"title":"weekly","rrule":"FREQ=WEEKLY"}`)
+	guildSeriesBody := func(f *policyFixture) string {
+		return fmt.Sprintf(`{"title":"weekly","start_time":"2030-01-07T19:00:00Z","rrule":"FREQ=WEEKLY","guild_id":%q}`, f.guild)
+	}
+	userBody := func(name string) func(*policyFixture) string {
+		return func(f *policyFixture) string {
+			return fmt.Sprintf(`{"user_id":%q,"name":"renamed","email":"%s@example.com"}`, f.users[name], name)
+		}
+	}
+
+	tests := []routeCase{
+		// Mistakes
+		{name: "mistake create by attendee", method: http.MethodPost, path: fixed("/v1/mistakes"), body: eventBody, actor: "emcee"},
+		{name: "mistake create by outsider", method: http.MethodPost, path: fixed("/v1/mistakes"), body: eventBody, actor: "outsider", forbidden: true},
+		{name: "mistake get by attendee", method: http.MethodGet, path: mistakePath, actor: "emcee"},
+		{name: "mistake get by outsider", method: http.MethodGet, path: mistakePath, actor: "outsider", forbidden: true},
+		{name: "mistake update by owner", method: http.MethodPut, path: mistakePath, body: eventBody, actor: "owner"},
+		{name: "mistake update by outsider", method: http.MethodPut, path: mistakePath, body: eventBody, actor: "outsider", forbidden: true},
+		{name: "mistake delete by emcee", method: http.MethodDelete, path: mistakePath, actor: "emcee"},
+		{name: "mistake delete by outsider", method: http.MethodDelete, path: mistakePath, actor: "outsider", forbidden: true},
+		{name: "mistake delete by staff", method: http.MethodDelete, path: mistakePath, actor: "staff"},
+		{name: "mistakes by event for attendee", method: http.MethodGet, path: byEvent("/v1/mistakes"), actor: "owner"},
+		{name: "mistakes by event for outsider", method: http.MethodGet, path: byEvent("/v1/mistakes"), actor: "outsider", forbidden: true},
+		{name: "mistakes by user for self", method: http.MethodGet, path: byUser("/v1/mistakes", "owner"), actor: "owner"},
+		{name: "mistakes by user for other", method: http.MethodGet, path: byUser("/v1/mistakes", "owner"), actor: "emcee", forbidden: true},
+
+		// Transcripts
+		{name: "transcript create by attendee", method: http.MethodPost, path: fixed("/v1/transcripts"), body: eventBody, actor: "owner"},
+		{name: "transcript create by outsider", method: http.MethodPost, path: fixed("/v1/transcripts"), body: eventBody, actor: "outsider", forbidden: true},
+		{name: "transcript get by attendee", method: http.MethodGet, path: scriptPath, actor: "emcee"},
+		{name: "transcript get by outsider", method: http.MethodGet, path: scriptPath, actor: "outsider", forbidden: true},
+		{name: "transcript update by owner", method: http.MethodPut, path: scriptPath, body: eventBody, actor: "owner"},
+		{name: "transcript update by outsider", method: http.MethodPut, path: scriptPath, body: eventBody, actor: "outsider", forbidden: true},
+		{name: "transcript delete by emcee", method: http.MethodDelete, path: scriptPath, actor: "emcee"},
+		{name: "transcript delete by outsider", method: http.MethodDelete, path: scriptPath, actor: "outsider", forbidden: true},
+		{name: "transcripts by event for attendee", method: http.MethodGet, path: byEvent("/v1/transcripts"), actor: "emcee"},
+		{name: "transcripts by event for outsider", method: http.MethodGet, path: byEvent("/v1/transcripts"), actor: "outsider", forbidden: true},
+		{name: "transcripts by user for self", method: http.MethodGet, path: byUser("/v1/transcripts", "owner"), actor: "owner"},
+		{name: "transcripts by user for other", method: http.MethodGet, path: byUser("/v1/transcripts", "owner"), actor: "outsider", forbidden: true},
+
+		// Practices
+		{name: "practice create", method: http.MethodPost, path: fixed("/v1/practices"), body: fixed(`{"title":"new"}`), actor: "outsider"},
+		{name: "practice get by attendee", method: http.MethodGet, path: practicePath, actor: "owner"},
+		{name: "practice get by outsider", method: http.MethodGet, path: practicePath, actor: "outsider", forbidden: true},
+		{name: "practice update by emcee", method: http.MethodPut, path: practicePath, body: fixed(`{"title":"renamed"}`), actor: "emcee"},
+		{name: "practice update by member", method: http.MethodPut, path: practicePath, body: fixed(`{"title":"renamed"}`), actor: "owner", forbidden: true},
+		{name: "practice delete by member", method: http.MethodDelete, path: practicePath, actor: "owner", forbidden: true},
+		{name: "practice delete by admin", method: http.MethodDelete, path: practicePath, actor: "admin"},
+		{name: "practice start by emcee", method: http.MethodPost, path: suffix(practicePath, "/start"), actor: "emcee"},
+		{name: "practice start by member", method: http.MethodPost, path: suffix(practicePath, "/start"), actor: "owner", forbidden: true},
+		{name: "practice cancel by emcee", method: http.MethodPost, path: suffix(practicePath, "/cancel"), actor: "emcee"},
+		{name: "practice cancel by member", method: http.MethodPost, path: suffix(practicePath, "/cancel"), actor: "owner", forbidden: true},
+		{name: "practice agenda by attendee", method: http.MethodGet, path: suffix(practicePath, "/agenda"), actor: "owner"},
+		{name: "practice agenda by outsider", method: http.MethodGet, path: suffix(practicePath, "/agenda"), actor: "outsider", forbidden: true},
+		{name: "practice messages by attendee", method: http.MethodGet, path: suffix(practicePath, "/messages"), actor: "owner"},
+		{name: "practice messages by outsider", method: http.MethodGet, path: suffix(practicePath, "/messages"), actor: "outsider", forbidden: true},
+		{name: "speaking order by emcee", method: http.MethodPut, path: suffix(practicePath, "/speaking-order"), body: fixed(`{"user_ids":[]}`), actor: "emcee"},
+		{name: "speaking order by member", method: http.MethodPut, path: suffix(practicePath, "/speaking-order"), body: fixed(`{"user_ids":[]}`), actor: "owner", forbidden: true},
+		{name: "practices by user for self", method: http.MethodGet, path: byUser("/v1/practices", "owner"), actor: "owner"},
+		{name: "practices by user for other", method: http.MethodGet, path: byUser("/v1/practices", "owner"), actor: "emcee", forbidden: true},
+
+		// Event attendees
+		{name: "event attendee self join", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: attendeeBody("outsider", "member"), actor: "outsider", forbidden: true},
+		{name: "event attendee self join guild event by member", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: guildEventAttendeeBody("member"), actor: "member"},
+		{name: "event attendee self join guild event by outsider", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: guildEventAttendeeBody("outsider"), actor: "outsider", forbidden: true},
+		{name: "event attendee self promote", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: attendeeBody("outsider", "emcee"), actor: "outsider", forbidden: true},
+		{name: "event attendee added by emcee", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: attendeeBody("outsider", "emcee"), actor: "emcee"},
+		{name: "event attendee add other by member", method: http.MethodPost, path: fixed("/v1/event-attendees"), body: attendeeBody("outsider", "member"), actor: "owner", forbidden: true},
+		{name: "event attendee get by attendee", method: http.MethodGet, path: ownerEAPath, actor: "emcee"},
+		{name: "event attendee get by outsider", method: http.MethodGet, path: ownerEAPath, actor: "outsider", forbidden: true},
+		{name: "event attendee update by emcee", method: http.MethodPut, path: ownerEAPath, body: attendeeBody("owner", "member"), actor: "emcee"},
+		{name: "event attendee update by self", method: http.MethodPut, path: ownerEAPath, body: attendeeBody("owner", "emcee"), actor: "owner", forbidden: true},
+		{name: "event attendee delete by self", method: http.MethodDelete, path: ownerEAPath, actor: "owner"},
+		{name: "event attendee delete by outsider", method: http.MethodDelete, path: ownerEAPath, actor: "outsider", forbidden: true},
+		{name: "event attendees by event for attendee", method: http.MethodGet, path: byEvent("/v1/event-attendees"), actor: "owner"},
+		{name: "event attendees by event for outsider", method: http.MethodGet, path: byEvent("/v1/event-attendees"), actor: "outsider", forbidden: true},
+		{name: "event attendees by user for self", method: http.MethodGet, path: byUser("/v1/event-attendees", "owner"), actor: "owner"},
+		{name: "event attendees by user for other", method: http.MethodGet, path: byUser("/v1/event-attendees", "owner"), actor: "outsider", forbidden: true},
+
+		// Guilds
+		{name: "guild create", method: http.MethodPost, path: fixed("/v1/guilds"), body: fixed(`{"name":"new"}`), actor: "outsider"},
+		{name: "guild get", method: http.MethodGet, path: guildPath, actor: "outsider"},
+		{name: "guild update by master", method: http.MethodPut, path: guildPath, body: fixed(`{"name":"renamed"}`), actor: "master"},
+		{name: "guild update by member", method: http.MethodPut, path: guildPath, body: fixed(`{"name":"renamed"}`), actor: "member", forbidden: true},
+		{name: "guild delete by outsider", method: http.MethodDelete, path: guildPath, actor: "outsider", forbidden: true},
+
+		// Guild attendees
+		{name: "guild attendee self join", method: http.MethodPost, path: fixed("/v1/guild-attendees"), body: guildAttendeeBody("outsider", "member"), actor: "outsider", forbidden: true},
+		{name: "guild attendee self promote", method: http.MethodPost, path: fixed("/v1/guild-attendees"), body: guildAttendeeBody("outsider", "master"), actor: "outsider", forbidden: true},
+		{name: "guild attendee added by master", method: http.MethodPost, path: fixed("/v1/guild-attendees"), body: guildAttendeeBody("outsider", "master"), actor: "master", forbidden: true},
+		{name: "guild attendee added by admin", method: http.MethodPost, path: fixed("/v1/guild-attendees"), body: guildAttendeeBody("outsider", "member"), actor: "admin"},
+		{name: "guild attendee get by member", method: http.MethodGet, path: memberGAPath, actor: "master"},
+		{name: "guild attendee get by outsider", method: http.MethodGet, path: memberGAPath, actor: "outsider", forbidden: true},
+		{name: "guild attendee promote self", method: http.MethodPut, path: memberGAPath, body: guildAttendeeBody("member", "master"), actor: "member", forbidden: true},
+		{name: "guild attendee promote by master", method: http.MethodPut, path: memberGAPath, body: guildAttendeeBody("member", "master"), actor: "master"},
+		{name: "guild attendee leave", method: http.MethodDelete, path: memberGAPath, actor: "member"},
+		{name: "guild attendee delete by outsider", method: http.MethodDelete, path: memberGAPath, actor: "outsider", forbidden: true},
+
+		// Guild discovery
+		{name: "guild search", method: http.MethodGet, path: fixed("/v1/guilds?q=guild&min_members=1"), actor: "outsider"},
+
+		// Guild membership
+		{name: "guild invite create by master", method: http.MethodPost, path: suffix(guildPath, "/invites"), body: fixed(`{}`), actor: "master"},
+		{name: "guild invite create by member", method: http.MethodPost, path: suffix(guildPath, "/invites"), body: fixed(`{}`), actor: "member", forbidden: true},
+		{name: "guild invite list by master", method: http.MethodGet, path: suffix(guildPath, "/invites"), actor: "master"},
+		{name: "guild invite list by member", method: http.MethodGet, path: suffix(guildPath, "/invites"), actor: "member", forbidden: true},
+		{name: "guild invite revoke by outsider", method: http.MethodDelete, path: suffix(guildPath, "/invites/"+uuid.NewString()), actor: "outsider", forbidden: true},
+		{name: "guild join request by outsider", method: http.MethodPost, path: suffix(guildPath, "/join-requests"), body: fixed(`{"message":"hi"}`), actor: "outsider"},
+		{name: "guild join request list by master", method: http.MethodGet, path: suffix(guildPath, "/join-requests"), actor: "master"},
+		{name: "guild join request list by member", method: http.MethodGet, path: suffix(guildPath, "/join-requests"), actor: "member", forbidden: true},
+		{name: "guild join request approve by member", method: http.MethodPost, path: suffix(guildPath, "/join-requests/"+uuid.NewString()+"/approve"), actor: "member", forbidden: true},
+		{name: "guild join request reject by outsider", method: http.MethodPost, path: suffix(guildPath, "/join-requests/"+uuid.NewString()+"/reject"), actor: "outsider", forbidden: true},
+		{name: "guild attendees by guild for member", method: http.MethodGet, path: func(f *policyFixture) string { return "/v1/guild-attendees/guild/" + f.guild.String() }, actor: "member"},
+		{name: "guild attendees by guild for outsider", method: http.MethodGet, path: func(f *policyFixture) string { return "/v1/guild-attendees/guild/" + f.guild.String() }, actor: "outsider", forbidden: true},
+		{name: "guild attendees by user for self", method: http.MethodGet, path: byUser("/v1/guild-attendees", "member"), actor: "member"},
+		{name: "guild attendees by user for other", method: http.MethodGet, path: byUser("/v1/guild-attendees", "member"), actor: "master", forbidden: true},
+
+		// Guild events
+		{name: "guild event create by master", method: http.MethodPost, path: fixed("/v1/practices"), body: guildEventBody, actor: "master"},
+		{name: "guild event create by member", method: http.MethodPost, path: fixed("/v1/practices"), body: guildEventBody, actor: "member", forbidden: true},
+		{name: "guild event create by outsider", method: http.MethodPost, path: fixed("/v1/practices"), body: guildEventBody, actor: "outsider", forbidden: true},
+		{name: "guild event update by emcee", method: http.MethodPut, path: guildEventPath, body: fixed(`{"title":"renamed"}`), actor: "emcee"},
+		{name: "guild event delete by emcee", method: http.MethodDelete, path: guildEventPath, actor: "emcee", forbidden: true},
+		{name: "guild event delete by member", method: http.MethodDelete, path: guildEventPath, actor: "member", forbidden: true},
+		{name: "guild event delete by master", method: http.MethodDelete, path: guildEventPath, actor: "master"},
+		{name: "guild event start by emcee", method: http.MethodPost, path: suffix(guildEventPath, "/start"), actor: "emcee"},
+		{name: "guild event start by master", method: http.MethodPost, path: suffix(guildEventPath, "/start"), actor: "master"},
+		{name: "guild event start by member", method: http.MethodPost, path: suffix(guildEventPath, "/start"), actor: "member", forbidden: true},
+		{name: "guild event cancel by emcee", method: http.MethodPost, path: suffix(guildEventPath, "/cancel"), actor: "emcee", forbidden: true},
+		{name: "guild event cancel by master", method: http.MethodPost, path: suffix(guildEventPath, "/cancel"), actor: "master"},
+		{name: "guild events for member", method: http.MethodGet, path: suffix(guildPath, "/events"), actor: "member"},
+		{name: "guild events for outsider", method: http.MethodGet, path: suffix(guildPath, "/events"), actor: "outsider", forbidden: true},
+
+		// Recurring practices
+		{name: "series create", method: http.MethodPost, path: fixed("/v1/event-series"), body: fixed(`{"title":"weekly","start_time":"2030-01-07T19:00:00Z","rrule":"FREQ=WEEKLY"}`), actor: "outsider"},
+		{name: "guild series create by master", method: http.MethodPost, path: fixed("/v1/event-series"), body: guildSeriesBody, actor: "master"},
+		{name: "guild series create by member", method: http.MethodPost, path: fixed("/v1/event-series"), body: guildSeriesBody, actor: "member", forbidden: true},
+		{name: "series get by creator", method: http.MethodGet, path: seriesPath, actor: "owner"},
+		{name: "series get by outsider", method: http.MethodGet, path: seriesPath, actor: "outsider", forbidden: true},
+		{name: "series update by creator", method: http.MethodPut, path: seriesPath, body: seriesBody, actor: "owner"},
+		{name: "series update by outsider", method: http.MethodPut, path: seriesPath, body: seriesBody, actor: "outsider", forbidden: true},
+		{name: "series delete by outsider", method: http.MethodDelete, path: seriesPath, actor: "outsider", forbidden: true},
+		{name: "series events by outsider", method: http.MethodGet, path: suffix(seriesPath, "/events"), actor: "outsider", forbidden: true},
+		{name: "guild series get by member", method: http.MethodGet, path: guildSeriesPath, actor: "member"},
+		{name: "guild series events by member", method: http.MethodGet, path: suffix(guildSeriesPath, "/events"), actor: "member"},
+		{name: "guild series update by member", method: http.MethodPut, path: guildSeriesPath, body: seriesBody, actor: "member", forbidden: true},
+		{name: "guild series update by master", method: http.MethodPut, path: guildSeriesPath, body: seriesBody, actor: "master"},
+		{name: "guild series delete by master", method: http.MethodDelete, path: guildSeriesPath, actor: "master"},
+		{name: "series delete by staff", method: http.MethodDelete, path: seriesPath, actor: "staff"},
+
+		// Calendar feeds
+		{name: "own calendar URL", method: http.MethodGet, path: fixed("/v1/me/calendar"), actor: "outsider"},
+		{name: "guild calendar URL for member", method: http.MethodGet, path: suffix(guildPath, "/calendar"), actor: "member"},
+		{name: "guild calendar URL for outsider", method: http.MethodGet, path: suffix(guildPath, "/calendar"), actor: "outsider", forbidden: true},
+
+		// WebRTC
+		{name: "ICE servers", method: http.MethodGet, path: fixed("/v1/webrtc/ice-servers"), actor: "outsider"},
+
+		// Users
+		{name: "user create by user", method: http.MethodPost, path: fixed("/v1/users"), body: fixed(`{"user_id":"00000000-0000-0000-0000-000000000001","name":"x","email":"x@example.com"}`), actor: "outsider", forbidden: true},
+		{name: "user create by staff", method: http.MethodPost, path: fixed("/v1/users"), body: fixed(`{"user_id":"00000000-0000-0000-0000-000000000001","name":"x","email":"x@example.com"}`), actor: "staff"},
+		{name: "user get", method: http.MethodGet, path: userPath("owner"), actor: "outsider"},
+		{name: "user update self", method: http.MethodPut, path: userPath("owner"), body: userBody("owner"), actor: "owner"},
+		{name: "user update other", method: http.MethodPut, path: userPath("owner"), body: userBody("owner"), actor: "outsider", forbidden: true},
+		{name: "user update other by staff", method: http.MethodPut, path: userPath("owner"), body: userBody("owner"), actor: "staff"},
+		{name: "user delete other", method: http.MethodDelete, path: userPath("owner"), actor: "outsider", forbidden: true},
+		{name: "user delete self", method: http.MethodDelete, path: userPath("outsider"), actor: "outsider"},
+		{name: "user by name", method: http.MethodGet, path: fixed("/v1/users/name/owner"), actor: "outsider"},
+		{name: "user by email", method: http.MethodGet, path: fixed("/v1/users/email/owner@example.com"), actor: "outsider"},
+		{name: "user points for self", method: http.MethodGet, path: func(f *policyFixture) string { return userPath("owner")(f) + "/points" }, actor: "owner"},
+		{name: "user points for other", method: http.MethodGet, path: func(f *policyFixture) string { return userPath("owner")(f) + "/points" }, actor: "outsider", forbidden: true},
+
+		// Leaderboards
+		{name: "leaderboard", method: http.MethodGet, path: fixed("/v1/leaderboard"), actor: "outsider"},
+		{name: "guild leaderboard for member", method: http.MethodGet, path: func(f *policyFixture) string { return guildPath(f) + "/leaderboard" }, actor: "member"},
+		{name: "guild leaderboard for outsider", method: http.MethodGet, path: func(f *policyFixture) string { return guildPath(f) + "/leaderboard" }, actor: "outsider", forbidden: true},
+
+		// Points
+		{name: "points recompute by user", method: http.MethodPost, path: fixed("/v1/points/recompute"), actor: "owner", forbidden: true},
+		{name: "points recompute by admin", method: http.MethodPost, path: fixed("/v1/points/recompute"), actor: "admin"},
+		{name: "debug vars by user", method: http.MethodGet, path: fixed("/v1/debug/vars"), actor: "owner", forbidden: true},
+		{name: "debug vars by staff", method: http.MethodGet, path: fixed("/v1/debug/vars"), actor: "staff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPolicyFixture(t)
+			body := ""
+			if tt.body != nil {
+				body = tt.body(f)
+			}
+
+			w := doRequest(t, f.router, tt.method, tt.path(f), f.tokens[tt.actor], body)
+
+			if tt.forbidden {
+				assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+			} else {
+				assert.NotEqual(t, http.StatusForbidden, w.Code, w.Body.String())
+				assert.Less(t, w.Code, 300, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthorization_UnauthenticatedRejected(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	w := doRequest(t, f.router, http.MethodGet, "/v1/mistakes/"+f.mistake.String(), "", "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthorization_MissingResourceIsNotFound(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	w := doRequest(t, f.router, http.MethodGet, "/v1/mistakes/"+uuid.New().String(), f.tokens["outsider"], "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUserUpdate_CannotEscalateRole(t *testing.T) {
+	f := newPolicyFixture(t)
+	body := `{"name":"owner","email":"owner@example.com","role":"admin","status":"active"}`
+
+	w := doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["owner"], body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"user"`)
+}
+
+func TestUserUpdate_SelfOnlyChangesProfile(t *testing.T) {
+	f := newPolicyFixture(t)
+	body := `{"name":"renamed","email":"taken@example.com","is_email_verified":true,"deleted_at":"2024-01-01T00:00:00Z"}`
+
+	w := doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["owner"], body)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got domain.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "renamed", got.Name)
+	assert.Equal(t, "owner@example.com", got.Email)
+	assert.False(t, got.IsEmailVerified)
+	assert.False(t, got.DeletedAt.Valid)
+
+	w = doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["owner"], `{"timezone":"Mars/Olympus"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserUpdate_AdminKeepsOmittedFields(t *testing.T) {
+	f := newPolicyFixture(t)
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	owner := f.store.users[f.users["owner"]]
+	owner.Timezone, owner.CreatedAt = "Asia/Tokyo", created
+
+	w := doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["admin"], `{"role":"staff","deleted_at":"2024-01-01T00:00:00Z"}`)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got domain.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, domain.UserRoleStaff, got.Role)
+	assert.Equal(t, "owner", got.Name)
+	assert.Equal(t, "owner@example.com", got.Email)
+	assert.Equal(t, "Asia/Tokyo", got.Timezone)
+	assert.True(t, created.Equal(got.CreatedAt))
+	assert.False(t, got.DeletedAt.Valid)
+
+	w = doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["admin"], `{"role":"root"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserUpdate_CannotWritePoints(t *testing.T) {
+	f := newPolicyFixture(t)
+	body := `{"name":"owner","email":"owner@example.com","points":9999,"level":99,"late_streak":0}`
+
+	// Not even admins: points only come from the ledger
+	w := doRequest(t, f.router, http.MethodPut, "/v1/users/"+f.users["owner"].String(), f.tokens["admin"], body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"points":0`)
+	assert.Contains(t, w.Body.String(), `"level":0`)
+}
+
+func TestEventAttendeeUpdate_OnlyChangesRole(t *testing.T) {
+	f := newPolicyFixture(t)
+	body := fmt.Sprintf(`{"event_id":%q,"user_id":%q,"role":"emcee","punctuality":"on_time","joined_at":"2024-01-01T00:00:00Z"}`, f.guildEvent, f.users["emcee"])
+
+	w := doRequest(t, f.router, http.MethodPut, "/v1/event-attendees/"+f.ownerEA.String(), f.tokens["emcee"], body)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var got domain.EventAttendee
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, f.event, got.EventID)
+	assert.Equal(t, f.users["owner"], got.UserID)
+	assert.Equal(t, domain.EventAttendeeRoleEmcee, got.Role)
+	assert.Nil(t, got.Punctuality)
+	assert.Nil(t, got.JoinedAt)
+
+	w = doRequest(t, f.router, http.MethodPut, "/v1/event-attendees/"+f.ownerEA.String(), f.tokens["emcee"], `{"role":"host"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRecordUpdate_KeepsEventAndUser(t *testing.T) {
+	f := newPolicyFixture(t)
+	body := fmt.Sprintf(`{"event_id":%q,"user_id":%q,"content":"moved","fixed_text":"moved"}`, f.guildEvent, f.users["outsider"])
+
+	for _, path := range []string{"/v1/mistakes/" + f.mistake.String(), "/v1/transcripts/" + f.script.String()} {
+		w := doRequest(t, f.router, http.MethodPut, path, f.tokens["owner"], body)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var got struct {
+			EventID uuid.UUID `json:"event_id"`
+			UserID  uuid.UUID `json:"user_id"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+		assert.Equal(t, f.event, got.EventID, path)
+		assert.Equal(t, f.users["owner"], got.UserID, path)
+	}
+}
+
+func TestGuildUpdate_CannotWriteLevel(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	w := doRequest(t, f.router, http.MethodPut, "/v1/guilds/"+f.guild.String(), f.tokens["master"], `{"name":"guild","level":99}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"level":0`)
+
+	w = doRequest(t, f.router, http.MethodPost, "/v1/guilds", f.tokens["outsider"], `{"name":"mine","level":99}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"level":0`)
+}
+
+func TestPracticeCreate_CreatorBecomesEmcee(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	w := doRequest(t, f.router, http.MethodPost, "/v1/practices", f.tokens["outsider"], `{"title":"mine"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created struct {
+		ID uuid.UUID `json:"event_id"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = doRequest(t, f.router, http.MethodPut, "/v1/practices/"+created.ID.String(), f.tokens["outsider"], `{"title":"renamed"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -37,8 +37,29 @@ func (a *API) PracticeCreateHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// New practices are always scheduled; the rest of the lifecycle goes
+	// through the transition endpoints
+	practice.Status = domain.EventStatusScheduled
+	practice.StartedAt, practice.EndedAt, practice.ReviewedAt, practice.CancelledAt = nil, nil, nil, nil
+	practice.ActualDuration = nil
+	if err := a.agenda.Validate(c.Request.Context(), &practice); err != nil {
+		respondAgendaError(c, err)
+		return
+	}
+
+	if practice.GuildID != nil {
+		if _, err := a.guildRepo.GetByID(c.Request.Context(), *practice.GuildID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Guild not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	if err := a.eventRepo.Create(c.Request.Context(), &practice); err != nil {
+	// The creator hosts the practice
+	if err := a.eventRepo.CreateWithEmcee(c.Request.Context(), &practice, actorFrom(c).UserID); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Event already exists"})
 			return
@@ -59,7 +80,7 @@ func (a *API) PracticeUpdateHandler(c *gin.Context) {
 	}
 
 	// Check if record exists first
-	_, err = a.eventRepo.GetByID(c.Request.Context(), id)
+	existing, err := a.eventRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Practice not found"})
@@ -75,7 +96,24 @@ func (a *API) PracticeUpdateHandler(c *gin.Context) {
 		return
 	}
 
+	// A practice cannot move between guilds or series. Editing one
+	// occurrence of a series detaches it from later edits of the series.
 	practice.ID = id
+	practice.GuildID = existing.GuildID
+	practice.SeriesID = existing.SeriesID
+	practice.OccurrenceStart = existing.OccurrenceStart
+	practice.Detached = existing.SeriesID != nil
+	// The lifecycle only changes through the transition endpoints
+	practice.Status = existing.Status
+	practice.StartedAt = existing.StartedAt
+	practice.EndedAt = existing.EndedAt
+	practice.ReviewedAt = existing.ReviewedAt
+	practice.CancelledAt = existing.CancelledAt
+	practice.ActualDuration = existing.ActualDuration
+	if err := a.agenda.Validate(c.Request.Context(), &practice); err != nil {
+		respondAgendaError(c, err)
+		return
+	}
 	if err := a.eventRepo.Update(c.Request.Context(), &practice); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Event already exists"})
@@ -91,54 +129,62 @@ func (a *API) PracticeUpdateHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// The mode, times or review may have changed
+	a.pushAgenda(c.Request.Context(), id)
 
 	c.JSON(http.StatusOK, updated)
 }
 
-func (a *API) PracticeDeleteHandler(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
+func (a *API) PracticeGetByUserHandler(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
 		return
 	}
 
-	// Check if record exists first
-	_, err = a.eventRepo.GetByID(c.Request.Context(), id)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Practice not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := a.eventRepo.Delete(c.Request.Context(), id); err != nil {
-		if errors.Is(err, domain.ErrHasRelatedRecords) {
-			c.JSON(http.StatusConflict, gin.H{"error": "cannot delete event: has related records"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	page, err := a.eventRepo.ListByUserID(c.Request.Context(), userID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, page)
 }
 
-func (a *API) PracticeGetByUserHandler(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := uuid.Parse(userIDStr)
+func (a *API) GuildEventListHandler(c *gin.Context) {
+	guildID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
 		return
 	}
 
-	practices, err := a.eventRepo.GetByUserID(c.Request.Context(), userID)
-	if err != nil {
+	if _, err := a.guildRepo.GetByID(c.Request.Context(), guildID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Guild not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, practices)
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.eventRepo.ListByGuildID(c.Request.Context(), guildID, opts)
+	if err != nil {
+		respondListError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// practiceReviewRequest optionally attaches the recording when a practice is
+// marked as reviewed.
+type practiceReviewRequest struct {
+	RecordLink *string `json:"record_link"`
+}
+
+// loadPractice fetches the practice named by the :id param, responding with
+// the error itself if it cannot.
+func (a *API) loadPractice(c *gin.Context) (*domain.Event, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return nil, false
+	}
+
+	practice, err := a.eventRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Practice not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return practice, true
+}
+
+// respondTransitionError maps errors of a status transition.
+func respondTransitionError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// transitionPractice moves the practice to status and saves it. The save
+// fails if another request transitioned the practice in the meantime.
+func (a *API) transitionPractice(c *gin.Context, practice *domain.Event, to domain.EventStatus) bool {
+	from := practice.Status
+	if err := practice.Transition(to, time.Now()); err != nil {
+		respondTransitionError(c, err)
+		return false
+	}
+	if err := a.eventRepo.Transition(c.Request.Context(), practice, from); err != nil {
+		respondTransitionError(c, err)
+		return false
+	}
+	return true
+}
+
+// closePracticeRoom removes everyone still in the practice's room and tells
+// them why with a message of msgType.
+func (a *API) closePracticeRoom(ctx context.Context, practice *domain.Event, msgType string) {
+	roomID := practice.ID.String()
+	for _, client := range a.webrtcHub.CloseRoom(roomID, msgType, RoomPayload{EventID: roomID}) {
+		a.recordAttendance(ctx, client, roomID, domain.AttendanceActionLeave)
+	}
+}
+
+// endPractice ends a live practice. ActualDuration is set to the minutes
+// since it started, attendees' punctuality and LateStreak are settled,
+// points are awarded, and everyone still in the event's room is removed
+// from it.
+func (a *API) endPractice(ctx context.Context, practice *domain.Event) error {
+	if err := practice.Transition(domain.EventStatusEnded, time.Now()); err != nil {
+		return err
+	}
+	if err := a.punctuality.CloseEvent(ctx, practice); err != nil {
+		return err
+	}
+	// The event is already closed at this point, so a failed award must not
+	// fail the request; it only affects the attendees' points
+	if err := a.points.AwardEvent(ctx, practice); err != nil {
+		log.Printf("積分發放失敗 (event: %s): %v", practice.ID, err)
+	}
+
+	// The event is marked as ended first, so nobody can rejoin the room
+	a.closePracticeRoom(ctx, practice, msgSessionEnded)
+	return nil
+}
+
+// PracticeStartHandler takes a scheduled practice live.
+func (a *API) PracticeStartHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+	if !a.transitionPractice(c, practice, domain.EventStatusLive) {
+		return
+	}
+	// Report slots now count from the actual start
+	a.pushAgenda(c.Request.Context(), practice.ID)
+
+	c.JSON(http.StatusOK, practice)
+}
+
+// PracticeEndHandler ends a live practice, see endPractice.
+func (a *API) PracticeEndHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+	if err := a.endPractice(c.Request.Context(), practice); err != nil {
+		respondTransitionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, practice)
+}
+
+// PracticeCancelHandler cancels a practice that has not started. It serves
+// both POST /cancel and DELETE, and either way the practice stays visible
+// with its cancelled status.
+func (a *API) PracticeCancelHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+	if !a.transitionPractice(c, practice, domain.EventStatusCancelled) {
+		return
+	}
+
+	// Attendees may already be waiting in the room
+	a.closePracticeRoom(c.Request.Context(), practice, msgSessionCanceled)
+
+	c.JSON(http.StatusOK, practice)
+}
+
+// PracticeReviewHandler marks an ended practice as reviewed, optionally
+// attaching its recording.
+func (a *API) PracticeReviewHandler(c *gin.Context) {
+	practice, ok := a.loadPractice(c)
+	if !ok {
+		return
+	}
+
+	var req practiceReviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.RecordLink != nil {
+		practice.RecordLink = req.RecordLink
+	}
+	if !a.transitionPractice(c, practice, domain.EventStatusReviewed) {
+		return
+	}
+
+	c.JSON(http.StatusOK, practice)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPracticeLifecycle(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	transition := func(t *testing.T, id, action, body string) (int, domain.Event) {
+		t.Helper()
+		w := doRequest(t, f.router, http.MethodPost, "/v1/practices/"+id+"/"+action, f.tokens["owner"], body)
+		var event domain.Event
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		}
+		return w.Code, event
+	}
+	create := func(t *testing.T) string {
+		t.Helper()
+		// Clients cannot skip the lifecycle when creating a practice
+		w := doRequest(t, f.router, http.MethodPost, "/v1/practices", f.tokens["owner"], `{"title":"practice","status":"ended","actual_duration":30}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, domain.EventStatusScheduled, event.Status)
+		assert.Nil(t, event.ActualDuration)
+		return event.ID.String()
+	}
+
+	t.Run("Reviewed", func(t *testing.T) {
+		id := create(t)
+
+		code, event := transition(t, id, "start", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, domain.EventStatusLive, event.Status)
+		assert.NotNil(t, event.StartedAt)
+
+		// Editing the practice does not touch its lifecycle
+		w := doRequest(t, f.router, http.MethodPut, "/v1/practices/"+id, f.tokens["owner"], `{"title":"renamed","status":"scheduled"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, domain.EventStatusLive, event.Status)
+
+		code, _ = transition(t, id, "cancel", "")
+		assert.Equal(t, http.StatusConflict, code, "live practices cannot be cancelled")
+
+		code, event = transition(t, id, "end", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, domain.EventStatusEnded, event.Status)
+		assert.NotNil(t, event.EndedAt)
+		assert.NotNil(t, event.ActualDuration)
+
+		code, event = transition(t, id, "review", `{"record_link":"https://example.com/recording"}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, domain.EventStatusReviewed, event.Status)
+		assert.NotNil(t, event.ReviewedAt)
+		require.NotNil(t, event.RecordLink)
+		assert.Equal(t, "https://example.com/recording", *event.RecordLink)
+
+		for _, action := range []string{"start", "end", "cancel", "review"} {
+			code, _ := transition(t, id, action, "")
+			assert.Equal(t, http.StatusConflict, code, action)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		id := create(t)
+
+		code, _ := transition(t, id, "end", "")
+		assert.Equal(t, http.StatusConflict, code, "scheduled practices cannot end")
+
+		code, event := transition(t, id, "cancel", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, domain.EventStatusCancelled, event.Status)
+		assert.NotNil(t, event.CancelledAt)
+
+		code, _ = transition(t, id, "start", "")
+		assert.Equal(t, http.StatusConflict, code)
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		id := create(t)
+
+		// Deleting cancels the practice, which keeps its emcee attendee
+		w := doRequest(t, f.router, http.MethodDelete, "/v1/practices/"+id, f.tokens["owner"], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doRequest(t, f.router, http.MethodGet, "/v1/practices/"+id, f.tokens["owner"], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, domain.EventStatusCancelled, event.Status)
+
+		w = doRequest(t, f.router, http.MethodDelete, "/v1/practices/"+id, f.tokens["owner"], "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
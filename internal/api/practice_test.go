@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuildEvents(t *testing.T) {
+	f := newPolicyFixture(t)
+
+	create := func(t *testing.T, start string) *domain.Event {
+		t.Helper()
+		body := fmt.Sprintf(`{"title":"guild night","start_time":%q,"guild_id":%q}`, start, f.guild)
+		w := doRequest(t, f.router, http.MethodPost, "/v1/practices", f.tokens["master"], body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		return &event
+	}
+	march := create(t, "2025-03-10T19:00:00Z")
+	april := create(t, "2025-04-10T19:00:00Z")
+	require.NotNil(t, march.GuildID)
+	assert.Equal(t, f.guild, *march.GuildID)
+
+	t.Run("DateRange", func(t *testing.T) {
+		path := "/v1/guilds/" + f.guild.String() + "/events?start_after=2025-04-01T00:00:00Z&start_before=2025-05-01T00:00:00Z"
+		w := doRequest(t, f.router, http.MethodGet, path, f.tokens["member"], "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page domain.Page[*domain.Event]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, april.ID, page.Items[0].ID)
+	})
+
+	t.Run("UpdateKeepsGuild", func(t *testing.T) {
+		body := fmt.Sprintf(`{"title":"moved","guild_id":%q}`, uuid.New())
+		w := doRequest(t, f.router, http.MethodPut, "/v1/practices/"+march.ID.String(), f.tokens["master"], body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var event domain.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+		assert.Equal(t, f.guild, *event.GuildID)
+	})
+
+	t.Run("UnknownGuild", func(t *testing.T) {
+		body := fmt.Sprintf(`{"title":"lost","guild_id":%q}`, uuid.New())
+		w := doRequest(t, f.router, http.MethodPost, "/v1/practices", f.tokens["outsider"], body)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doRequest(t, f.router, http.MethodGet, "/v1/guilds/"+uuid.NewString()+"/events", f.tokens["outsider"], "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
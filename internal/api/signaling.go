@@ -0,0 +1,442 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+// ProtocolVersion is the version of the signaling protocol spoken on /ws.
+// Client messages without "v" are taken as version 1; the server sets "v"
+// on every message it sends.
+const ProtocolVersion = 1
+
+// maxRequestIDLength bounds the request IDs echoed back to clients
+const maxRequestIDLength = 128
+
+// Message types of the signaling protocol; messageCatalog describes them.
+const (
+	msgAuth              = "auth"
+	msgConnected         = "connected"
+	msgJoinRoom          = "join-room"
+	msgLeaveRoom         = "leave-room"
+	msgGetOnlineUsers    = "get-online-users"
+	msgOnlineUsersList   = "online-users-list"
+	msgCurrentUsers      = "current-users"
+	msgUserJoined        = "user-joined"
+	msgUserLeft          = "user-left"
+	msgOffer             = "offer"
+	msgAnswer            = "answer"
+	msgICECandidate      = "ice-candidate"
+	msgRaiseHand         = "raise-hand"
+	msgLowerHand         = "lower-hand"
+	msgMuteRequest       = "mute-request"
+	msgKick              = "kick"
+	msgGrantFloor        = "grant-floor"
+	msgNextSpeaker       = "next-speaker"
+	msgReorderQueue      = "reorder-queue"
+	msgSkipSpeaker       = "skip-speaker"
+	msgStartTimer        = "start-timer"
+	msgStopTimer         = "stop-timer"
+	msgEndSession        = "end-session"
+	msgRoomState         = "room-state"
+	msgTimerExpired      = "timer-expired"
+	msgSessionEnded      = "session-ended"
+	msgSessionCanceled   = "session-cancelled"
+	msgAgendaUpdated     = "agenda-updated"
+	msgChatMessage       = "chat-message"
+	msgEditChatMessage   = "edit-chat-message"
+	msgDeleteChatMessage = "delete-chat-message"
+	msgChatHistory       = "chat-history"
+	msgAck               = "ack"
+	msgError             = "error"
+)
+
+// noPayload stands for the payload of messages that carry none; whatever
+// clients send along is ignored.
+var noPayload = struct{}{}
+
+// messageSpec describes a message type. request is the payload clients send
+// with it and event the payload the server sends with it, each nil when the
+// message does not travel that way.
+type messageSpec struct {
+	doc     string
+	request interface{}
+	event   interface{}
+}
+
+// messageCatalog lists every message of the protocol. Clients are answered
+// unknown_type for types without a request payload here, and the JSON
+// Schema of the protocol is generated from it.
+var messageCatalog = map[string]messageSpec{
+	msgAuth: {
+		doc:     "First message when no ?token= was given, within 10 seconds of connecting. resume restores a previous session.",
+		request: AuthPayload{},
+	},
+	msgConnected: {
+		doc:   "Sent once the connection is authenticated, ahead of any other message.",
+		event: ConnectedPayload{},
+	},
+	msgJoinRoom: {
+		doc:     "Join the room of an event, leaving any previous room.",
+		request: JoinPayload{},
+	},
+	msgLeaveRoom: {
+		doc:     "Leave the current room.",
+		request: noPayload,
+	},
+	msgGetOnlineUsers: {
+		doc:     "Request the users of the current room, or of eventId before joining.",
+		request: RoomPayload{},
+	},
+	msgOnlineUsersList: {
+		doc:   "Users of the room, answering get-online-users.",
+		event: []domain.OnlineUser{},
+	},
+	msgCurrentUsers: {
+		doc:   "The other users of the room, sent on join-room.",
+		event: []domain.OnlineUser{},
+	},
+	msgUserJoined: {
+		doc:   "A user joined the room; userId is its client ID.",
+		event: domain.OnlineUser{},
+	},
+	msgUserLeft: {
+		doc:   "The client with this ID left the room.",
+		event: "",
+	},
+	msgOffer: {
+		doc:     "WebRTC SDP offer for target, a client in the same room. The target receives it with sender instead of target.",
+		request: SignalPayload{},
+		event:   ForwardedSignal{},
+	},
+	msgAnswer: {
+		doc:     "WebRTC SDP answer for target, a client in the same room. The target receives it with sender instead of target.",
+		request: SignalPayload{},
+		event:   ForwardedSignal{},
+	},
+	msgICECandidate: {
+		doc:     "ICE candidate for target, a client in the same room. The target receives it with sender instead of target.",
+		request: SignalPayload{},
+		event:   ForwardedSignal{},
+	},
+	msgRaiseHand: {
+		doc:     "Join the speaking queue of the current room.",
+		request: noPayload,
+	},
+	msgLowerHand: {
+		doc:     "Leave the speaking queue of the current room.",
+		request: noPayload,
+	},
+	msgMuteRequest: {
+		doc:     "Emcee only: ask target to mute itself. Broadcast to the room.",
+		request: ControlPayload{},
+		event:   ControlBroadcast{},
+	},
+	msgKick: {
+		doc:     "Emcee only: remove target from the room. Broadcast to the room, target included.",
+		request: ControlPayload{},
+		event:   ControlBroadcast{},
+	},
+	msgGrantFloor: {
+		doc:     "Emcee only: give target the floor. Broadcast to the room, also for next-speaker.",
+		request: ControlPayload{},
+		event:   ControlBroadcast{},
+	},
+	msgNextSpeaker: {
+		doc:     "Emcee only: give the floor to the first raised hand, or the next speaker of the agenda.",
+		request: noPayload,
+	},
+	msgReorderQueue: {
+		doc:     "Emcee only: reorder the speaking queue; userIds must list every queued user.",
+		request: ControlPayload{},
+	},
+	msgSkipSpeaker: {
+		doc:     "Emcee only: remove userId, by default the first in line, from the speaking queue.",
+		request: ControlPayload{},
+	},
+	msgStartTimer: {
+		doc:     "Emcee only: start the turn timer of seconds (1-3600). Broadcast to the room with endsAt.",
+		request: ControlPayload{},
+		event:   ControlBroadcast{},
+	},
+	msgStopTimer: {
+		doc:     "Emcee only: stop the turn timer. Broadcast to the room.",
+		request: noPayload,
+		event:   ControlBroadcast{},
+	},
+	msgEndSession: {
+		doc:     "Emcee only: end the event like POST /v1/practices/:id/end.",
+		request: noPayload,
+	},
+	msgRoomState: {
+		doc:   "Floor, speaking queue and turn timer of the room, after every change and on join-room when not empty.",
+		event: domain.RoomState{},
+	},
+	msgTimerExpired: {
+		doc:   "The turn timer of the room ran out.",
+		event: RoomPayload{},
+	},
+	msgSessionEnded: {
+		doc:   "The event ended; everyone was removed from the room.",
+		event: RoomPayload{},
+	},
+	msgSessionCanceled: {
+		doc:   "The event was cancelled; everyone was removed from the room.",
+		event: RoomPayload{},
+	},
+	msgAgendaUpdated: {
+		doc:   "The agenda of the event changed; the payload is as GET /v1/practices/:id/agenda.",
+		event: domain.Agenda{},
+	},
+	msgChatMessage: {
+		doc:     "Send content (1-2000 characters) to the chat of the current room. Broadcast to the room, sender included, once saved.",
+		request: ChatPayload{},
+		event:   domain.EventMessageView{},
+	},
+	msgEditChatMessage: {
+		doc:     "Author only: replace the content of messageId. Broadcast to the room with edited_at set.",
+		request: ChatPayload{},
+		event:   domain.EventMessageView{},
+	},
+	msgDeleteChatMessage: {
+		doc:     "Author only: delete messageId. Broadcast to the room.",
+		request: ChatPayload{},
+		event:   ChatDeleted{},
+	},
+	msgChatHistory: {
+		doc:   "The last 100 chat messages of the room, oldest first, sent on join-room when there are any.",
+		event: []domain.EventMessageView{},
+	},
+	msgAck: {
+		doc:   "The message with requestId succeeded.",
+		event: AckPayload{},
+	},
+	msgError: {
+		doc:   "A message failed; requestId is set when the message had one.",
+		event: ErrorPayload{},
+	},
+}
+
+// ErrorCode classifies the errors sent to clients, so they can react
+// without parsing messages meant for people.
+type ErrorCode string
+
+const (
+	// CodeInvalidMessage is for frames that are not a message envelope
+	CodeInvalidMessage     ErrorCode = "invalid_message"
+	CodeUnsupportedVersion ErrorCode = "unsupported_version"
+	CodeUnknownType        ErrorCode = "unknown_type"
+	CodeInvalidPayload     ErrorCode = "invalid_payload"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotInRoom          ErrorCode = "not_in_room"
+	CodeEventNotFound      ErrorCode = "event_not_found"
+	// CodeEventClosed is for events that ended or were cancelled
+	CodeEventClosed     ErrorCode = "event_closed"
+	CodeNotAttendee     ErrorCode = "not_attendee"
+	CodeTargetNotInRoom ErrorCode = "target_not_in_room"
+	CodeMessageNotFound ErrorCode = "message_not_found"
+	// CodeRateLimited is for messages sent faster than the server accepts
+	CodeRateLimited ErrorCode = "rate_limited"
+	// CodeInvalidState is for messages the room's state does not allow,
+	// like next-speaker after the last speaker
+	CodeInvalidState ErrorCode = "invalid_state"
+	CodeInternal     ErrorCode = "internal"
+)
+
+// SignalError is an error a client message is answered with.
+type SignalError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *SignalError) Error() string {
+	return e.Message
+}
+
+func signalError(code ErrorCode, message string) *SignalError {
+	return &SignalError{Code: code, Message: message}
+}
+
+var errInternal = signalError(CodeInternal, "internal error")
+
+// ErrorPayload is the payload of error messages.
+type ErrorPayload struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// AckPayload is the payload of ack messages; Type is the type of the
+// acknowledged message.
+type AckPayload struct {
+	Type string `json:"type"`
+}
+
+// SignalPayload is the payload of offer, answer and ice-candidate. Fields
+// holds everything besides target as sent, since WebRTC stacks (iOS in
+// particular) break on any change to the format of SDP and candidates.
+type SignalPayload struct {
+	Target string
+	Fields map[string]json.RawMessage
+}
+
+func (p *SignalPayload) UnmarshalJSON(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil || fields == nil {
+		return signalError(CodeInvalidPayload, "invalid payload")
+	}
+	target, ok := fields["target"]
+	if !ok {
+		return signalError(CodeInvalidPayload, "missing target")
+	}
+	if err := json.Unmarshal(target, &p.Target); err != nil {
+		return signalError(CodeInvalidPayload, "invalid target")
+	}
+	delete(fields, "target")
+	p.Fields = fields
+	return nil
+}
+
+func (p SignalPayload) MarshalJSON() ([]byte, error) {
+	return marshalFields(p.Fields, "target", p.Target)
+}
+
+func (SignalPayload) jsonSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"target": map[string]interface{}{"type": "string"}},
+		"required":             []string{"target"},
+		"additionalProperties": true,
+	}
+}
+
+// ForwardedSignal is a SignalPayload as its target receives it: Sender, the
+// client ID of the sender, in place of the target.
+type ForwardedSignal struct {
+	Sender string
+	Fields map[string]json.RawMessage
+}
+
+func (p ForwardedSignal) MarshalJSON() ([]byte, error) {
+	return marshalFields(p.Fields, "sender", p.Sender)
+}
+
+func (ForwardedSignal) jsonSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"sender": map[string]interface{}{"type": "string"}},
+		"required":             []string{"sender"},
+		"additionalProperties": true,
+	}
+}
+
+// marshalFields encodes fields with key set to value.
+func marshalFields(fields map[string]json.RawMessage, key, value string) ([]byte, error) {
+	out := make(map[string]json.RawMessage, len(fields)+1)
+	for k, v := range fields {
+		out[k] = v
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	out[key] = b
+	return json.Marshal(out)
+}
+
+// decodeMessage parses the envelope of a frame from a client. The payload
+// is left to decodePayload.
+func decodeMessage(data []byte) (Message, error) {
+	var m Message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Message{}, signalError(CodeInvalidMessage, "invalid message")
+	}
+	switch {
+	case m.Type == "":
+		return m, signalError(CodeInvalidMessage, "missing type")
+	case len(m.RequestID) > maxRequestIDLength:
+		m.RequestID = ""
+		return m, signalError(CodeInvalidMessage, fmt.Sprintf("requestId must be at most %d bytes", maxRequestIDLength))
+	case m.V < 0:
+		return m, signalError(CodeInvalidMessage, "invalid version")
+	case m.V > ProtocolVersion:
+		return m, signalError(CodeUnsupportedVersion, fmt.Sprintf("unsupported version, the server speaks %d", ProtocolVersion))
+	}
+	return m, nil
+}
+
+// decodePayload decodes the payload of m into a new value of the request
+// payload type of m.Type and returns a pointer to it, or nil for messages
+// without payload.
+func decodePayload(m Message) (interface{}, error) {
+	spec, ok := messageCatalog[m.Type]
+	if !ok || spec.request == nil {
+		return nil, signalError(CodeUnknownType, "unknown type")
+	}
+	if spec.request == noPayload {
+		return nil, nil
+	}
+	p := reflect.New(reflect.TypeOf(spec.request)).Interface()
+	if len(m.Payload) == 0 || bytes.Equal(m.Payload, []byte("null")) {
+		// Payload types that decode themselves decide whether they may be left out
+		if _, ok := p.(json.Unmarshaler); !ok {
+			return p, nil
+		}
+	}
+	if err := json.Unmarshal(m.Payload, p); err != nil {
+		var sigErr *SignalError
+		if errors.As(err, &sigErr) {
+			return nil, sigErr
+		}
+		return nil, signalError(CodeInvalidPayload, "invalid payload")
+	}
+	return p, nil
+}
+
+// encodeMessage builds the frame sent to clients for a message.
+func encodeMessage(msgType string, payload interface{}) ([]byte, error) {
+	return encodeReply(msgType, "", payload)
+}
+
+// encodeReply builds the frame of a message answering the client message
+// with requestID.
+func encodeReply(msgType, requestID string, payload interface{}) ([]byte, error) {
+	return json.Marshal(frame{V: ProtocolVersion, Type: msgType, RequestID: requestID, Payload: payload})
+}
+
+// frame is a message as the server sends it.
+type frame struct {
+	V         int         `json:"v"`
+	Type      string      `json:"type"`
+	RequestID string      `json:"requestId,omitempty"`
+	Payload   interface{} `json:"payload"`
+}
+
+// answer tells c how its message m went: an error when err is set, else an
+// ack if m asked for one with a request ID.
+func answer(c *domain.Client, m Message, err error) {
+	msgType, payload := msgAck, interface{}(AckPayload{Type: m.Type})
+	if err != nil {
+		var sigErr *SignalError
+		if !errors.As(err, &sigErr) {
+			log.Printf("處理 %s 訊息失敗 (user: %s): %v", m.Type, c.ID, err)
+			sigErr = errInternal
+		}
+		msgType, payload = msgError, ErrorPayload{Code: sigErr.Code, Message: sigErr.Message}
+	} else if m.RequestID == "" {
+		return
+	}
+	b, err := encodeReply(msgType, m.RequestID, payload)
+	if err == nil && !deliver(c, b) {
+		err = fmt.Errorf("client send channel full")
+	}
+	if err != nil {
+		log.Printf("傳送 %s 訊息失敗 (user: %s): %v", msgType, c.ID, err)
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schemaType is implemented by payload types encoding themselves, which
+// describe their JSON form instead of having it derived from their fields.
+type schemaType interface {
+	jsonSchema() map[string]interface{}
+}
+
+var (
+	schemaTypeType    = reflect.TypeOf((*schemaType)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+)
+
+// SignalingSchema returns the JSON Schema (draft 2020-12) of the messages
+// of messageCatalog: ClientMessage is what clients may send and
+// ServerMessage what they may receive. docs/signaling.schema.json holds it
+// for client teams; TestSignalingSchema keeps that file current.
+func SignalingSchema() map[string]interface{} {
+	b := &schemaBuilder{defs: map[string]interface{}{}}
+	types := make([]string, 0, len(messageCatalog))
+	for msgType := range messageCatalog {
+		types = append(types, msgType)
+	}
+	sort.Strings(types)
+
+	var client, server []interface{}
+	for _, msgType := range types {
+		spec := messageCatalog[msgType]
+		if spec.request != nil {
+			client = append(client, b.message("client."+msgType, msgType, spec.doc, spec.request, false))
+		}
+		if spec.event != nil {
+			server = append(server, b.message("server."+msgType, msgType, spec.doc, spec.event, true))
+		}
+	}
+	b.defs["ClientMessage"] = map[string]interface{}{"oneOf": client}
+	b.defs["ServerMessage"] = map[string]interface{}{"oneOf": server}
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "jpcorrect signaling protocol",
+		"description": fmt.Sprintf("Messages exchanged on /ws, protocol version %d.", ProtocolVersion),
+		"anyOf":       []interface{}{ref("ClientMessage"), ref("ServerMessage")},
+		"$defs":       b.defs,
+	}
+}
+
+// schemaBuilder collects the definitions of the named types it meets.
+type schemaBuilder struct {
+	defs map[string]interface{}
+	// names maps definition names back to their types, to catch clashes
+	names map[string]reflect.Type
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// message defines the envelope of a message of msgType with payload and
+// returns a reference to it. The server always sets v and the payload;
+// clients may leave out both.
+func (b *schemaBuilder) message(name, msgType, doc string, payload interface{}, fromServer bool) map[string]interface{} {
+	properties := map[string]interface{}{
+		"v":         map[string]interface{}{"type": "integer", "minimum": 1, "maximum": ProtocolVersion},
+		"type":      map[string]interface{}{"const": msgType},
+		"requestId": map[string]interface{}{"type": "string", "maxLength": maxRequestIDLength},
+	}
+	required := []string{"type"}
+	if fromServer {
+		required = []string{"v", "type"}
+	}
+	if payload != noPayload {
+		properties["payload"] = b.schemaOf(reflect.TypeOf(payload))
+		if fromServer {
+			required = append(required, "payload")
+		}
+	}
+	b.defs[name] = map[string]interface{}{
+		"description": doc,
+		"type":        "object",
+		"properties":  properties,
+		"required":    required,
+	}
+	return ref(name)
+}
+
+// schemaOf describes the JSON encoding/json gives values of t.
+func (b *schemaBuilder) schemaOf(t reflect.Type) map[string]interface{} {
+	switch {
+	case t.Implements(schemaTypeType):
+		return b.define(t, func() map[string]interface{} {
+			return reflect.Zero(t).Interface().(schemaType).jsonSchema()
+		})
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case t.Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{"anyOf": []interface{}{b.schemaOf(t.Elem()), map[string]interface{}{"type": "null"}}}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		return b.define(t, func() map[string]interface{} {
+			properties := map[string]interface{}{}
+			required := []string{}
+			b.fields(t, properties, &required)
+			return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+		})
+	}
+	panic(fmt.Sprintf("no JSON Schema for %s", t))
+}
+
+// define adds the schema build returns for the named type t to the
+// definitions, once, and returns a reference to it.
+func (b *schemaBuilder) define(t reflect.Type, build func() map[string]interface{}) map[string]interface{} {
+	name := t.Name()
+	if b.names == nil {
+		b.names = map[string]reflect.Type{}
+	}
+	if other, ok := b.names[name]; ok {
+		if other != t {
+			panic(fmt.Sprintf("JSON Schema definition %s names both %s and %s", name, other, t))
+		}
+		return ref(name)
+	}
+	b.names[name] = t
+	b.defs[name] = build()
+	return ref(name)
+}
+
+// fields adds the JSON fields of the struct type t to properties, flattening
+// embedded structs like encoding/json.
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.fields(f.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schemaOf(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	for name, tt := range map[string]struct {
+		data string
+		code ErrorCode
+	}{
+		"Unversioned":  {data: `{"type":"join-room","payload":{"eventId":"x"}}`},
+		"Versioned":    {data: `{"v":1,"type":"leave-room","requestId":"r1"}`},
+		"NotJSON":      {data: `join-room`, code: CodeInvalidMessage},
+		"NotAnObject":  {data: `["join-room"]`, code: CodeInvalidMessage},
+		"MissingType":  {data: `{"payload":{}}`, code: CodeInvalidMessage},
+		"NegativeV":    {data: `{"v":-1,"type":"leave-room"}`, code: CodeInvalidMessage},
+		"FutureV":      {data: `{"v":2,"type":"leave-room"}`, code: CodeUnsupportedVersion},
+		"LongRequest":  {data: `{"type":"leave-room","requestId":"` + strings.Repeat("r", maxRequestIDLength+1) + `"}`, code: CodeInvalidMessage},
+		"PayloadIsRaw": {data: `{"type":"offer","payload":{"target":"bob","sdp":{"type":"offer"}}}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeMessage([]byte(tt.data))
+
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var sigErr *SignalError
+			require.ErrorAs(t, err, &sigErr)
+			assert.Equal(t, tt.code, sigErr.Code)
+		})
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	for name, tt := range map[string]struct {
+		m       Message
+		want    interface{}
+		code    ErrorCode
+		message string
+	}{
+		"Typed":         {m: Message{Type: "join-room", Payload: json.RawMessage(`{"eventId":"e1"}`)}, want: &JoinPayload{EventID: "e1"}},
+		"Missing":       {m: Message{Type: "get-online-users"}, want: &RoomPayload{}},
+		"None":          {m: Message{Type: "raise-hand", Payload: json.RawMessage(`"ignored"`)}, want: nil},
+		"WrongShape":    {m: Message{Type: "start-timer", Payload: json.RawMessage(`{"seconds":"90"}`)}, code: CodeInvalidPayload, message: "invalid payload"},
+		"ServerOnly":    {m: Message{Type: "room-state"}, code: CodeUnknownType, message: "unknown type"},
+		"Unknown":       {m: Message{Type: "dance"}, code: CodeUnknownType, message: "unknown type"},
+		"MissingTarget": {m: Message{Type: "answer", Payload: json.RawMessage(`{"sdp":"v=0"}`)}, code: CodeInvalidPayload, message: "missing target"},
+		"InvalidTarget": {m: Message{Type: "answer", Payload: json.RawMessage(`{"target":1}`)}, code: CodeInvalidPayload, message: "invalid target"},
+		"NullSignal":    {m: Message{Type: "offer", Payload: json.RawMessage(`null`)}, code: CodeInvalidPayload, message: "invalid payload"},
+		"Signal": {
+			m:    Message{Type: "ice-candidate", Payload: json.RawMessage(`{"target":"bob","candidate":{"sdpMLineIndex":0}}`)},
+			want: &SignalPayload{Target: "bob", Fields: map[string]json.RawMessage{"candidate": json.RawMessage(`{"sdpMLineIndex":0}`)}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := decodePayload(tt.m)
+
+			if tt.code == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+			assert.Equal(t, signalError(tt.code, tt.message), err)
+		})
+	}
+}
+
+func TestHandleWebRTCMessage_RequestID(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventID := uuid.New()
+	s.events[eventID] = &domain.Event{ID: eventID, Status: domain.EventStatusScheduled}
+	alice := newTestClient("alice")
+	a.webrtcHub.AddClient(alice)
+	attend(s, eventID, alice)
+	send := func(requestID, msgType string, payload string) []Message {
+		a.handleWebRTCMessage(context.Background(), alice, Message{Type: msgType, RequestID: requestID, Payload: json.RawMessage(payload)})
+		return drain(t, alice)
+	}
+
+	t.Run("Ack", func(t *testing.T) {
+		msgs := send("join-1", "join-room", `{"eventId":"`+eventID.String()+`"}`)
+
+		require.Equal(t, []string{"current-users", "ack"}, messageTypes(msgs))
+		assert.Empty(t, msgs[0].RequestID, "only the answer carries the request ID")
+		assert.Equal(t, "join-1", msgs[1].RequestID)
+		assert.Equal(t, ProtocolVersion, msgs[1].V)
+		assert.JSONEq(t, `{"type":"join-room"}`, string(msgs[1].Payload))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		msgs := send("kick-1", "kick", `{"target":"bob"}`)
+
+		require.Equal(t, []string{"error"}, messageTypes(msgs))
+		assert.Equal(t, "kick-1", msgs[0].RequestID)
+		assert.JSONEq(t, `{"code":"forbidden","message":"only the emcee can send this message"}`, string(msgs[0].Payload))
+	})
+
+	t.Run("NoRequestID", func(t *testing.T) {
+		assert.Equal(t, []string{"room-state"}, messageTypes(send("", "raise-hand", "")), "no ack unless asked for")
+	})
+}
+
+// Every message clients may send is handled, if only to be refused
+func TestHandleWebRTCMessage_Catalog(t *testing.T) {
+	a, _ := newTestAPI(t)
+	c := newTestClient("alice")
+	a.webrtcHub.AddClient(c)
+
+	for msgType, spec := range messageCatalog {
+		if spec.request == nil {
+			continue
+		}
+		payload, err := decodePayload(Message{Type: msgType, Payload: json.RawMessage(`{"target":"bob"}`)})
+		require.NoError(t, err, msgType)
+
+		err = a.dispatchMessage(context.Background(), c, msgType, payload)
+
+		var sigErr *SignalError
+		if errors.As(err, &sigErr) {
+			assert.NotEqual(t, CodeUnknownType, sigErr.Code, msgType)
+		}
+		drain(t, c)
+	}
+}
+
+func TestServeWebSocket_InvalidMessage(t *testing.T) {
+	srv, s := newSocketServer(t)
+	conn := dialSocket(t, srv, s)
+
+	for _, tt := range []struct {
+		frame string
+		want  string
+	}{
+		{frame: `{"type":`, want: `{"code":"invalid_message","message":"invalid message"}`},
+		{frame: `{"v":9,"type":"leave-room","requestId":"r1"}`, want: `{"code":"unsupported_version","message":"unsupported version, the server speaks 1"}`},
+		{frame: `{"type":"leave-room","requestId":"r2"}`},
+	} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(tt.frame)))
+
+		// The connection survives malformed messages
+		m := readSocketMessage(t, conn)
+		if tt.want == "" {
+			assert.Equal(t, "ack", m.Type, tt.frame)
+			assert.Equal(t, "r2", m.RequestID)
+			continue
+		}
+		assert.Equal(t, "error", m.Type, tt.frame)
+		assert.JSONEq(t, tt.want, string(m.Payload))
+	}
+}
+
+func TestSignalingSchema(t *testing.T) {
+	got, err := json.MarshalIndent(SignalingSchema(), "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	path := filepath.Join("..", "..", "docs", "signaling.schema.json")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go test ./internal/api -run TestSignalingSchema -update after changing the protocol")
+}
+
+func FuzzDecodeMessage(f *testing.F) {
+	for _, seed := range []string{
+		`{"type":"auth","payload":{"token":"t","resume":"r"}}`,
+		`{"v":1,"type":"join-room","requestId":"1","payload":{"eventId":"6f1c1a3e-8a4b-4c2d-9e5f-0123456789ab"}}`,
+		`{"type":"offer","payload":{"target":"bob","sdp":{"type":"offer","sdp":"v=0\r\n"}}}`,
+		`{"type":"ice-candidate","payload":{"target":"bob","candidate":null}}`,
+		`{"type":"reorder-queue","payload":{"userIds":["6f1c1a3e-8a4b-4c2d-9e5f-0123456789ab"]}}`,
+		`{"type":"start-timer","payload":{"seconds":90}}`,
+		`{"type":"raise-hand","payload":null}`,
+		`{"v":2,"type":"leave-room"}`,
+		`{"type":"offer","payload":"bob"}`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m, err := decodeMessage(data)
+		if err != nil {
+			var sigErr *SignalError
+			require.ErrorAs(t, err, &sigErr)
+			assert.Contains(t, []ErrorCode{CodeInvalidMessage, CodeUnsupportedVersion}, sigErr.Code)
+			return
+		}
+		require.NotEmpty(t, m.Type)
+		require.LessOrEqual(t, m.V, ProtocolVersion)
+
+		payload, err := decodePayload(m)
+		if err != nil {
+			var sigErr *SignalError
+			require.ErrorAs(t, err, &sigErr)
+			assert.Contains(t, []ErrorCode{CodeUnknownType, CodeInvalidPayload}, sigErr.Code)
+			return
+		}
+		if payload == nil {
+			return
+		}
+		// Decoded payloads encode to payloads decoding the same
+		b, err := json.Marshal(payload)
+		require.NoError(t, err)
+		again, err := decodePayload(Message{Type: m.Type, Payload: b})
+		require.NoError(t, err, string(b))
+		b2, err := json.Marshal(again)
+		require.NoError(t, err)
+		assert.Equal(t, string(b), string(b2))
+	})
+}
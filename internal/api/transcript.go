@@ -59,7 +59,7 @@ func (a *API) TranscriptUpdateHandler(c *gin.Context) {
 	}
 
 	// Check if record exists first
-	_, err = a.transcriptRepo.GetByID(c.Request.Context(), id)
+	existing, err := a.transcriptRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Transcript not found"})
@@ -75,7 +75,11 @@ func (a *API) TranscriptUpdateHandler(c *gin.Context) {
 		return
 	}
 
+	// The policy checked ownership against the stored record, so it stays
+	// in its event with its user
 	transcript.ID = id
+	transcript.EventID, transcript.UserID = existing.EventID, existing.UserID
+	transcript.CreatedAt = existing.CreatedAt
 	if err := a.transcriptRepo.Update(c.Request.Context(), &transcript); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Transcript already exists"})
@@ -134,13 +138,19 @@ func (a *API) TranscriptGetByEventHandler(c *gin.Context) {
 		return
 	}
 
-	transcripts, err := a.transcriptRepo.GetByEventID(c.Request.Context(), eventID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.transcriptRepo.ListByEventID(c.Request.Context(), eventID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transcripts)
+	c.JSON(http.StatusOK, page)
 }
 
 func (a *API) TranscriptGetByUserHandler(c *gin.Context) {
@@ -151,11 +161,17 @@ func (a *API) TranscriptGetByUserHandler(c *gin.Context) {
 		return
 	}
 
-	transcripts, err := a.transcriptRepo.GetByUserID(c.Request.Context(), userID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.transcriptRepo.ListByUserID(c.Request.Context(), userID, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transcripts)
+	c.JSON(http.StatusOK, page)
 }
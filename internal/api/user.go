@@ -38,6 +38,9 @@ func (a *API) UserCreateHandler(c *gin.Context) {
 		return
 	}
 
+	// Maintained by the server, see PointsService and PunctualityService
+	user.LateStreak, user.Points, user.Level = 0, 0, 0
+
 	if err := a.userRepo.Create(c.Request.Context(), &user); err != nil {
 		if errors.Is(err, domain.ErrDuplicateEntry) {
 			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
@@ -58,7 +61,7 @@ func (a *API) UserUpdateHandler(c *gin.Context) {
 		return
 	}
 
-	_, err = a.userRepo.GetByID(c.Request.Context(), id)
+	existing, err := a.userRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -68,8 +71,19 @@ func (a *API) UserUpdateHandler(c *gin.Context) {
 		return
 	}
 
-	var user domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	// Users change only their profile, as through PATCH /v1/me; email,
+	// verification, role and status are for admins and staff. Fields left
+	// out of the request keep their value.
+	var req interface{ apply(*domain.User) error } = &meUpdateRequest{}
+	if actorFrom(c).IsPrivileged() {
+		req = &userAdminUpdateRequest{}
+	}
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := *existing
+	if err := req.apply(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -126,13 +140,19 @@ func (a *API) UserDeleteHandler(c *gin.Context) {
 func (a *API) UserGetByNameHandler(c *gin.Context) {
 	name := c.Param("name")
 
-	users, err := a.userRepo.GetByName(c.Request.Context(), name)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := a.userRepo.ListByName(c.Request.Context(), name, opts)
+	if err != nil {
+		respondListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, page)
 }
 
 func (a *API) UserGetByEmailHandler(c *gin.Context) {
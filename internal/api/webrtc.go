@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net"
@@ -17,20 +19,31 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// Message is the generic JSON wrapper for messages between client and server
+// Message is the generic JSON wrapper for messages between client and server.
+// V is the protocol version (see signaling.go); a RequestID set by the client
+// comes back in the ack or error answering the message.
 type Message struct {
-	Type    string          `json:"type"`
-	Payload json.RawMessage `json:"payload"`
+	V         int             `json:"v,omitempty"`
+	Type      string          `json:"type"`
+	RequestID string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
 }
 
 // Various payload structures
+// AuthPayload authenticates a socket. Resume is the resume token of a
+// previous connection to restore.
+type AuthPayload struct {
+	Token  string `json:"token"`
+	Resume string `json:"resume,omitempty"`
+}
+
 type JoinPayload struct {
-	UserName string `json:"userName"`
+	EventID string `json:"eventId"`
 }
 
-type TargetPayload struct {
-	Target string          `json:"target"`
-	Data   json.RawMessage `json:"-"`
+// RoomPayload names the room (event) a room-scoped message refers to
+type RoomPayload struct {
+	EventID string `json:"eventId"`
 }
 
 // RateLimiter struct to track connection attempts per IP
@@ -43,10 +56,59 @@ type RateLimiter struct {
 	cancel   context.CancelFunc
 }
 
-// Hub maintains set of clients
+// Hub maintains the set of clients and the per-event rooms they joined.
+// Client.RoomID is only accessed under mu; handlers read it through RoomOf.
+// Once connected to a HubBackend, rooms span every instance sharing it (see
+// webrtc_fanout.go).
 type Hub struct {
 	mu      sync.RWMutex
 	clients map[string]*domain.Client
+	rooms   map[string]map[string]*domain.Client
+	// states outlive the clients of a room so reconnects keep the queue
+	states map[string]*roomState
+
+	// node identifies this instance to the others
+	node string
+	// outbox queues envelopes for the backend; nil until Connect
+	outbox chan hubEnvelope
+	// remote holds the room members connected to other instances, by room
+	// and client ID
+	remote map[string]map[string]remoteMember
+	// seen is when each other instance was last heard from
+	seen map[string]time.Time
+}
+
+// roomState is a room's RoomState with the timer behind RoomState.Timer.
+type roomState struct {
+	domain.RoomState
+	timer *time.Timer
+	// idleSince is when a sweep first found the room without members
+	idleSince time.Time
+}
+
+// snapshot copies the state for use outside the hub's lock.
+func (s *roomState) snapshot() domain.RoomState {
+	out := domain.RoomState{Queue: append([]domain.AgendaSpeaker{}, s.Queue...)}
+	if len(s.Kicked) > 0 {
+		out.Kicked = append([]uuid.UUID{}, s.Kicked...)
+	}
+	if s.Floor != nil {
+		floor := *s.Floor
+		out.Floor = &floor
+	}
+	if s.Timer != nil {
+		timer := *s.Timer
+		out.Timer = &timer
+	}
+	return out
+}
+
+// stopTimer stops the timer, if one runs.
+func (s *roomState) stopTimer() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.Timer, s.timer = nil, nil
 }
 
 // Builds a new RateLimiter
@@ -124,7 +186,14 @@ func (rl *RateLimiter) Close() {
 }
 
 func NewHub() *Hub {
-	return &Hub{clients: make(map[string]*domain.Client)}
+	return &Hub{
+		clients: make(map[string]*domain.Client),
+		rooms:   make(map[string]map[string]*domain.Client),
+		states:  make(map[string]*roomState),
+		node:    uuid.New().String(),
+		remote:  make(map[string]map[string]remoteMember),
+		seen:    make(map[string]time.Time),
+	}
 }
 
 func (h *Hub) AddClient(c *domain.Client) {
@@ -136,9 +205,30 @@ func (h *Hub) AddClient(c *domain.Client) {
 func (h *Hub) RemoveClient(id string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if c, ok := h.clients[id]; ok {
+		h.leaveLocked(c)
+	}
 	delete(h.clients, id)
 }
 
+// ResumeClient puts c in the place of client id, keeping its room. c takes
+// over the Send channel of id along with the messages queued in it, and the
+// one its writer could not write.
+func (h *Hub) ResumeClient(id string, c *domain.Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old, ok := h.clients[id]
+	if !ok {
+		return false
+	}
+	c.ID, c.RoomID, c.Send, c.Unsent = old.ID, old.RoomID, old.Send, old.Unsent
+	h.clients[id] = c
+	if c.RoomID != "" {
+		h.rooms[c.RoomID][id] = c
+	}
+	return true
+}
+
 func (h *Hub) GetClient(id string) (*domain.Client, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -146,60 +236,473 @@ func (h *Hub) GetClient(id string) (*domain.Client, bool) {
 	return c, ok
 }
 
-func (h *Hub) ListUsers() []domain.OnlineUser {
+func (h *Hub) RoomOf(c *domain.Client) string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	out := make([]domain.OnlineUser, 0, len(h.clients))
-	for id, c := range h.clients {
-		if c.Name == "" {
-			continue
+	return c.RoomID
+}
+
+func (h *Hub) JoinRoom(clientID, roomID string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.clients[clientID]
+	if !ok {
+		return "", false
+	}
+
+	prev := c.RoomID
+	h.leaveLocked(c)
+	room, ok := h.rooms[roomID]
+	if !ok {
+		room = make(map[string]*domain.Client)
+		h.rooms[roomID] = room
+	}
+	room[clientID] = c
+	c.RoomID = roomID
+	h.publishLocked(hubEnvelope{Kind: envJoin, Member: memberOf(c)})
+	return prev, true
+}
+
+func (h *Hub) LeaveRoom(clientID string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c, ok := h.clients[clientID]
+	if !ok {
+		// Clients of other instances are removed by the instance holding them
+		return h.evictRemoteLocked(clientID)
+	}
+	if c.RoomID == "" {
+		return "", false
+	}
+
+	roomID := c.RoomID
+	h.leaveLocked(c)
+	return roomID, true
+}
+
+func (h *Hub) CloseRoom(roomID, msgType string, payload interface{}) []*domain.Client {
+	frame, err := encodeMessage(msgType, payload)
+	if err != nil {
+		log.Printf("編碼 %s 訊息失敗: %v", msgType, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := h.closeLocked(roomID, frame)
+	for id, m := range h.remote[roomID] {
+		out = append(out, m.client(id, roomID))
+	}
+	delete(h.remote, roomID)
+	h.publishLocked(hubEnvelope{Kind: envClose, RoomID: roomID, Frame: frame})
+	return out
+}
+
+// closeLocked removes the clients of this instance from roomID, sends them
+// frame and drops the room's state. The caller must hold h.mu.
+func (h *Hub) closeLocked(roomID string, frame []byte) []*domain.Client {
+	room := h.rooms[roomID]
+	out := make([]*domain.Client, 0, len(room))
+	for _, c := range room {
+		c.RoomID = ""
+		if frame != nil && !deliver(c, frame) {
+			log.Printf("傳送關閉通知失敗 (user: %s, room: %s)", c.ID, roomID)
 		}
-		out = append(out, domain.OnlineUser{UserID: id, UserName: c.Name})
+		out = append(out, c)
+	}
+	delete(h.rooms, roomID)
+	if state, ok := h.states[roomID]; ok {
+		state.stopTimer()
+		delete(h.states, roomID)
 	}
 	return out
 }
 
-func (h *Hub) BroadcastExcept(senderID string, msgType string, payload interface{}) {
-	m := map[string]interface{}{"type": msgType, "payload": payload}
-	b, _ := json.Marshal(m)
+// leaveLocked removes c from its room, dropping the room once it is empty.
+// The caller must hold h.mu.
+func (h *Hub) leaveLocked(c *domain.Client) {
+	if c.RoomID == "" {
+		return
+	}
+	if room, ok := h.rooms[c.RoomID]; ok {
+		delete(room, c.ID)
+		if len(room) == 0 {
+			delete(h.rooms, c.RoomID)
+		}
+	}
+	h.publishLocked(hubEnvelope{Kind: envLeave, RoomID: c.RoomID, ClientID: c.ID})
+	c.RoomID = ""
+}
+
+// GetRoomClient returns a client in roomID. Clients of other instances are
+// returned without a connection; use SendToClient to reach them.
+func (h *Hub) GetRoomClient(roomID, clientID string) (*domain.Client, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if c, ok := h.rooms[roomID][clientID]; ok {
+		return c, true
+	}
+	if m, ok := h.remote[roomID][clientID]; ok {
+		return m.client(clientID, roomID), true
+	}
+	return nil, false
+}
 
+func (h *Hub) GetRoomClientByUser(roomID string, userID uuid.UUID) (*domain.Client, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	for id, c := range h.clients {
-		if id == senderID {
-			continue
+	for _, c := range h.rooms[roomID] {
+		if c.UserID == userID {
+			return c, true
+		}
+	}
+	for id, m := range h.remote[roomID] {
+		if m.userID == userID {
+			return m.client(id, roomID), true
 		}
+	}
+	return nil, false
+}
+
+func (h *Hub) RoomState(roomID string) domain.RoomState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if state, ok := h.states[roomID]; ok {
+		return state.snapshot()
+	}
+	return domain.RoomState{Queue: []domain.AgendaSpeaker{}}
+}
+
+// stateLocked returns the state of roomID, creating it if needed. The
+// caller must hold h.mu.
+func (h *Hub) stateLocked(roomID string) *roomState {
+	state, ok := h.states[roomID]
+	if !ok {
+		state = &roomState{}
+		h.states[roomID] = state
+	}
+	return state
+}
+
+// sweepStates drops the states of rooms left empty for roomStateTTL, such
+// as those of practices never ended, every interval until ctx ends.
+func (h *Hub) sweepStates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
 		select {
-		case c.Send <- b:
-		default:
-			// drop
+		case now := <-ticker.C:
+			h.mu.Lock()
+			h.sweepLocked(now, roomStateTTL)
+			h.mu.Unlock()
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
-func sendToClient(c *domain.Client, msgType string, payload interface{}) error {
-	m := map[string]interface{}{"type": msgType, "payload": payload}
-	b, err := json.Marshal(m)
+// sweepLocked drops the states of rooms that had no members here or on
+// other instances since a sweep at least ttl before now. The caller must
+// hold h.mu.
+func (h *Hub) sweepLocked(now time.Time, ttl time.Duration) {
+	for roomID, state := range h.states {
+		switch {
+		case len(h.rooms[roomID]) > 0 || len(h.remote[roomID]) > 0:
+			state.idleSince = time.Time{}
+		case state.idleSince.IsZero():
+			state.idleSince = now
+		case now.Sub(state.idleSince) >= ttl:
+			state.stopTimer()
+			delete(h.states, roomID)
+		}
+	}
+}
+
+// publishStateLocked shares the state of roomID with the other instances.
+// The caller must hold h.mu.
+func (h *Hub) publishStateLocked(roomID string, snapshot domain.RoomState) {
+	h.publishLocked(hubEnvelope{Kind: envState, RoomID: roomID, State: &snapshot})
+}
+
+func (h *Hub) UpdateRoomState(roomID string, fn func(*domain.RoomState) error) (domain.RoomState, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.stateLocked(roomID)
+	next := state.snapshot()
+	if err := fn(&next); err != nil {
+		return state.snapshot(), err
+	}
+	next.Timer = state.Timer
+	state.RoomState = next
+	snapshot := state.snapshot()
+	h.publishStateLocked(roomID, snapshot)
+	return snapshot, nil
+}
+
+func (h *Hub) StartTimer(roomID string, d time.Duration) domain.RoomState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.stateLocked(roomID)
+	h.armTimerLocked(roomID, state, domain.RoomTimer{Seconds: int(d / time.Second), EndsAt: time.Now().Add(d)})
+	snapshot := state.snapshot()
+	h.publishStateLocked(roomID, snapshot)
+	return snapshot
+}
+
+// armTimerLocked replaces the timer of state with one running until
+// timer.EndsAt. The caller must hold h.mu.
+func (h *Hub) armTimerLocked(roomID string, state *roomState, timer domain.RoomTimer) {
+	state.stopTimer()
+	state.Timer = &timer
+	state.timer = time.AfterFunc(time.Until(timer.EndsAt), func() { h.expireTimer(roomID, timer.EndsAt) })
+}
+
+func (h *Hub) StopTimer(roomID string) domain.RoomState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.stateLocked(roomID)
+	state.stopTimer()
+	snapshot := state.snapshot()
+	h.publishStateLocked(roomID, snapshot)
+	return snapshot
+}
+
+// expireTimer clears the timer of roomID that was to end at endsAt and
+// tells the room. A timer replaced or stopped in the meantime is left alone.
+// Every instance runs its own copy of the timer, so only the clients of
+// this instance are told.
+func (h *Hub) expireTimer(roomID string, endsAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state, ok := h.states[roomID]
+	if !ok || state.Timer == nil || !state.Timer.EndsAt.Equal(endsAt) {
+		return
+	}
+	state.Timer, state.timer = nil, nil
+
+	expired, err := encodeMessage(msgTimerExpired, RoomPayload{EventID: roomID})
+	if err != nil {
+		log.Printf("編碼 timer-expired 訊息失敗: %v", err)
+		return
+	}
+	current, err := encodeMessage(msgRoomState, state.snapshot())
+	if err != nil {
+		log.Printf("編碼 room-state 訊息失敗: %v", err)
+		return
+	}
+	h.deliverRoomLocked(roomID, "", expired)
+	h.deliverRoomLocked(roomID, "", current)
+}
+
+func (h *Hub) ListRoomUsers(roomID string) []domain.OnlineUser {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	room := h.rooms[roomID]
+	out := make([]domain.OnlineUser, 0, len(room)+len(h.remote[roomID]))
+	for id, c := range room {
+		out = append(out, domain.OnlineUser{UserID: id, UserName: c.Name})
+	}
+	for id, m := range h.remote[roomID] {
+		out = append(out, domain.OnlineUser{UserID: id, UserName: m.name})
+	}
+	return out
+}
+
+func (h *Hub) BroadcastToRoom(roomID, senderID string, msgType string, payload interface{}) {
+	b, err := encodeMessage(msgType, payload)
+	if err != nil {
+		log.Printf("編碼 %s 訊息失敗: %v", msgType, err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	h.deliverRoomLocked(roomID, senderID, b)
+	h.publishLocked(hubEnvelope{Kind: envBroadcast, RoomID: roomID, ClientID: senderID, Frame: b})
+}
+
+// SendToClient sends a message to a client in roomID, on whichever
+// instance holds it.
+func (h *Hub) SendToClient(roomID, clientID, msgType string, payload interface{}) error {
+	b, err := encodeMessage(msgType, payload)
 	if err != nil {
 		return err
 	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if c, ok := h.rooms[roomID][clientID]; ok {
+		if !deliver(c, b) {
+			return fmt.Errorf("client send channel full")
+		}
+		return nil
+	}
+	if _, ok := h.remote[roomID][clientID]; ok {
+		h.publishLocked(hubEnvelope{Kind: envDirect, RoomID: roomID, ClientID: clientID, Frame: b})
+		return nil
+	}
+	return fmt.Errorf("client %s is not in room %s", clientID, roomID)
+}
+
+// deliverRoomLocked sends b to the clients of this instance in roomID,
+// except senderID. The caller must hold h.mu.
+func (h *Hub) deliverRoomLocked(roomID, senderID string, b []byte) {
+	for id, c := range h.rooms[roomID] {
+		if id == senderID {
+			continue
+		}
+		// A client that cannot keep up misses the message
+		deliver(c, b)
+	}
+}
+
+// deliver queues b for c and reports whether there was room for it. A
+// client that misses wsMaxDrops messages in a row is disconnected.
+func deliver(c *domain.Client, b []byte) bool {
 	select {
 	case c.Send <- b:
-		return nil
+		c.Dropped.Store(0)
+		return true
 	default:
+		wsMetrics.framesDropped.Add(1)
+		if c.Dropped.Add(1) == wsMaxDrops {
+			wsMetrics.slowConsumers.Add(1)
+			log.Printf("使用者 %s 接收過慢，中斷連線", c.ID)
+			disconnectSlow(c)
+		}
+		return false
+	}
+}
+
+// disconnectSlow closes the connection of a client that cannot keep up; its
+// read loop then cleans up as for any disconnect. Clients of other
+// instances and tests have no connection.
+func disconnectSlow(c *domain.Client) {
+	if c.Conn == nil {
+		return
+	}
+	// The hub's lock may be held, and a slow client is slow to write to
+	go func() {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow")
+		if err := c.Conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteWait)); err != nil {
+			log.Printf("傳送 close message 失敗 (user: %s): %v", c.ID, err)
+		}
+		_ = c.Conn.Close()
+	}()
+}
+
+func sendToClient(c *domain.Client, msgType string, payload interface{}) error {
+	b, err := encodeMessage(msgType, payload)
+	if err != nil {
+		return err
+	}
+	if !deliver(c, b) {
 		return fmt.Errorf("client send channel full")
 	}
+	return nil
+}
+
+const (
+	// wsAuthTimeout bounds how long a socket may stay unauthenticated
+	wsAuthTimeout = 10 * time.Second
+	// wsWriteWait bounds a write to a client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long a client may stay silent, pongs included,
+	// before it is disconnected; it is pinged every wsPingPeriod
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessageSize bounds a message read from a client; SDP offers are
+	// the largest ones
+	wsMaxMessageSize = 64 << 10
+	// wsSendBuffer is how many messages may wait for a client's writer
+	wsSendBuffer = 16
+	// wsMaxDrops is how many messages in a row a client may miss because
+	// its send buffer is full before it is disconnected as too slow
+	wsMaxDrops = 32
+	// roomStateTTL is how long the state of a room with no members on any
+	// instance is kept; empty rooms are looked for every roomStateSweep
+	roomStateTTL   = time.Hour
+	roomStateSweep = 5 * time.Minute
+)
+
+// wsMetrics counts WebSocket traffic, published at /v1/debug/vars
+var wsMetrics = struct {
+	connections   *expvar.Int
+	framesSent    *expvar.Int
+	framesDropped *expvar.Int
+	slowConsumers *expvar.Int
+	resumed       *expvar.Int
+}{new(expvar.Int), new(expvar.Int), new(expvar.Int), new(expvar.Int), new(expvar.Int)}
+
+func init() {
+	m := expvar.NewMap("websocket")
+	m.Set("connections", wsMetrics.connections)
+	m.Set("frames_sent", wsMetrics.framesSent)
+	m.Set("frames_dropped", wsMetrics.framesDropped)
+	m.Set("slow_consumers_disconnected", wsMetrics.slowConsumers)
+	m.Set("sessions_resumed", wsMetrics.resumed)
 }
 
-// Simple validation for WebRTC username (can be overridden)
-func defaultValidateUserName(name string) (bool, string) {
-	if name == "" {
-		return false, "名稱不可為空"
+// authenticateToken resolves a raw JWT to an active user
+func (api *API) authenticateToken(ctx context.Context, token string) (*domain.User, error) {
+	claims, err := api.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return api.activeUser(ctx, claims)
+}
+
+// authenticateSocket expects the first message on conn to be
+// {"type": "auth", "payload": {"token": "<JWT>", "resume": "<resume token>"}}
+// and returns the user with the resume token, if any.
+func (api *API) authenticateSocket(ctx context.Context, conn *websocket.Conn) (*domain.User, string, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(wsAuthTimeout)); err != nil {
+		return nil, "", err
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, "", domain.NewAuthError(http.StatusUnauthorized, "authentication required", err.Error())
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, "", err
+	}
+
+	msg, err := decodeMessage(data)
+	var payload interface{}
+	if err == nil && msg.Type == msgAuth {
+		payload, err = decodePayload(msg)
+	}
+	p, _ := payload.(*AuthPayload)
+	if err != nil || p == nil || p.Token == "" {
+		return nil, "", domain.NewAuthError(http.StatusUnauthorized, "authentication required", "first message must be auth")
+	}
+	user, err := api.authenticateToken(ctx, p.Token)
+	return user, p.Resume, err
+}
+
+// rejectSocket reports an authentication failure and closes the socket
+func rejectSocket(conn *websocket.Conn, err error) {
+	payload := ErrorPayload{Code: CodeInternal, Message: "internal server error"}
+	var authErr *domain.AuthError
+	if errors.As(err, &authErr) {
+		payload.Code, payload.Message = CodeUnauthorized, authErr.Message
+		if authErr.StatusCode == http.StatusForbidden {
+			payload.Code = CodeForbidden
+		}
+	}
+	b, err := encodeMessage(msgError, payload)
+	if err == nil {
+		err = conn.WriteMessage(websocket.TextMessage, b)
+	}
+	if err != nil {
+		log.Println("傳送驗證錯誤失敗:", err)
+	}
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, payload.Message)
+	if err := conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+		log.Println("傳送 close message 失敗:", err)
 	}
-	if len([]rune(name)) > 20 {
-		return false, "名稱長度不可超過 20 個字元"
+	if err := conn.Close(); err != nil {
+		log.Println("關閉連線失敗:", err)
 	}
-	return true, name
 }
 
 func (api *API) ServeWebSocket(c *gin.Context) {
@@ -217,59 +720,155 @@ func (api *API) ServeWebSocket(c *gin.Context) {
 		return
 	}
 
+	// Authenticate with the token query parameter before upgrading, or
+	// with a first "auth" message afterwards
+	ctx := c.Request.Context()
+	var user *domain.User
+	resume := c.Query("resume")
+	if token := c.Query("token"); token != "" {
+		var err error
+		if user, err = api.authenticateToken(ctx, token); err != nil {
+			api.respondAuthError(c, err)
+			return
+		}
+	}
+
 	conn, err := api.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println("websocket upgrade error:", err)
 		return
 	}
+	conn.SetReadLimit(wsMaxMessageSize)
+
+	if user == nil {
+		var authResume string
+		if user, authResume, err = api.authenticateSocket(ctx, conn); err != nil {
+			log.Printf("WebSocket 驗證失敗 (ip: %s): %v", ip, err)
+			rejectSocket(conn, err)
+			return
+		}
+		if authResume != "" {
+			resume = authResume
+		}
+	}
 
-	id := uuid.New().String()
 	client := &domain.Client{
-		ID:   id,
-		Conn: conn,
-		Send: make(chan []byte, 16),
-		Done: make(chan struct{}),
+		ID:      uuid.New().String(),
+		UserID:  user.ID,
+		Name:    user.Name,
+		Conn:    conn,
+		Send:    make(chan []byte, wsSendBuffer),
+		Done:    make(chan struct{}),
+		Stopped: make(chan struct{}),
 	}
+	// A resumed client keeps its ID and room, and its peers are not told
+	// it was away
+	resumed := resume != "" && api.resumeClient(resume, client)
+	if !resumed {
+		api.webrtcHub.AddClient(client)
+	}
+	session, err := api.sessions.issue(client)
+	if err != nil {
+		log.Printf("產生 resume token 失敗 (user: %s): %v", client.ID, err)
+		api.endSession(context.WithoutCancel(ctx), client)
+		_ = conn.Close()
+		return
+	}
+	wsMetrics.connections.Add(1)
+	log.Println("新使用者連線:", client.ID, user.ID, "resumed:", resumed)
 
-	api.webrtcHub.AddClient(client)
-	log.Println("新使用者連線:", id)
+	// The client must answer the writer's pings, or send something, within
+	// pongWait
+	extendDeadline := func() error { return conn.SetReadDeadline(time.Now().Add(api.pongWait)) }
+	if err := extendDeadline(); err != nil {
+		log.Printf("設定讀取期限失敗 (user: %s): %v", client.ID, err)
+	}
+	conn.SetPongHandler(func(string) error { return extendDeadline() })
 
-	// send connected message with assigned id
-	if err := sendToClient(client, "connected", map[string]string{"id": id}); err != nil {
-		log.Printf("傳送連線確認訊息失敗 (user: %s): %v", id, err)
+	// connected goes out ahead of the messages queued for a resumed client
+	connected := ConnectedPayload{ID: client.ID, UserID: user.ID.String(), UserName: user.Name, ResumeToken: session.token}
+	if resumed {
+		connected.Resumed, connected.EventID = true, api.webrtcHub.RoomOf(client)
+	}
+	if b, err := encodeMessage(msgConnected, connected); err == nil {
+		if err = conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err == nil {
+			err = conn.WriteMessage(websocket.TextMessage, b)
+		}
+		if err != nil {
+			log.Printf("傳送連線確認訊息失敗 (user: %s): %v", client.ID, err)
+		}
 	}
 
 	// start writer
-	go writer(client)
+	go writer(client, api.pingPeriod)
 
 	// read loop
 	for {
-		var msg Message
-		if err := client.Conn.ReadJSON(&msg); err != nil {
+		_, data, err := client.Conn.ReadMessage()
+		if err != nil {
+			log.Println("read error:", err)
+			break
+		}
+		if err := extendDeadline(); err != nil {
 			log.Println("read error:", err)
 			break
 		}
 
-		api.handleWebRTCMessage(client, msg)
-	}
-
-	// cleanup
-	api.webrtcHub.RemoveClient(client.ID)
-	if client.Name != "" {
-		api.webrtcHub.BroadcastExcept(client.ID, "user-left", client.ID)
+		// A malformed message is answered, not a reason to disconnect
+		msg, err := decodeMessage(data)
+		if err != nil {
+			answer(client, msg, err)
+			continue
+		}
+		api.handleWebRTCMessage(ctx, client, msg)
 	}
 
-	// cleanup 時
-	close(client.Done) // 先關閉 done
-	close(client.Send) // 再關閉 send
+	// cleanup 時先停止 writer 再關閉連線
+	// Waiting for the writer leaves Send, and the message it could not
+	// write, untouched for a resume
+	close(client.Done)
 	if err := client.Conn.Close(); err != nil {
 		log.Printf("關閉連線失敗 (user: %s): %v", client.ID, err)
 	}
-	log.Println("使用者離線:", client.ID)
+	<-client.Stopped
+	wsMetrics.connections.Add(-1)
+
+	// The client stays in its room for the grace window; messages to it
+	// wait in Send for a reconnect. The request context ends with the
+	// connection, but the leave must still be recorded.
+	api.sessions.suspend(session, api.resumeGrace, func() {
+		api.endSession(context.WithoutCancel(ctx), client)
+	})
+	log.Println("使用者斷線:", client.ID)
 }
 
-func writer(c *domain.Client) {
-	for {
+// writer sends c's queued messages and pings it every pingPeriod. A failed
+// write closes the connection, which ends the read loop.
+func writer(c *domain.Client, pingPeriod time.Duration) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	defer close(c.Stopped)
+
+	write := func(b []byte) error {
+		err := c.Conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err == nil {
+			err = c.Conn.WriteMessage(websocket.TextMessage, b)
+		}
+		if err != nil {
+			c.Unsent = b
+			return err
+		}
+		wsMetrics.framesSent.Add(1)
+		return nil
+	}
+
+	// A resumed client gets what its last connection could not write first
+	var err error
+	if b := c.Unsent; b != nil {
+		c.Unsent = nil
+		err = write(b)
+	}
+	for err == nil {
 		select {
 		case <-c.Done:
 			return
@@ -277,119 +876,199 @@ func writer(c *domain.Client) {
 			if !ok {
 				return
 			}
-			if err := c.Conn.WriteMessage(websocket.TextMessage, b); err != nil {
-				log.Println("write error:", err)
-				if err := c.Conn.Close(); err != nil {
-					log.Println("關閉連線失敗:", err)
-				}
+			// Done may have been closed while Send was ready too
+			select {
+			case <-c.Done:
+				c.Unsent = b
 				return
+			default:
 			}
+			err = write(b)
+		case <-ticker.C:
+			err = c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
 		}
 	}
+	log.Println("write error:", err)
+	if err := c.Conn.Close(); err != nil {
+		log.Println("關閉連線失敗:", err)
+	}
 }
 
-func (api *API) handleWebRTCMessage(c *domain.Client, m Message) {
-	switch m.Type {
-	case "get-online-users":
-		users := api.webrtcHub.ListUsers()
-		if err := sendToClient(c, "online-users-list", users); err != nil {
-			log.Printf("傳送線上使用者列表失敗 (user: %s): %v", c.ID, err)
-		}
-
-	case "join-room":
-		var p JoinPayload
-		if err := json.Unmarshal(m.Payload, &p); err != nil {
-			if err := sendToClient(c, "error", map[string]string{"message": "invalid payload"}); err != nil {
-				log.Printf("傳送錯誤訊息失敗 (user: %s): %v", c.ID, err)
-			}
-			return
-		}
-		valid, name := defaultValidateUserName(p.UserName)
-		if !valid {
-			if err := sendToClient(c, "error", map[string]string{"message": name}); err != nil {
-				log.Printf("傳送使用者名稱驗證錯誤失敗 (user: %s): %v", c.ID, err)
-			}
-			return
+// resolveRoom validates an eventId sent by a client and returns the room ID
+// for that event. Only attendees of the event may use its room.
+func (api *API) resolveRoom(ctx context.Context, c *domain.Client, eventID string) (string, error) {
+	if eventID == "" {
+		return "", signalError(CodeInvalidPayload, "missing eventId")
+	}
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return "", signalError(CodeInvalidPayload, "invalid eventId")
+	}
+	event, err := api.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", signalError(CodeEventNotFound, "event not found")
 		}
-		c.Name = name
-		// notify others
-		api.webrtcHub.BroadcastExcept(c.ID, "user-joined", map[string]string{"userId": c.ID, "userName": c.Name})
-		// send current users (excluding self)
-		current := api.webrtcHub.ListUsers()
-		filtered := make([]domain.OnlineUser, 0)
-		for _, u := range current {
-			if u.UserID != c.ID {
-				filtered = append(filtered, u)
-			}
+		log.Printf("查詢活動失敗 (event: %s): %v", id, err)
+		return "", errInternal
+	}
+	if !event.Status.IsOpen() {
+		if event.Status == domain.EventStatusCancelled {
+			return "", signalError(CodeEventClosed, "event was cancelled")
 		}
-		if err := sendToClient(c, "current-users", filtered); err != nil {
-			log.Printf("傳送當前使用者列表失敗 (user: %s): %v", c.ID, err)
+		return "", signalError(CodeEventClosed, "event has ended")
+	}
+	if _, err := api.eventAttendeeRepo.GetByEventAndUser(ctx, id, c.UserID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", signalError(CodeNotAttendee, "not an attendee of this event")
 		}
+		log.Printf("查詢活動參與者失敗 (event: %s, user: %s): %v", id, c.UserID, err)
+		return "", errInternal
+	}
+	if state := api.webrtcHub.RoomState(id.String()); state.IsKicked(c.UserID) {
+		return "", signalError(CodeForbidden, "removed from this session by the emcee")
+	}
+	return id.String(), nil
+}
 
-	case "offer", "answer", "ice-candidate":
-		// forward to target
-		var payload map[string]json.RawMessage
-		if err := json.Unmarshal(m.Payload, &payload); err != nil {
-			if err := sendToClient(c, "error", map[string]string{"message": "invalid payload"}); err != nil {
-				log.Printf("傳送錯誤訊息失敗 (user: %s): %v", c.ID, err)
-			}
-			return
-		}
-		var targetID string
-		if t, ok := payload["target"]; ok {
-			if err := json.Unmarshal(t, &targetID); err != nil {
-				if err := sendToClient(c, "error", map[string]string{"message": "invalid target"}); err != nil {
-					log.Printf("傳送錯誤訊息失敗 (user: %s): %v", c.ID, err)
-				}
-				return
-			}
-		} else {
-			if err := sendToClient(c, "error", map[string]string{"message": "missing target"}); err != nil {
-				log.Printf("傳送錯誤訊息失敗 (user: %s): %v", c.ID, err)
-			}
-			return
-		}
-		target, ok := api.webrtcHub.GetClient(targetID)
+// recordAttendance logs that c joined or left the room of an event. Failures
+// are logged only; signaling must not depend on the database being writable.
+func (api *API) recordAttendance(ctx context.Context, c *domain.Client, roomID string, action domain.AttendanceAction) {
+	eventID, err := uuid.Parse(roomID)
+	if err != nil {
+		return
+	}
+	entry := &domain.AttendanceLog{EventID: eventID, UserID: c.UserID, Action: action, At: time.Now()}
+	if err := api.attendanceRepo.Record(ctx, entry); err != nil {
+		log.Printf("記錄出席失敗 (event: %s, user: %s, action: %s): %v", eventID, c.UserID, action, err)
+	}
+}
+
+// handleWebRTCMessage runs a message from c and answers it with an error if
+// it fails, or with an ack if it succeeds and carries a request ID.
+func (api *API) handleWebRTCMessage(ctx context.Context, c *domain.Client, m Message) {
+	payload, err := decodePayload(m)
+	if err == nil {
+		err = api.dispatchMessage(ctx, c, m.Type, payload)
+	}
+	answer(c, m, err)
+}
+
+// dispatchMessage runs a message with its payload as decoded by
+// decodePayload.
+func (api *API) dispatchMessage(ctx context.Context, c *domain.Client, msgType string, payload interface{}) error {
+	switch msgType {
+	case msgGetOnlineUsers:
+		return api.handleGetOnlineUsers(ctx, c, payload.(*RoomPayload))
+
+	case msgJoinRoom:
+		return api.handleJoinRoom(ctx, c, payload.(*JoinPayload))
+
+	case msgOffer, msgAnswer, msgICECandidate:
+		return api.forwardSignal(c, msgType, payload.(*SignalPayload))
+
+	case msgRaiseHand, msgLowerHand:
+		return api.handleHandMessage(c, msgType)
+
+	case msgMuteRequest, msgKick, msgGrantFloor, msgNextSpeaker, msgReorderQueue, msgSkipSpeaker, msgStartTimer, msgStopTimer, msgEndSession:
+		p, ok := payload.(*ControlPayload)
 		if !ok {
-			if err := sendToClient(c, "error", map[string]string{"message": "target not online"}); err != nil {
-				log.Printf("傳送目標離線錯誤失敗 (user: %s, target: %s): %v", c.ID, targetID, err)
-			}
-			return
-		}
-		// Build forward payload: preserve original JSON format for WebRTC compatibility
-		// Critical for iOS - any data format changes can break WebRTC connections
-		forward := make(map[string]json.RawMessage)
-		senderJSON, _ := json.Marshal(c.ID)
-		forward["sender"] = senderJSON
-		// Copy all fields except "target", preserving original JSON format
-		for k, v := range payload {
-			if k != "target" {
-				forward[k] = v
-			}
-		}
-		// Convert to interface{} for sendToClient
-		var forwardData interface{}
-		forwardBytes, _ := json.Marshal(forward)
-		if err := json.Unmarshal(forwardBytes, &forwardData); err != nil {
-			log.Printf("unmarshal forward data 失敗: %v", err)
-			return
+			p = &ControlPayload{}
 		}
+		return api.handleControlMessage(ctx, c, msgType, p)
 
-		if err := sendToClient(target, m.Type, forwardData); err != nil {
-			log.Printf("轉發 %s 訊息失敗 (from: %s, to: %s): %v", m.Type, c.ID, target.ID, err)
-		}
+	case msgChatMessage, msgEditChatMessage, msgDeleteChatMessage:
+		return api.handleChatMessage(ctx, c, msgType, payload.(*ChatPayload))
 
-	case "leave-room":
-		if c.Name != "" {
-			name := c.Name
-			c.Name = ""
-			api.webrtcHub.BroadcastExcept(c.ID, "user-left", c.ID)
-			log.Println("使用者離開聊天室:", c.ID, name)
+	case msgLeaveRoom:
+		if roomID, ok := api.webrtcHub.LeaveRoom(c.ID); ok {
+			api.webrtcHub.BroadcastToRoom(roomID, c.ID, msgUserLeft, c.ID)
+			api.recordAttendance(ctx, c, roomID, domain.AttendanceActionLeave)
+			log.Println("使用者離開聊天室:", c.ID, roomID)
 		}
+		return nil
+
+	case msgAuth:
+		return signalError(CodeInvalidMessage, "already authenticated")
 
 	default:
-		if err := sendToClient(c, "error", map[string]string{"message": "unknown type"}); err != nil {
-			log.Printf("傳送未知訊息類型錯誤失敗 (user: %s, type: %s): %v", c.ID, m.Type, err)
+		return signalError(CodeUnknownType, "unknown type")
+	}
+}
+
+// handleGetOnlineUsers lists the client's current room, or the room named in
+// the payload for clients that have not joined yet.
+func (api *API) handleGetOnlineUsers(ctx context.Context, c *domain.Client, p *RoomPayload) error {
+	roomID := api.webrtcHub.RoomOf(c)
+	if roomID == "" {
+		var err error
+		if roomID, err = api.resolveRoom(ctx, c, p.EventID); err != nil {
+			return err
+		}
+	}
+	users := api.webrtcHub.ListRoomUsers(roomID)
+	if err := sendToClient(c, msgOnlineUsersList, users); err != nil {
+		log.Printf("傳送線上使用者列表失敗 (user: %s): %v", c.ID, err)
+	}
+	return nil
+}
+
+// handleJoinRoom moves c into the room of an event, telling the room and
+// catching c up on who is there, the room's state and its chat.
+func (api *API) handleJoinRoom(ctx context.Context, c *domain.Client, p *JoinPayload) error {
+	roomID, err := api.resolveRoom(ctx, c, p.EventID)
+	if err != nil {
+		return err
+	}
+	prev, ok := api.webrtcHub.JoinRoom(c.ID, roomID)
+	if !ok {
+		return errInternal
+	}
+	if prev != roomID {
+		if prev != "" {
+			api.webrtcHub.BroadcastToRoom(prev, c.ID, msgUserLeft, c.ID)
+			api.recordAttendance(ctx, c, prev, domain.AttendanceActionLeave)
+		}
+		api.recordAttendance(ctx, c, roomID, domain.AttendanceActionJoin)
+	}
+	// notify others
+	api.webrtcHub.BroadcastToRoom(roomID, c.ID, msgUserJoined, domain.OnlineUser{UserID: c.ID, UserName: c.Name})
+	// send current users (excluding self)
+	current := api.webrtcHub.ListRoomUsers(roomID)
+	filtered := make([]domain.OnlineUser, 0)
+	for _, u := range current {
+		if u.UserID != c.ID {
+			filtered = append(filtered, u)
 		}
 	}
+	if err := sendToClient(c, msgCurrentUsers, filtered); err != nil {
+		log.Printf("傳送當前使用者列表失敗 (user: %s): %v", c.ID, err)
+	}
+	// Late joiners and reconnecting clients catch up on the session
+	if state := api.webrtcHub.RoomState(roomID); !state.IsEmpty() {
+		if err := sendToClient(c, msgRoomState, state); err != nil {
+			log.Printf("傳送房間狀態失敗 (user: %s): %v", c.ID, err)
+		}
+	}
+	if eventID, err := uuid.Parse(roomID); err == nil {
+		api.sendChatHistory(ctx, c, eventID)
+	}
+	return nil
+}
+
+// forwardSignal passes an offer, answer or ICE candidate on to its target,
+// which may be connected to another instance.
+func (api *API) forwardSignal(c *domain.Client, msgType string, p *SignalPayload) error {
+	roomID := api.webrtcHub.RoomOf(c)
+	if roomID == "" {
+		return signalError(CodeNotInRoom, "not in a room")
+	}
+	if _, ok := api.webrtcHub.GetRoomClient(roomID, p.Target); !ok {
+		return signalError(CodeTargetNotInRoom, "target not in room")
+	}
+	forward := ForwardedSignal{Sender: c.ID, Fields: p.Fields}
+	if err := api.webrtcHub.SendToClient(roomID, p.Target, msgType, forward); err != nil {
+		log.Printf("轉發 %s 訊息失敗 (from: %s, to: %s): %v", msgType, c.ID, p.Target, err)
+	}
+	return nil
 }
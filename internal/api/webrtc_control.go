@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxTimerSeconds bounds the countdown an emcee can start
+const maxTimerSeconds = 60 * 60
+
+var (
+	errQueueOrder = signalError(CodeInvalidPayload, "userIds must list everyone in the queue")
+	errNotQueued  = signalError(CodeInvalidState, "not in the queue")
+)
+
+// ControlPayload is sent with an emcee control message. Target is the
+// client ID of someone in the emcee's room; UserID and UserIDs name users
+// in the speaking queue.
+type ControlPayload struct {
+	Target  string      `json:"target"`
+	Seconds int         `json:"seconds"`
+	UserID  uuid.UUID   `json:"userId"`
+	UserIDs []uuid.UUID `json:"userIds"`
+}
+
+// ControlBroadcast tells the room which control the emcee used. By and
+// Target are client IDs.
+type ControlBroadcast struct {
+	By       string     `json:"by"`
+	Target   string     `json:"target,omitempty"`
+	UserName string     `json:"userName,omitempty"`
+	Seconds  int        `json:"seconds,omitempty"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+}
+
+// speakerOf describes the user behind c in the speaking queue.
+func speakerOf(c *domain.Client) domain.AgendaSpeaker {
+	return domain.AgendaSpeaker{UserID: c.UserID, Name: c.Name}
+}
+
+// requireEmcee returns the event of roomID, c's room, if c is its emcee.
+func (api *API) requireEmcee(ctx context.Context, c *domain.Client, roomID string) (uuid.UUID, error) {
+	eventID, err := uuid.Parse(roomID)
+	if err != nil {
+		return uuid.Nil, signalError(CodeNotInRoom, "not in a room")
+	}
+	attendee, err := api.eventAttendeeRepo.GetByEventAndUser(ctx, eventID, c.UserID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		log.Printf("查詢活動參與者失敗 (event: %s, user: %s): %v", eventID, c.UserID, err)
+		return uuid.Nil, errInternal
+	}
+	if attendee == nil || attendee.Role != domain.EventAttendeeRoleEmcee {
+		return uuid.Nil, signalError(CodeForbidden, "only the emcee can send this message")
+	}
+	return eventID, nil
+}
+
+// loadAgenda builds the agenda of the event.
+func (api *API) loadAgenda(ctx context.Context, eventID uuid.UUID) (*domain.Agenda, error) {
+	event, err := api.eventRepo.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return api.agenda.Build(ctx, event)
+}
+
+// turnLength is how long a speaker of the agenda has the floor, or zero if
+// turns are not timed.
+func turnLength(agenda *domain.Agenda) time.Duration {
+	if agenda.Mode != domain.EventModeReport || len(agenda.Slots) == 0 {
+		return 0
+	}
+	return agenda.Slots[0].EndsAt.Sub(agenda.Slots[0].StartsAt)
+}
+
+// nextSpeaker returns who speaks after the holder of the floor: the first
+// raised hand, or else the next speaker of the agenda. Speakers who are not
+// in the room are skipped.
+func (api *API) nextSpeaker(agenda *domain.Agenda, roomID string) *domain.Client {
+	state := api.webrtcHub.RoomState(roomID)
+	for _, speaker := range state.Queue {
+		if client, ok := api.webrtcHub.GetRoomClientByUser(roomID, speaker.UserID); ok {
+			return client
+		}
+	}
+
+	speakers := agenda.Speakers
+	if state.Floor != nil {
+		for i, speaker := range speakers {
+			if speaker.UserID == state.Floor.UserID {
+				speakers = speakers[i+1:]
+				break
+			}
+		}
+	}
+	for _, speaker := range speakers {
+		if client, ok := api.webrtcHub.GetRoomClientByUser(roomID, speaker.UserID); ok {
+			return client
+		}
+	}
+	return nil
+}
+
+// giveFloor gives target the floor, restarting the turn timer for timed
+// turns, and tells the room with grant-floor and room-state.
+func (api *API) giveFloor(agenda *domain.Agenda, roomID string, out ControlBroadcast, target *domain.Client) {
+	speaker := speakerOf(target)
+	state, _ := api.webrtcHub.UpdateRoomState(roomID, func(s *domain.RoomState) error {
+		s.GiveFloor(&speaker)
+		return nil
+	})
+	if d := turnLength(agenda); d > 0 {
+		state = api.webrtcHub.StartTimer(roomID, d)
+	}
+
+	out.Target, out.UserName = target.ID, target.Name
+	api.webrtcHub.BroadcastToRoom(roomID, "", msgGrantFloor, out)
+	api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+}
+
+// handleHandMessage puts the sender in the speaking queue of its room
+// (raise-hand) or takes it out (lower-hand).
+func (api *API) handleHandMessage(c *domain.Client, msgType string) error {
+	roomID := api.webrtcHub.RoomOf(c)
+	if roomID == "" {
+		return signalError(CodeNotInRoom, "not in a room")
+	}
+	state, _ := api.webrtcHub.UpdateRoomState(roomID, func(s *domain.RoomState) error {
+		if msgType == msgRaiseHand {
+			s.RaiseHand(speakerOf(c))
+		} else {
+			s.LowerHand(c.UserID)
+		}
+		return nil
+	})
+	api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+	return nil
+}
+
+// handleControlMessage runs an emcee control message and broadcasts it to
+// the emcee's room, the emcee included:
+//   - mute-request asks the target to mute itself
+//   - kick removes every connection of the target's user from the room
+//   - grant-floor and next-speaker give a speaker the floor; next-speaker
+//     picks the first raised hand or the next one in the agenda and is
+//     broadcast as grant-floor
+//   - reorder-queue and skip-speaker rearrange the speaking queue
+//   - start-timer and stop-timer run the turn timer
+//   - end-session ends the practice like POST /v1/practices/:id/end
+//
+// Every change to the room's state is followed by room-state.
+func (api *API) handleControlMessage(ctx context.Context, c *domain.Client, msgType string, p *ControlPayload) error {
+	roomID := api.webrtcHub.RoomOf(c)
+	eventID, err := api.requireEmcee(ctx, c, roomID)
+	if err != nil {
+		return err
+	}
+
+	out := ControlBroadcast{By: c.ID}
+	switch msgType {
+	case msgMuteRequest, msgKick, msgGrantFloor:
+		if p.Target == "" {
+			return signalError(CodeInvalidPayload, "missing target")
+		}
+		target, ok := api.webrtcHub.GetRoomClient(roomID, p.Target)
+		if !ok {
+			return signalError(CodeTargetNotInRoom, "target not in room")
+		}
+		if msgType != msgGrantFloor && target.UserID == c.UserID {
+			return signalError(CodeInvalidPayload, "cannot target yourself")
+		}
+
+		switch msgType {
+		case msgGrantFloor:
+			agenda, err := api.loadAgenda(ctx, eventID)
+			if err != nil {
+				log.Printf("產生議程失敗 (event: %s): %v", eventID, err)
+				return errInternal
+			}
+			api.giveFloor(agenda, roomID, out, target)
+		case msgKick:
+			out.Target, out.UserName = target.ID, target.Name
+			// The target gets the kick before it leaves the room, and is
+			// kept out before it leaves so it cannot rejoin in between
+			api.webrtcHub.BroadcastToRoom(roomID, "", msgType, out)
+			state, _ := api.webrtcHub.UpdateRoomState(roomID, func(s *domain.RoomState) error {
+				s.Kick(target.UserID)
+				return nil
+			})
+			// The user's other connections leave along with the target
+			for {
+				conn, ok := api.webrtcHub.GetRoomClientByUser(roomID, target.UserID)
+				if !ok {
+					break
+				}
+				if _, ok := api.webrtcHub.LeaveRoom(conn.ID); !ok {
+					break
+				}
+				api.webrtcHub.BroadcastToRoom(roomID, conn.ID, msgUserLeft, conn.ID)
+				api.recordAttendance(ctx, conn, roomID, domain.AttendanceActionLeave)
+			}
+			api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+		default:
+			out.Target, out.UserName = target.ID, target.Name
+			api.webrtcHub.BroadcastToRoom(roomID, "", msgType, out)
+		}
+
+	case msgNextSpeaker:
+		agenda, err := api.loadAgenda(ctx, eventID)
+		if err != nil {
+			log.Printf("產生議程失敗 (event: %s): %v", eventID, err)
+			return errInternal
+		}
+		target := api.nextSpeaker(agenda, roomID)
+		if target == nil {
+			return signalError(CodeInvalidState, "no next speaker")
+		}
+		api.giveFloor(agenda, roomID, out, target)
+
+	case msgReorderQueue, msgSkipSpeaker:
+		state, err := api.webrtcHub.UpdateRoomState(roomID, func(s *domain.RoomState) error {
+			if msgType == msgReorderQueue {
+				if !s.Reorder(p.UserIDs) {
+					return errQueueOrder
+				}
+				return nil
+			}
+			// Without a userId the first raised hand is skipped
+			userID := p.UserID
+			if userID == uuid.Nil && len(s.Queue) > 0 {
+				userID = s.Queue[0].UserID
+			}
+			if !s.LowerHand(userID) {
+				return errNotQueued
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+
+	case msgStartTimer:
+		if p.Seconds <= 0 || p.Seconds > maxTimerSeconds {
+			return signalError(CodeInvalidPayload, fmt.Sprintf("seconds must be between 1 and %d", maxTimerSeconds))
+		}
+		state := api.webrtcHub.StartTimer(roomID, time.Duration(p.Seconds)*time.Second)
+		out.Seconds, out.EndsAt = state.Timer.Seconds, &state.Timer.EndsAt
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgType, out)
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+
+	case msgStopTimer:
+		state := api.webrtcHub.StopTimer(roomID)
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgType, out)
+		api.webrtcHub.BroadcastToRoom(roomID, "", msgRoomState, state)
+
+	case msgEndSession:
+		event, err := api.eventRepo.GetByID(ctx, eventID)
+		if err == nil {
+			err = api.endPractice(ctx, event)
+		}
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			return signalError(CodeInvalidState, "event is not live")
+		}
+		if err != nil {
+			log.Printf("結束活動失敗 (event: %s): %v", eventID, err)
+			return errInternal
+		}
+	}
+	return nil
+}
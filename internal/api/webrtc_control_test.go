@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWebRTCMessage_Controls(t *testing.T) {
+	a, s := newTestAPI(t)
+	emcee, ann, bob := newTestClient("emcee"), newTestClient("ann"), newTestClient("bob")
+	for _, c := range []*domain.Client{emcee, ann, bob} {
+		s.users[c.UserID] = &domain.User{ID: c.UserID, Name: c.Name}
+		a.webrtcHub.AddClient(c)
+	}
+
+	newPractice := func(t *testing.T) uuid.UUID {
+		t.Helper()
+		id := uuid.New()
+		s.events[id] = &domain.Event{ID: id, Mode: domain.EventModeReport, Status: domain.EventStatusLive}
+		ea := uuid.New()
+		s.eventAttendees[ea] = &domain.EventAttendee{ID: ea, EventID: id, UserID: emcee.UserID, Role: domain.EventAttendeeRoleEmcee}
+		for _, c := range []*domain.Client{emcee, ann, bob} {
+			if c != emcee {
+				attend(s, id, c)
+			}
+			sendMessage(t, a, c, "join-room", JoinPayload{EventID: id.String()})
+		}
+		for _, c := range []*domain.Client{emcee, ann, bob} {
+			drain(t, c)
+		}
+		return id
+	}
+	broadcast := func(t *testing.T, c *domain.Client, msgType string) ControlBroadcast {
+		t.Helper()
+		msgs := drain(t, c)
+		require.NotEmpty(t, msgs)
+		require.Equal(t, msgType, msgs[0].Type)
+		var out ControlBroadcast
+		require.NoError(t, json.Unmarshal(msgs[0].Payload, &out))
+		// Everyone else in the room got the same messages
+		for _, other := range []*domain.Client{emcee, ann, bob} {
+			drain(t, other)
+		}
+		return out
+	}
+	roomState := func(t *testing.T, c *domain.Client) domain.RoomState {
+		t.Helper()
+		msgs := drain(t, c)
+		require.NotEmpty(t, msgs)
+		last := msgs[len(msgs)-1]
+		require.Equal(t, "room-state", last.Type)
+		var state domain.RoomState
+		require.NoError(t, json.Unmarshal(last.Payload, &state))
+		return state
+	}
+	queued := func(state domain.RoomState) []uuid.UUID {
+		var out []uuid.UUID
+		for _, speaker := range state.Queue {
+			out = append(out, speaker.UserID)
+		}
+		return out
+	}
+	errorOf := func(t *testing.T, c *domain.Client) string {
+		t.Helper()
+		msgs := drain(t, c)
+		require.Equal(t, []string{"error"}, messageTypes(msgs))
+		var p map[string]string
+		require.NoError(t, json.Unmarshal(msgs[0].Payload, &p))
+		return p["message"]
+	}
+
+	t.Run("EmceeOnly", func(t *testing.T) {
+		newPractice(t)
+
+		for _, msgType := range []string{"mute-request", "kick", "grant-floor", "next-speaker", "reorder-queue", "skip-speaker", "start-timer", "stop-timer", "end-session"} {
+			sendMessage(t, a, ann, msgType, ControlPayload{Target: "bob", Seconds: 60})
+
+			assert.Equal(t, "only the emcee can send this message", errorOf(t, ann), msgType)
+			assert.Empty(t, drain(t, bob), msgType)
+		}
+	})
+
+	t.Run("NotInRoom", func(t *testing.T) {
+		outsider := newTestClient("outsider")
+		a.webrtcHub.AddClient(outsider)
+
+		sendMessage(t, a, outsider, "stop-timer", nil)
+
+		assert.Equal(t, "not in a room", errorOf(t, outsider))
+	})
+
+	t.Run("MuteRequest", func(t *testing.T) {
+		newPractice(t)
+
+		sendMessage(t, a, emcee, "mute-request", ControlPayload{Target: "bob"})
+
+		for _, c := range []*domain.Client{emcee, ann, bob} {
+			msgs := drain(t, c)
+			require.Equal(t, []string{"mute-request"}, messageTypes(msgs))
+			var out ControlBroadcast
+			require.NoError(t, json.Unmarshal(msgs[0].Payload, &out))
+			assert.Equal(t, ControlBroadcast{By: "emcee", Target: "bob", UserName: "Bob"}, out)
+		}
+
+		sendMessage(t, a, emcee, "mute-request", ControlPayload{Target: "emcee"})
+		assert.Equal(t, "cannot target yourself", errorOf(t, emcee))
+		sendMessage(t, a, emcee, "mute-request", ControlPayload{Target: "ghost"})
+		assert.Equal(t, "target not in room", errorOf(t, emcee))
+	})
+
+	t.Run("Kick", func(t *testing.T) {
+		id := newPractice(t)
+
+		sendMessage(t, a, emcee, "kick", ControlPayload{Target: "bob"})
+
+		assert.Equal(t, []string{"kick"}, messageTypes(drain(t, bob)))
+		assert.Empty(t, bob.RoomID)
+		assert.Equal(t, []string{"kick", "user-left", "room-state"}, messageTypes(drain(t, ann)))
+		assert.Len(t, a.webrtcHub.ListRoomUsers(id.String()), 2)
+
+		// Bob stays out until the session ends
+		sendMessage(t, a, bob, "join-room", JoinPayload{EventID: id.String()})
+		assert.Equal(t, "removed from this session by the emcee", errorOf(t, bob))
+		assert.Len(t, a.webrtcHub.ListRoomUsers(id.String()), 2)
+		assert.Empty(t, drain(t, ann))
+	})
+
+	t.Run("KickEveryConnection", func(t *testing.T) {
+		id := newPractice(t)
+		tab := newTestClient("bob-tab")
+		tab.UserID = bob.UserID
+		a.webrtcHub.AddClient(tab)
+		defer a.webrtcHub.RemoveClient(tab.ID)
+		sendMessage(t, a, tab, "join-room", JoinPayload{EventID: id.String()})
+		drain(t, tab)
+		drain(t, ann)
+
+		sendMessage(t, a, emcee, "kick", ControlPayload{Target: "bob"})
+
+		assert.Empty(t, bob.RoomID)
+		assert.Empty(t, tab.RoomID)
+		// The tab may see the other connection leave before it does
+		assert.Equal(t, "kick", messageTypes(drain(t, tab))[0])
+		assert.Equal(t, []string{"kick", "user-left", "user-left", "room-state"}, messageTypes(drain(t, ann)))
+		assert.Len(t, a.webrtcHub.ListRoomUsers(id.String()), 2)
+		leaves := filterFake(s, s.attendanceLogs, func(l *domain.AttendanceLog) bool {
+			return l.EventID == id && l.UserID == bob.UserID && l.Action == domain.AttendanceActionLeave
+		})
+		assert.Len(t, leaves, 2)
+	})
+
+	t.Run("Floor", func(t *testing.T) {
+		id := newPractice(t)
+
+		// The agenda orders ann before bob
+		sendMessage(t, a, emcee, "next-speaker", nil)
+		assert.Equal(t, "ann", broadcast(t, bob, "grant-floor").Target)
+		sendMessage(t, a, emcee, "next-speaker", nil)
+		assert.Equal(t, "bob", broadcast(t, ann, "grant-floor").Target)
+		sendMessage(t, a, emcee, "next-speaker", nil)
+		assert.Equal(t, "no next speaker", errorOf(t, emcee))
+
+		sendMessage(t, a, emcee, "grant-floor", ControlPayload{Target: "ann"})
+		assert.Equal(t, []string{"grant-floor", "room-state"}, messageTypes(drain(t, emcee)))
+		state := roomState(t, bob)
+		require.NotNil(t, state.Floor)
+		assert.Equal(t, ann.UserID, state.Floor.UserID)
+		// Report turns are timed
+		require.NotNil(t, state.Timer)
+		assert.Equal(t, 5*60, state.Timer.Seconds)
+		drain(t, ann)
+		a.webrtcHub.StopTimer(id.String())
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		id := newPractice(t)
+
+		sendMessage(t, a, bob, "raise-hand", nil)
+		sendMessage(t, a, ann, "raise-hand", nil)
+		sendMessage(t, a, ann, "raise-hand", nil)
+		assert.Equal(t, []uuid.UUID{bob.UserID, ann.UserID}, queued(roomState(t, emcee)))
+		drain(t, ann)
+		drain(t, bob)
+
+		sendMessage(t, a, emcee, "reorder-queue", ControlPayload{UserIDs: []uuid.UUID{bob.UserID}})
+		assert.Equal(t, "userIds must list everyone in the queue", errorOf(t, emcee))
+		sendMessage(t, a, emcee, "reorder-queue", ControlPayload{UserIDs: []uuid.UUID{ann.UserID, bob.UserID}})
+		assert.Equal(t, []uuid.UUID{ann.UserID, bob.UserID}, queued(roomState(t, bob)))
+		drain(t, emcee)
+		drain(t, ann)
+
+		// Raised hands speak before the agenda
+		sendMessage(t, a, emcee, "skip-speaker", nil)
+		assert.Equal(t, []uuid.UUID{bob.UserID}, queued(roomState(t, ann)))
+		sendMessage(t, a, emcee, "next-speaker", nil)
+		assert.Equal(t, "bob", broadcast(t, ann, "grant-floor").Target)
+		state := a.webrtcHub.RoomState(id.String())
+		assert.Empty(t, state.Queue)
+		require.NotNil(t, state.Floor)
+		assert.Equal(t, bob.UserID, state.Floor.UserID)
+
+		sendMessage(t, a, emcee, "skip-speaker", ControlPayload{UserID: ann.UserID})
+		assert.Equal(t, "not in the queue", errorOf(t, emcee))
+
+		sendMessage(t, a, ann, "raise-hand", nil)
+		sendMessage(t, a, ann, "lower-hand", nil)
+		assert.Empty(t, roomState(t, emcee).Queue)
+		drain(t, ann)
+		drain(t, bob)
+		a.webrtcHub.StopTimer(id.String())
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		id := newPractice(t)
+		sendMessage(t, a, ann, "raise-hand", nil)
+		for _, c := range []*domain.Client{emcee, ann, bob} {
+			sendMessage(t, a, c, "leave-room", nil)
+			drain(t, c)
+		}
+
+		// The room was empty in between, but the queue is kept
+		sendMessage(t, a, bob, "join-room", JoinPayload{EventID: id.String()})
+
+		msgs := drain(t, bob)
+		require.Equal(t, []string{"current-users", "room-state"}, messageTypes(msgs))
+		var state domain.RoomState
+		require.NoError(t, json.Unmarshal(msgs[1].Payload, &state))
+		assert.Equal(t, []uuid.UUID{ann.UserID}, queued(state))
+	})
+
+	t.Run("Timer", func(t *testing.T) {
+		id := newPractice(t)
+
+		sendMessage(t, a, emcee, "start-timer", ControlPayload{Seconds: 90})
+		out := broadcast(t, ann, "start-timer")
+		assert.Equal(t, 90, out.Seconds)
+		assert.NotNil(t, out.EndsAt)
+
+		sendMessage(t, a, emcee, "stop-timer", nil)
+		broadcast(t, ann, "stop-timer")
+		assert.Nil(t, a.webrtcHub.RoomState(id.String()).Timer)
+
+		sendMessage(t, a, emcee, "start-timer", ControlPayload{Seconds: 0})
+		assert.Equal(t, "seconds must be between 1 and 3600", errorOf(t, emcee))
+	})
+
+	t.Run("EndSession", func(t *testing.T) {
+		id := newPractice(t)
+
+		sendMessage(t, a, emcee, "end-session", nil)
+
+		assert.Equal(t, domain.EventStatusEnded, s.events[id].Status)
+		for _, c := range []*domain.Client{emcee, ann, bob} {
+			assert.Equal(t, []string{"session-ended"}, messageTypes(drain(t, c)))
+			assert.Empty(t, c.RoomID)
+		}
+
+		s.events[id].Status = domain.EventStatusScheduled
+		sendMessage(t, a, emcee, "join-room", JoinPayload{EventID: id.String()})
+		drain(t, emcee)
+		sendMessage(t, a, emcee, "end-session", nil)
+		assert.Equal(t, "event is not live", errorOf(t, emcee))
+		state := a.webrtcHub.RoomState(id.String())
+		assert.True(t, state.IsEmpty())
+	})
+}
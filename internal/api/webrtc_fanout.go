@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// hubHeartbeatInterval is how often an instance announces its room
+	// members to the others
+	hubHeartbeatInterval = 10 * time.Second
+	// hubPeerTimeout is how long an instance may stay silent before its
+	// room members are dropped
+	hubPeerTimeout = 3 * hubHeartbeatInterval
+	// hubOutboxSize bounds the envelopes waiting for the backend
+	hubOutboxSize = 1024
+)
+
+// Kinds of hubEnvelope
+const (
+	envHello     = "hello"     // a new instance asks the others for their members
+	envHeartbeat = "heartbeat" // Members are every room member of Node
+	envJoin      = "join"      // Member joined a room, leaving any previous one
+	envLeave     = "leave"     // ClientID left RoomID
+	envEvict     = "evict"     // the instance holding ClientID removes it from its room
+	envBroadcast = "broadcast" // Frame goes to RoomID, except ClientID
+	envDirect    = "direct"    // Frame goes to ClientID in RoomID
+	envClose     = "close"     // RoomID is closed; its clients get Frame
+	envState     = "state"     // State is the new RoomState of RoomID
+)
+
+// hubEnvelope is what hubs exchange through the HubBackend. Frame is a
+// message as sent to clients.
+type hubEnvelope struct {
+	Kind     string            `json:"kind"`
+	Node     string            `json:"node"`
+	RoomID   string            `json:"roomId,omitempty"`
+	ClientID string            `json:"clientId,omitempty"`
+	Frame    json.RawMessage   `json:"frame,omitempty"`
+	Member   *hubMember        `json:"member,omitempty"`
+	Members  []hubMember       `json:"members,omitempty"`
+	State    *domain.RoomState `json:"state,omitempty"`
+}
+
+// hubMember is a client in a room, as announced to other instances.
+type hubMember struct {
+	RoomID   string    `json:"roomId"`
+	ClientID string    `json:"clientId"`
+	UserID   uuid.UUID `json:"userId"`
+	Name     string    `json:"name"`
+}
+
+func memberOf(c *domain.Client) *hubMember {
+	return &hubMember{RoomID: c.RoomID, ClientID: c.ID, UserID: c.UserID, Name: c.Name}
+}
+
+// remoteMember is a room member connected to another instance.
+type remoteMember struct {
+	node   string
+	userID uuid.UUID
+	name   string
+}
+
+// client describes m as a Client without a connection.
+func (m remoteMember) client(id, roomID string) *domain.Client {
+	return &domain.Client{ID: id, UserID: m.userID, Name: m.name, RoomID: roomID}
+}
+
+// Connect shares the hub's rooms with the other instances using backend
+// until ctx ends. Messages to rooms and clients reach every instance,
+// presence lists include the clients of all of them, and room states are
+// copied to each.
+func (h *Hub) Connect(ctx context.Context, backend domain.HubBackend) {
+	outbox := make(chan hubEnvelope, hubOutboxSize)
+	go func() {
+		for {
+			select {
+			case env := <-outbox:
+				b, err := json.Marshal(env)
+				if err == nil {
+					err = backend.Publish(ctx, b)
+				}
+				if err != nil && ctx.Err() == nil {
+					log.Printf("發送 hub %s 訊息失敗: %v", env.Kind, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		if err := backend.Subscribe(ctx, h.receive); err != nil && ctx.Err() == nil {
+			log.Printf("hub 訂閱失敗: %v", err)
+		}
+	}()
+	go h.heartbeat(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.outbox = outbox
+	h.publishLocked(hubEnvelope{Kind: envHello})
+}
+
+// publishLocked queues env for the other instances. Envelopes are dropped
+// when the backend cannot keep up; heartbeats repair presence afterwards.
+// The caller must hold h.mu.
+func (h *Hub) publishLocked(env hubEnvelope) {
+	if h.outbox == nil {
+		return
+	}
+	env.Node = h.node
+	select {
+	case h.outbox <- env:
+	default:
+		log.Printf("hub 訊息佇列已滿，丟棄 %s 訊息", env.Kind)
+	}
+}
+
+// heartbeat announces this instance's members and drops silent instances
+// until ctx ends.
+func (h *Hub) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(hubHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.mu.Lock()
+			h.pruneLocked(time.Now())
+			h.publishLocked(h.heartbeatLocked())
+			h.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// heartbeatLocked lists the room members of this instance. The caller must
+// hold h.mu.
+func (h *Hub) heartbeatLocked() hubEnvelope {
+	env := hubEnvelope{Kind: envHeartbeat, Members: []hubMember{}}
+	for _, room := range h.rooms {
+		for _, c := range room {
+			env.Members = append(env.Members, *memberOf(c))
+		}
+	}
+	return env
+}
+
+// pruneLocked drops the members of instances not heard from within
+// hubPeerTimeout before now. The caller must hold h.mu.
+func (h *Hub) pruneLocked(now time.Time) {
+	for node, at := range h.seen {
+		if now.Sub(at) > hubPeerTimeout {
+			h.dropNodeLocked(node)
+			delete(h.seen, node)
+		}
+	}
+}
+
+// dropNodeLocked forgets every member of node. The caller must hold h.mu.
+func (h *Hub) dropNodeLocked(node string) {
+	for roomID, room := range h.remote {
+		for id, m := range room {
+			if m.node == node {
+				delete(room, id)
+			}
+		}
+		if len(room) == 0 {
+			delete(h.remote, roomID)
+		}
+	}
+}
+
+// addRemoteLocked records a member of node. The caller must hold h.mu.
+func (h *Hub) addRemoteLocked(node string, m hubMember) {
+	room, ok := h.remote[m.RoomID]
+	if !ok {
+		room = make(map[string]remoteMember)
+		h.remote[m.RoomID] = room
+	}
+	room[m.ClientID] = remoteMember{node: node, userID: m.UserID, name: m.Name}
+}
+
+// removeRemoteLocked forgets a member of another instance and returns the
+// room it was in. The caller must hold h.mu.
+func (h *Hub) removeRemoteLocked(clientID string) (string, bool) {
+	for roomID, room := range h.remote {
+		if _, ok := room[clientID]; ok {
+			delete(room, clientID)
+			if len(room) == 0 {
+				delete(h.remote, roomID)
+			}
+			return roomID, true
+		}
+	}
+	return "", false
+}
+
+// evictRemoteLocked asks the instance holding clientID to remove it from
+// its room. The caller must hold h.mu.
+func (h *Hub) evictRemoteLocked(clientID string) (string, bool) {
+	roomID, ok := h.removeRemoteLocked(clientID)
+	if ok {
+		h.publishLocked(hubEnvelope{Kind: envEvict, ClientID: clientID})
+	}
+	return roomID, ok
+}
+
+// applyStateLocked takes over a state published by another instance and
+// runs its own copy of the timer. The caller must hold h.mu.
+func (h *Hub) applyStateLocked(roomID string, next domain.RoomState) {
+	state := h.stateLocked(roomID)
+	state.stopTimer()
+	timer := next.Timer
+	next.Timer = nil
+	state.RoomState = next
+	if timer != nil {
+		h.armTimerLocked(roomID, state, *timer)
+	}
+}
+
+// receive applies an envelope published by any instance.
+func (h *Hub) receive(b []byte) {
+	var env hubEnvelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		log.Printf("解析 hub 訊息失敗: %v", err)
+		return
+	}
+	if env.Node == h.node {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seen[env.Node] = time.Now()
+	switch env.Kind {
+	case envHello:
+		h.publishLocked(h.heartbeatLocked())
+	case envHeartbeat:
+		h.dropNodeLocked(env.Node)
+		for _, m := range env.Members {
+			h.addRemoteLocked(env.Node, m)
+		}
+	case envJoin:
+		if env.Member != nil {
+			h.removeRemoteLocked(env.Member.ClientID)
+			h.addRemoteLocked(env.Node, *env.Member)
+		}
+	case envLeave:
+		h.removeRemoteLocked(env.ClientID)
+	case envEvict:
+		if c, ok := h.clients[env.ClientID]; ok {
+			h.leaveLocked(c)
+		}
+	case envBroadcast:
+		h.deliverRoomLocked(env.RoomID, env.ClientID, env.Frame)
+	case envDirect:
+		if c, ok := h.rooms[env.RoomID][env.ClientID]; ok {
+			deliver(c, env.Frame)
+		}
+	case envClose:
+		h.closeLocked(env.RoomID, env.Frame)
+		delete(h.remote, env.RoomID)
+	case envState:
+		if env.State != nil {
+			h.applyStateLocked(env.RoomID, *env.State)
+		}
+	}
+}
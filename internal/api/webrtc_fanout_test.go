@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/database"
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fanoutWait = 5 * time.Second
+	fanoutTick = 10 * time.Millisecond
+)
+
+// memoryBus links hubs in one process like instances sharing a database.
+type memoryBus struct {
+	mu   sync.Mutex
+	subs []chan []byte
+}
+
+func (b *memoryBus) Publish(ctx context.Context, msg []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		sub <- msg
+	}
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, fn func(msg []byte)) error {
+	sub := make(chan []byte, hubOutboxSize)
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+	for {
+		select {
+		case msg := <-sub:
+			fn(msg)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// receive waits for the next message to c and checks its type.
+func receive(t *testing.T, c *domain.Client, msgType string) Message {
+	t.Helper()
+	select {
+	case b := <-c.Send:
+		var m Message
+		require.NoError(t, json.Unmarshal(b, &m))
+		require.Equal(t, msgType, m.Type)
+		return m
+	case <-time.After(fanoutWait):
+		t.Fatalf("%s never received %s", c.ID, msgType)
+		return Message{}
+	}
+}
+
+// waitLinked announces each hub until the other has heard from it, as a
+// subscription may start after the hello of the other hub.
+func waitLinked(t *testing.T, hubs ...*Hub) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		linked := true
+		for _, h := range hubs {
+			h.mu.Lock()
+			h.publishLocked(h.heartbeatLocked())
+			for _, other := range hubs {
+				if _, ok := h.seen[other.node]; other != h && !ok {
+					linked = false
+				}
+			}
+			h.mu.Unlock()
+		}
+		return linked
+	}, fanoutWait, 100*time.Millisecond)
+}
+
+// testHubFanout runs two hubs connected through backends returned by
+// newBackend, with alice on the first and bob on the second.
+func testHubFanout(t *testing.T, newBackend func() domain.HubBackend) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := NewHub(), NewHub()
+	a.Connect(ctx, newBackend())
+	b.Connect(ctx, newBackend())
+	waitLinked(t, a, b)
+
+	alice, bob := newTestClient("alice"), newTestClient("bob")
+	a.AddClient(alice)
+	b.AddClient(bob)
+	members := func(h *Hub, roomID string, n int) func() bool {
+		return func() bool { return len(h.ListRoomUsers(roomID)) == n }
+	}
+
+	a.JoinRoom(alice.ID, "room")
+	b.JoinRoom(bob.ID, "room")
+	require.Eventually(t, members(a, "room", 2), fanoutWait, fanoutTick)
+	require.Eventually(t, members(b, "room", 2), fanoutWait, fanoutTick)
+	remote, ok := a.GetRoomClient("room", bob.ID)
+	require.True(t, ok)
+	assert.Equal(t, bob.UserID, remote.UserID)
+	assert.Nil(t, remote.Send, "bob is connected to the other hub")
+	_, ok = a.GetRoomClientByUser("room", bob.UserID)
+	assert.True(t, ok)
+
+	a.BroadcastToRoom("room", alice.ID, "ping", nil)
+	receive(t, bob, "ping")
+	assert.Empty(t, drain(t, alice))
+
+	// Offers reach the hub holding the target
+	require.NoError(t, a.SendToClient("room", bob.ID, "offer", map[string]string{"sdp": "v=0"}))
+	m := receive(t, bob, "offer")
+	assert.JSONEq(t, `{"sdp":"v=0"}`, string(m.Payload))
+	assert.Error(t, a.SendToClient("room", "ghost", "offer", nil))
+
+	_, _ = a.UpdateRoomState("room", func(s *domain.RoomState) error {
+		s.RaiseHand(speakerOf(bob))
+		return nil
+	})
+	a.StartTimer("room", time.Minute)
+	require.Eventually(t, func() bool {
+		state := b.RoomState("room")
+		return len(state.Queue) == 1 && state.Timer != nil
+	}, fanoutWait, fanoutTick)
+	a.StopTimer("room")
+	require.Eventually(t, func() bool { return b.RoomState("room").Timer == nil }, fanoutWait, fanoutTick)
+
+	// Kicking bob removes him on his own hub
+	_, ok = a.LeaveRoom(bob.ID)
+	require.True(t, ok)
+	assert.Len(t, a.ListRoomUsers("room"), 1)
+	require.Eventually(t, members(b, "room", 1), fanoutWait, fanoutTick)
+
+	b.JoinRoom(bob.ID, "room")
+	require.Eventually(t, members(a, "room", 2), fanoutWait, fanoutTick)
+	drain(t, bob)
+	closed := a.CloseRoom("room", "session-ended", nil)
+	var ids []string
+	for _, c := range closed {
+		ids = append(ids, c.ID)
+	}
+	assert.ElementsMatch(t, []string{alice.ID, bob.ID}, ids)
+	receive(t, alice, "session-ended")
+	receive(t, bob, "session-ended")
+	require.Eventually(t, func() bool {
+		state := b.RoomState("room")
+		return len(b.ListRoomUsers("room")) == 0 && state.IsEmpty()
+	}, fanoutWait, fanoutTick)
+
+	// Hubs that stop sending heartbeats are forgotten
+	b.JoinRoom(bob.ID, "other")
+	require.Eventually(t, members(a, "other", 1), fanoutWait, fanoutTick)
+	a.mu.Lock()
+	a.pruneLocked(time.Now().Add(2 * hubPeerTimeout))
+	a.mu.Unlock()
+	assert.Empty(t, a.ListRoomUsers("other"))
+}
+
+func TestHub_Fanout(t *testing.T) {
+	bus := &memoryBus{}
+	testHubFanout(t, func() domain.HubBackend { return bus })
+}
+
+// TestHub_FanoutPostgres runs two hubs against one database, as two
+// instances of the API would.
+func TestHub_FanoutPostgres(t *testing.T) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	channel := "test_hub_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	testHubFanout(t, func() domain.HubBackend {
+		ps, err := database.NewPubSub(context.Background(), databaseURL, channel)
+		require.NoError(t, err)
+		t.Cleanup(ps.Close)
+		return ps
+	})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKeepaliveServer serves an API that pings every 50ms and waits 100ms
+// for pongs.
+func newKeepaliveServer(t *testing.T) (*httptest.Server, *fakeStore) {
+	t.Helper()
+	a, s := newTestAPI(t)
+	a.pongWait, a.pingPeriod = 100*time.Millisecond, 50*time.Millisecond
+	return serveAPI(t, a), s
+}
+
+// dialSocket connects an authenticated user to srv and reads the connected
+// message.
+func dialSocket(t *testing.T, srv *httptest.Server, s *fakeStore) *websocket.Conn {
+	t.Helper()
+	user := &domain.User{ID: uuid.New(), Name: "Hanako", Status: domain.UserStatusActive}
+	s.users[user.ID] = user
+	token := signTestToken(t, jwt.RegisteredClaims{
+		Subject:   user.ID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	conn, _, err := websocket.DefaultDialer.Dial(socketURL(srv, "token="+token), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	require.Equal(t, "connected", readSocketMessage(t, conn).Type)
+	return conn
+}
+
+// socketPair returns both ends of an in-process websocket connection.
+func socketPair(t *testing.T) (server, client *websocket.Conn) {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		require.NoError(t, err)
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	server = <-conns
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = client.Close()
+	})
+	return server, client
+}
+
+func TestServeWebSocket_Keepalive(t *testing.T) {
+	t.Run("AnsweredPings", func(t *testing.T) {
+		srv, s := newKeepaliveServer(t)
+		conn := dialSocket(t, srv, s)
+
+		// Reading answers pings; the connection outlives several pong waits
+		pings := 0
+		conn.SetPingHandler(func(data string) error {
+			pings++
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(500*time.Millisecond)))
+		_, _, err := conn.ReadMessage()
+		require.ErrorContains(t, err, "timeout")
+		assert.GreaterOrEqual(t, pings, 3)
+	})
+
+	t.Run("MissedPongs", func(t *testing.T) {
+		srv, s := newKeepaliveServer(t)
+		conn := dialSocket(t, srv, s)
+		conn.SetPingHandler(func(string) error { return nil })
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, _, err := conn.ReadMessage()
+
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "timeout", "the server closes the connection")
+	})
+
+	t.Run("MessageTooLarge", func(t *testing.T) {
+		srv, s := newSocketServer(t)
+		conn := dialSocket(t, srv, s)
+
+		payload := `"` + strings.Repeat("a", wsMaxMessageSize) + `"`
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"offer","payload":`+payload+`}`)))
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "%v", err)
+	})
+}
+
+func TestDeliver_SlowConsumer(t *testing.T) {
+	server, client := socketPair(t)
+	c := &domain.Client{ID: "slow", Conn: server, Send: make(chan []byte, 1)}
+	dropped, slow := wsMetrics.framesDropped.Value(), wsMetrics.slowConsumers.Value()
+
+	require.True(t, deliver(c, []byte("{}")))
+	// A drop followed by a delivery does not count towards the limit
+	assert.False(t, deliver(c, []byte("{}")))
+	<-c.Send
+	require.True(t, deliver(c, []byte("{}")))
+	assert.Zero(t, c.Dropped.Load())
+
+	for i := 0; i < wsMaxDrops-1; i++ {
+		assert.False(t, deliver(c, []byte("{}")))
+	}
+	assert.Equal(t, slow, wsMetrics.slowConsumers.Value(), "still connected before the limit")
+
+	assert.False(t, deliver(c, []byte("{}")))
+	require.NoError(t, client.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, _, err := client.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), "%v", err)
+	assert.Equal(t, int64(wsMaxDrops+1), wsMetrics.framesDropped.Value()-dropped)
+	assert.Equal(t, int64(1), wsMetrics.slowConsumers.Value()-slow)
+}
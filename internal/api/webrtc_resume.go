@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"sync"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// wsResumeGrace is how long a disconnected client keeps its ID, room and
+// queued messages for a reconnect presenting its resume token
+const wsResumeGrace = 30 * time.Second
+
+// ConnectedPayload is sent once a connection is authenticated. ResumeToken
+// restores the session when presented on a reconnect within the grace
+// window; Resumed and EventID tell the client it got its session back.
+type ConnectedPayload struct {
+	ID          string `json:"id"`
+	UserID      string `json:"userId"`
+	UserName    string `json:"userName"`
+	ResumeToken string `json:"resumeToken"`
+	Resumed     bool   `json:"resumed,omitempty"`
+	EventID     string `json:"eventId,omitempty"`
+}
+
+// resumeSession is the session of a client, from its connection until the
+// grace window after it disconnected ends.
+type resumeSession struct {
+	token  string
+	client *domain.Client
+	// timer ends the session; set once the connection is gone
+	timer *time.Timer
+	// released is closed once the connection's read loop and writer are
+	// done with the client
+	released chan struct{}
+	// taken is set when another connection resumed the session
+	taken bool
+}
+
+// resumeRegistry holds the sessions of this instance by resume token.
+// Reconnects reaching another instance start a new session.
+type resumeRegistry struct {
+	mu      sync.Mutex
+	byToken map[string]*resumeSession
+}
+
+func newResumeRegistry() *resumeRegistry {
+	return &resumeRegistry{byToken: make(map[string]*resumeSession)}
+}
+
+// issue starts the session of a connected client.
+func (r *resumeRegistry) issue(c *domain.Client) (*resumeSession, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	s := &resumeSession{token: base64.RawURLEncoding.EncodeToString(b), client: c, released: make(chan struct{})}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byToken[s.token] = s
+	return s, nil
+}
+
+// suspend keeps s for grace after its connection ended and then calls
+// expire. It reports false when another connection already took s over.
+func (r *resumeRegistry) suspend(s *resumeSession, grace time.Duration, expire func()) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	close(s.released)
+	if s.taken {
+		return false
+	}
+	s.timer = time.AfterFunc(grace, func() {
+		r.mu.Lock()
+		current := r.byToken[s.token] == s
+		if current {
+			delete(r.byToken, s.token)
+		}
+		r.mu.Unlock()
+		if current {
+			expire()
+		}
+	})
+	return true
+}
+
+// take hands the session of token over to a new connection of userID.
+// live is true when the session's connection has not ended yet.
+func (r *resumeRegistry) take(token string, userID uuid.UUID) (s *resumeSession, live bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.byToken[token]
+	if !ok || s.client.UserID != userID {
+		return nil, false
+	}
+	// A timer that already fired is ending the session
+	if s.timer != nil && !s.timer.Stop() {
+		return nil, false
+	}
+	delete(r.byToken, token)
+	s.taken = true
+	return s, s.timer == nil
+}
+
+// resumeClient gives c the session of token: its client ID, room and the
+// messages queued while it was away. It returns false when the session
+// cannot be resumed and c starts a new one.
+func (api *API) resumeClient(token string, c *domain.Client) bool {
+	s, live := api.sessions.take(token, c.UserID)
+	if s == nil {
+		return false
+	}
+	old := s.client
+	if live {
+		// The client reconnected before its old connection was noticed to
+		// be gone; that connection must let go of the client first, its
+		// writer included, so no queued message is taken by it
+		_ = old.Conn.Close()
+		<-s.released
+	}
+	// A session that missed messages cannot be restored faithfully
+	if old.Dropped.Load() > 0 || !api.webrtcHub.ResumeClient(old.ID, c) {
+		api.endSession(context.Background(), old)
+		return false
+	}
+	wsMetrics.resumed.Add(1)
+	return true
+}
+
+// endSession removes a client for good: its room sees user-left and its
+// attendance records the leave.
+func (api *API) endSession(ctx context.Context, c *domain.Client) {
+	if roomID, ok := api.webrtcHub.LeaveRoom(c.ID); ok {
+		api.webrtcHub.BroadcastToRoom(roomID, c.ID, msgUserLeft, c.ID)
+		api.recordAttendance(ctx, c, roomID, domain.AttendanceActionLeave)
+	}
+	api.webrtcHub.RemoveClient(c.ID)
+	log.Println("使用者離線:", c.ID)
+}
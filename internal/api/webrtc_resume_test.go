@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeWebSocket_Resume(t *testing.T) {
+	a, s := newTestAPI(t)
+	a.resumeGrace = 200 * time.Millisecond
+	srv := serveAPI(t, a)
+
+	tokens := map[string]string{}
+	users := map[string]uuid.UUID{}
+	for _, name := range []string{"Ann", "Bob"} {
+		user := &domain.User{ID: uuid.New(), Name: name, Status: domain.UserStatusActive}
+		s.users[user.ID] = user
+		users[name] = user.ID
+		tokens[name] = signTestToken(t, jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		})
+	}
+	dial := func(t *testing.T, name, resume string) (*websocket.Conn, ConnectedPayload) {
+		t.Helper()
+		query := url.Values{"token": {tokens[name]}}
+		if resume != "" {
+			query.Set("resume", resume)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(socketURL(srv, query.Encode()), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		m := readSocketMessage(t, conn)
+		require.Equal(t, "connected", m.Type)
+		var p ConnectedPayload
+		require.NoError(t, json.Unmarshal(m.Payload, &p))
+		require.NotEmpty(t, p.ResumeToken)
+		return conn, p
+	}
+	send := func(t *testing.T, conn *websocket.Conn, msgType string, payload interface{}) {
+		t.Helper()
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": msgType, "payload": payload}))
+	}
+	// newRoom connects ann and bob to the room of a new event
+	newRoom := func(t *testing.T) (ann, bob *websocket.Conn, annSession, bobSession ConnectedPayload) {
+		t.Helper()
+		id := uuid.New()
+		s.events[id] = &domain.Event{ID: id, Status: domain.EventStatusScheduled}
+		for _, userID := range users {
+			attend(s, id, &domain.Client{UserID: userID})
+		}
+		ann, annSession = dial(t, "Ann", "")
+		bob, bobSession = dial(t, "Bob", "")
+		send(t, ann, "join-room", JoinPayload{EventID: id.String()})
+		require.Equal(t, "current-users", readSocketMessage(t, ann).Type)
+		send(t, bob, "join-room", JoinPayload{EventID: id.String()})
+		require.Equal(t, "current-users", readSocketMessage(t, bob).Type)
+		require.Equal(t, "user-joined", readSocketMessage(t, ann).Type)
+		return ann, bob, annSession, bobSession
+	}
+	// suspended waits until the server noticed the connection of session
+	// is gone
+	suspended := func(t *testing.T, session ConnectedPayload) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			a.sessions.mu.Lock()
+			defer a.sessions.mu.Unlock()
+			rs, ok := a.sessions.byToken[session.ResumeToken]
+			return ok && rs.timer != nil
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("Resumed", func(t *testing.T) {
+		ann, bob, annSession, bobSession := newRoom(t)
+		require.NoError(t, ann.Close())
+		suspended(t, annSession)
+
+		// Messages to ann wait for her to come back
+		send(t, bob, "offer", map[string]string{"target": annSession.ID, "sdp": "v=0"})
+		ann, resumed := dial(t, "Ann", annSession.ResumeToken)
+
+		assert.True(t, resumed.Resumed)
+		assert.Equal(t, annSession.ID, resumed.ID)
+		assert.NotEmpty(t, resumed.EventID)
+		assert.NotEqual(t, annSession.ResumeToken, resumed.ResumeToken, "tokens are single use")
+		assert.Equal(t, "offer", readSocketMessage(t, ann).Type)
+
+		// bob never saw ann leave: his next message is her answer
+		send(t, ann, "answer", map[string]string{"target": bobSession.ID, "sdp": "v=0"})
+		assert.Equal(t, "answer", readSocketMessage(t, bob).Type)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		ann, bob, annSession, _ := newRoom(t)
+		require.NoError(t, ann.Close())
+
+		m := readSocketMessage(t, bob)
+		assert.Equal(t, "user-left", m.Type)
+		assert.JSONEq(t, `"`+annSession.ID+`"`, string(m.Payload))
+		_, fresh := dial(t, "Ann", annSession.ResumeToken)
+		assert.False(t, fresh.Resumed)
+		assert.NotEqual(t, annSession.ID, fresh.ID)
+	})
+
+	t.Run("OtherUser", func(t *testing.T) {
+		_, _, annSession, _ := newRoom(t)
+
+		_, fresh := dial(t, "Bob", annSession.ResumeToken)
+
+		assert.False(t, fresh.Resumed)
+		_, ok := a.webrtcHub.GetClient(annSession.ID)
+		assert.True(t, ok, "ann's session is untouched")
+	})
+
+	t.Run("Takeover", func(t *testing.T) {
+		// ann reconnects before the server noticed her old connection died
+		ann, _, annSession, _ := newRoom(t)
+
+		_, resumed := dial(t, "Ann", annSession.ResumeToken)
+
+		assert.True(t, resumed.Resumed)
+		assert.Equal(t, annSession.ID, resumed.ID)
+		require.NoError(t, ann.SetReadDeadline(time.Now().Add(time.Second)))
+		_, _, err := ann.ReadMessage()
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "timeout", "the old connection is closed")
+	})
+
+	t.Run("TakeoverKeepsQueue", func(t *testing.T) {
+		ann, _, annSession, _ := newRoom(t)
+		c, ok := a.webrtcHub.GetClient(annSession.ID)
+		require.True(t, ok)
+		roomID := a.webrtcHub.RoomOf(c)
+
+		// ann does not read, so the old writer is stuck in the middle of a
+		// message when she reconnects
+		const total = 12
+		pad := strings.Repeat("x", 1<<20)
+		for n := 0; n < total; n++ {
+			require.NoError(t, a.webrtcHub.SendToClient(roomID, annSession.ID, "offer", map[string]interface{}{"n": n, "pad": pad}))
+		}
+		conn, resumed := dial(t, "Ann", annSession.ResumeToken)
+		require.True(t, resumed.Resumed)
+
+		// Every message reaches one of the connections, in order
+		seq := func(m Message) int {
+			var p struct{ N int }
+			require.NoError(t, json.Unmarshal(m.Payload, &p))
+			return p.N
+		}
+		var got []int
+		require.NoError(t, ann.SetReadDeadline(time.Now().Add(2*time.Second)))
+		for {
+			var m Message
+			if err := ann.ReadJSON(&m); err != nil {
+				break
+			}
+			got = append(got, seq(m))
+		}
+		for len(got) < total {
+			got = append(got, seq(readSocketMessage(t, conn)))
+		}
+		want := make([]int, total)
+		for n := range want {
+			want[n] = n
+		}
+		assert.Equal(t, want, got)
+	})
+}
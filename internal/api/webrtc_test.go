@@ -0,0 +1,552 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(id string) *domain.Client {
+	return &domain.Client{
+		ID:     id,
+		UserID: uuid.New(),
+		Name:   strings.ToUpper(id[:1]) + id[1:],
+		Send:   make(chan []byte, 64),
+		Done:   make(chan struct{}),
+	}
+}
+
+// attend registers c's user as a member of the event.
+func attend(s *fakeStore, eventID uuid.UUID, c *domain.Client) {
+	id := uuid.New()
+	s.eventAttendees[id] = &domain.EventAttendee{ID: id, EventID: eventID, UserID: c.UserID, Role: domain.EventAttendeeRoleMember}
+}
+
+// drain returns the messages queued for c.
+func drain(t *testing.T, c *domain.Client) []Message {
+	t.Helper()
+	var out []Message
+	for {
+		select {
+		case b := <-c.Send:
+			var m Message
+			require.NoError(t, json.Unmarshal(b, &m))
+			out = append(out, m)
+		default:
+			return out
+		}
+	}
+}
+
+func messageTypes(msgs []Message) []string {
+	types := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		types = append(types, m.Type)
+	}
+	return types
+}
+
+func sendMessage(t *testing.T, a *API, c *domain.Client, msgType string, payload interface{}) {
+	t.Helper()
+	raw, err := json.Marshal(payload)
+	require.NoError(t, err)
+	a.handleWebRTCMessage(context.Background(), c, Message{Type: msgType, Payload: raw})
+}
+
+func TestHub_RoomIsolation(t *testing.T) {
+	h := NewHub()
+	a1, a2, b1 := newTestClient("a1"), newTestClient("a2"), newTestClient("b1")
+	for _, c := range []*domain.Client{a1, a2, b1} {
+		h.AddClient(c)
+	}
+	h.JoinRoom("a1", "room-a")
+	h.JoinRoom("a2", "room-a")
+	h.JoinRoom("b1", "room-b")
+
+	h.BroadcastToRoom("room-a", "a1", "ping", nil)
+
+	assert.Len(t, a1.Send, 0)
+	assert.Len(t, a2.Send, 1)
+	assert.Len(t, b1.Send, 0)
+	assert.ElementsMatch(t, []domain.OnlineUser{{UserID: "a1", UserName: "A1"}, {UserID: "a2", UserName: "A2"}}, h.ListRoomUsers("room-a"))
+	assert.Equal(t, []domain.OnlineUser{{UserID: "b1", UserName: "B1"}}, h.ListRoomUsers("room-b"))
+
+	_, ok := h.GetRoomClient("room-a", "b1")
+	assert.False(t, ok)
+	_, ok = h.GetRoomClient("room-b", "b1")
+	assert.True(t, ok)
+}
+
+func TestHub_JoinLeaveRoom(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		h := NewHub()
+		c := newTestClient("c")
+		h.AddClient(c)
+
+		prev, ok := h.JoinRoom("c", "room-a")
+		assert.True(t, ok)
+		assert.Empty(t, prev)
+
+		prev, ok = h.JoinRoom("c", "room-b")
+		assert.True(t, ok)
+		assert.Equal(t, "room-a", prev)
+		assert.Empty(t, h.ListRoomUsers("room-a"))
+		assert.NotContains(t, h.rooms, "room-a")
+
+		roomID, ok := h.LeaveRoom("c")
+		assert.True(t, ok)
+		assert.Equal(t, "room-b", roomID)
+		assert.Empty(t, h.rooms)
+		assert.Empty(t, c.RoomID)
+
+		_, ok = h.LeaveRoom("c")
+		assert.False(t, ok)
+	})
+
+	t.Run("NotConnected", func(t *testing.T) {
+		h := NewHub()
+
+		_, ok := h.JoinRoom("ghost", "room-a")
+
+		assert.False(t, ok)
+		assert.Empty(t, h.rooms)
+	})
+
+	t.Run("RemoveClientLeavesRoom", func(t *testing.T) {
+		h := NewHub()
+		h.AddClient(newTestClient("c"))
+		h.JoinRoom("c", "room-a")
+
+		h.RemoveClient("c")
+
+		assert.Empty(t, h.rooms)
+		_, ok := h.GetClient("c")
+		assert.False(t, ok)
+	})
+}
+
+func TestHub_RoomState(t *testing.T) {
+	t.Run("OutlivesClients", func(t *testing.T) {
+		h := NewHub()
+		c := newTestClient("c")
+		h.AddClient(c)
+		h.JoinRoom("c", "room-a")
+
+		_, err := h.UpdateRoomState("room-a", func(s *domain.RoomState) error {
+			s.RaiseHand(domain.AgendaSpeaker{UserID: c.UserID})
+			return nil
+		})
+		require.NoError(t, err)
+		h.RemoveClient("c")
+
+		assert.Len(t, h.RoomState("room-a").Queue, 1)
+		h.CloseRoom("room-a", "session-ended", nil)
+		state := h.RoomState("room-a")
+		assert.True(t, state.IsEmpty())
+		assert.Empty(t, h.states)
+	})
+
+	t.Run("SweptWhenIdle", func(t *testing.T) {
+		h := NewHub()
+		c := newTestClient("c")
+		h.AddClient(c)
+		h.JoinRoom("c", "room-a")
+		for _, roomID := range []string{"room-a", "room-b"} {
+			_, err := h.UpdateRoomState(roomID, func(s *domain.RoomState) error {
+				s.Kick(uuid.New())
+				return nil
+			})
+			require.NoError(t, err)
+		}
+		h.StartTimer("room-b", time.Hour)
+
+		// room-b has been empty since the first sweep; room-a has c
+		now := time.Now()
+		h.sweepLocked(now, time.Minute)
+		h.sweepLocked(now.Add(30*time.Second), time.Minute)
+		assert.Len(t, h.states, 2)
+		h.sweepLocked(now.Add(time.Minute), time.Minute)
+		assert.Len(t, h.states, 1)
+		assert.Contains(t, h.states, "room-a")
+
+		// An emptied room gets the whole ttl again
+		h.RemoveClient("c")
+		h.sweepLocked(now.Add(2*time.Minute), time.Minute)
+		assert.Len(t, h.states, 1)
+		h.sweepLocked(now.Add(3*time.Minute), time.Minute)
+		assert.Empty(t, h.states)
+	})
+
+	t.Run("FailedUpdate", func(t *testing.T) {
+		h := NewHub()
+
+		state, err := h.UpdateRoomState("room-a", func(s *domain.RoomState) error {
+			s.RaiseHand(domain.AgendaSpeaker{UserID: uuid.New()})
+			return errQueueOrder
+		})
+
+		assert.ErrorIs(t, err, errQueueOrder)
+		assert.Empty(t, state.Queue)
+		state = h.RoomState("room-a")
+		assert.True(t, state.IsEmpty())
+	})
+
+	t.Run("TimerExpires", func(t *testing.T) {
+		h := NewHub()
+		c := newTestClient("c")
+		h.AddClient(c)
+		h.JoinRoom("c", "room-a")
+
+		// A replaced timer does not fire
+		h.StartTimer("room-a", time.Millisecond)
+		state := h.StartTimer("room-a", 20*time.Millisecond)
+		require.NotNil(t, state.Timer)
+
+		var msgs []Message
+		require.Eventually(t, func() bool {
+			msgs = append(msgs, drain(t, c)...)
+			return len(msgs) >= 2
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, []string{"timer-expired", "room-state"}, messageTypes(msgs))
+		assert.Nil(t, h.RoomState("room-a").Timer)
+	})
+}
+
+func TestHub_ConcurrentAccess(t *testing.T) {
+	h := NewHub()
+	rooms := []string{"room-0", "room-1", "room-2"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("client-%d", i)
+			h.AddClient(newTestClient(id))
+			for j := 0; j < 20; j++ {
+				room := rooms[(i+j)%len(rooms)]
+				h.JoinRoom(id, room)
+				h.BroadcastToRoom(room, id, "ping", j)
+				h.ListRoomUsers(room)
+				h.GetRoomClient(room, id)
+				if j%3 == 0 {
+					h.LeaveRoom(id)
+				}
+			}
+			h.RemoveClient(id)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Empty(t, h.clients)
+	assert.Empty(t, h.rooms)
+}
+
+// Rooms closing under a client must not race with the messages it sends
+func TestHandleWebRTCMessage_RoomClosedWhileSending(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventID := uuid.New()
+	s.events[eventID] = &domain.Event{ID: eventID, Status: domain.EventStatusLive}
+	alice, bob := newTestClient("alice"), newTestClient("bob")
+	for _, c := range []*domain.Client{alice, bob} {
+		a.webrtcHub.AddClient(c)
+		attend(s, eventID, c)
+	}
+	roomID := eventID.String()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			a.webrtcHub.JoinRoom(alice.ID, roomID)
+			a.webrtcHub.JoinRoom(bob.ID, roomID)
+			a.webrtcHub.CloseRoom(roomID, msgSessionEnded, RoomPayload{EventID: roomID})
+		}
+	}()
+	ctx := context.Background()
+	for sending := true; sending; {
+		select {
+		case <-done:
+			sending = false
+		default:
+		}
+		for _, m := range []Message{
+			{Type: msgRaiseHand},
+			{Type: msgGetOnlineUsers, Payload: json.RawMessage(`{}`)},
+			{Type: msgOffer, Payload: json.RawMessage(`{"target":"bob"}`)},
+			{Type: msgStopTimer},
+			{Type: msgChatMessage, Payload: json.RawMessage(`{"content":"hi"}`)},
+		} {
+			a.handleWebRTCMessage(ctx, alice, m)
+		}
+		drain(t, alice)
+		drain(t, bob)
+	}
+}
+
+func TestHandleWebRTCMessage_JoinRoom(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventA, eventB := uuid.New(), uuid.New()
+	s.events[eventA] = &domain.Event{ID: eventA, Status: domain.EventStatusScheduled}
+	s.events[eventB] = &domain.Event{ID: eventB, Status: domain.EventStatusScheduled}
+
+	alice, bob, carol := newTestClient("alice"), newTestClient("bob"), newTestClient("carol")
+	for _, c := range []*domain.Client{alice, bob, carol} {
+		a.webrtcHub.AddClient(c)
+	}
+	attend(s, eventA, alice)
+	attend(s, eventA, bob)
+	attend(s, eventB, bob)
+	attend(s, eventB, carol)
+
+	t.Run("Success", func(t *testing.T) {
+		sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+		sendMessage(t, a, carol, "join-room", JoinPayload{EventID: eventB.String()})
+		drain(t, alice)
+		drain(t, carol)
+
+		sendMessage(t, a, bob, "join-room", JoinPayload{EventID: eventA.String()})
+
+		bobMsgs := drain(t, bob)
+		require.Equal(t, []string{"current-users"}, messageTypes(bobMsgs))
+		var current []domain.OnlineUser
+		require.NoError(t, json.Unmarshal(bobMsgs[0].Payload, &current))
+		assert.Equal(t, []domain.OnlineUser{{UserID: "alice", UserName: "Alice"}}, current)
+		assert.Equal(t, []string{"user-joined"}, messageTypes(drain(t, alice)))
+		assert.Empty(t, drain(t, carol))
+	})
+
+	t.Run("SwitchRoom", func(t *testing.T) {
+		sendMessage(t, a, bob, "join-room", JoinPayload{EventID: eventB.String()})
+
+		assert.Equal(t, []string{"user-left"}, messageTypes(drain(t, alice)))
+		assert.Equal(t, []string{"user-joined"}, messageTypes(drain(t, carol)))
+		assert.Equal(t, []string{"current-users"}, messageTypes(drain(t, bob)))
+	})
+
+	for name, tt := range map[string]struct {
+		eventID string
+		code    ErrorCode
+		message string
+	}{
+		"MissingEvent": {eventID: "", code: CodeInvalidPayload, message: "missing eventId"},
+		"InvalidEvent": {eventID: "not-a-uuid", code: CodeInvalidPayload, message: "invalid eventId"},
+		"UnknownEvent": {eventID: uuid.NewString(), code: CodeEventNotFound, message: "event not found"},
+		"NotAttendee":  {eventID: eventA.String(), code: CodeNotAttendee, message: "not an attendee of this event"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dave := newTestClient("dave")
+			a.webrtcHub.AddClient(dave)
+
+			sendMessage(t, a, dave, "join-room", JoinPayload{EventID: tt.eventID})
+
+			msgs := drain(t, dave)
+			require.Equal(t, []string{"error"}, messageTypes(msgs))
+			assert.JSONEq(t, fmt.Sprintf(`{"code":%q,"message":%q}`, tt.code, tt.message), string(msgs[0].Payload))
+			assert.Empty(t, dave.RoomID)
+		})
+	}
+}
+
+func TestHandleWebRTCMessage_OnlineUsersScopedToRoom(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventA, eventB := uuid.New(), uuid.New()
+	s.events[eventA] = &domain.Event{ID: eventA, Status: domain.EventStatusScheduled}
+	s.events[eventB] = &domain.Event{ID: eventB, Status: domain.EventStatusScheduled}
+	alice, viewer := newTestClient("alice"), newTestClient("viewer")
+	a.webrtcHub.AddClient(alice)
+	a.webrtcHub.AddClient(viewer)
+	attend(s, eventA, alice)
+	attend(s, eventA, viewer)
+	attend(s, eventB, viewer)
+	sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+
+	sendMessage(t, a, viewer, "get-online-users", RoomPayload{EventID: eventB.String()})
+	msgs := drain(t, viewer)
+	require.Equal(t, []string{"online-users-list"}, messageTypes(msgs))
+	assert.JSONEq(t, `[]`, string(msgs[0].Payload))
+
+	sendMessage(t, a, viewer, "get-online-users", RoomPayload{EventID: eventA.String()})
+	msgs = drain(t, viewer)
+	require.Equal(t, []string{"online-users-list"}, messageTypes(msgs))
+	assert.JSONEq(t, `[{"userId":"alice","userName":"Alice"}]`, string(msgs[0].Payload))
+
+	a.handleWebRTCMessage(context.Background(), viewer, Message{Type: "get-online-users"})
+	assert.Equal(t, []string{"error"}, messageTypes(drain(t, viewer)))
+
+	// Presence of events the user does not attend stays hidden
+	outsider := newTestClient("outsider")
+	a.webrtcHub.AddClient(outsider)
+	sendMessage(t, a, outsider, "get-online-users", RoomPayload{EventID: eventA.String()})
+	assert.Equal(t, []string{"error"}, messageTypes(drain(t, outsider)))
+}
+
+func TestHandleWebRTCMessage_ForwardScopedToRoom(t *testing.T) {
+	a, s := newTestAPI(t)
+	eventA, eventB := uuid.New(), uuid.New()
+	s.events[eventA] = &domain.Event{ID: eventA, Status: domain.EventStatusScheduled}
+	s.events[eventB] = &domain.Event{ID: eventB, Status: domain.EventStatusScheduled}
+	alice, bob, carol := newTestClient("alice"), newTestClient("bob"), newTestClient("carol")
+	for _, c := range []*domain.Client{alice, bob, carol} {
+		a.webrtcHub.AddClient(c)
+	}
+	attend(s, eventA, alice)
+	attend(s, eventA, bob)
+	attend(s, eventB, carol)
+	sendMessage(t, a, alice, "join-room", JoinPayload{EventID: eventA.String()})
+	sendMessage(t, a, bob, "join-room", JoinPayload{EventID: eventA.String()})
+	sendMessage(t, a, carol, "join-room", JoinPayload{EventID: eventB.String()})
+	for _, c := range []*domain.Client{alice, bob, carol} {
+		drain(t, c)
+	}
+
+	t.Run("SameRoom", func(t *testing.T) {
+		sendMessage(t, a, alice, "offer", map[string]interface{}{"target": "bob", "sdp": map[string]string{"type": "offer"}})
+
+		msgs := drain(t, bob)
+		require.Equal(t, []string{"offer"}, messageTypes(msgs))
+		assert.JSONEq(t, `{"sender":"alice","sdp":{"type":"offer"}}`, string(msgs[0].Payload))
+	})
+
+	t.Run("OtherRoom", func(t *testing.T) {
+		sendMessage(t, a, alice, "ice-candidate", map[string]interface{}{"target": "carol", "candidate": "x"})
+
+		assert.Empty(t, drain(t, carol))
+		msgs := drain(t, alice)
+		require.Equal(t, []string{"error"}, messageTypes(msgs))
+		assert.JSONEq(t, `{"code":"target_not_in_room","message":"target not in room"}`, string(msgs[0].Payload))
+	})
+
+	t.Run("NotInRoom", func(t *testing.T) {
+		dave := newTestClient("dave")
+		a.webrtcHub.AddClient(dave)
+
+		sendMessage(t, a, dave, "offer", map[string]interface{}{"target": "alice"})
+
+		assert.Empty(t, drain(t, alice))
+		assert.Equal(t, []string{"error"}, messageTypes(drain(t, dave)))
+	})
+}
+
+func newSocketServer(t *testing.T) (*httptest.Server, *fakeStore) {
+	t.Helper()
+	a, s := newTestAPI(t)
+	return serveAPI(t, a), s
+}
+
+// serveAPI serves a's routes until the test ends.
+func serveAPI(t *testing.T, a *API) *httptest.Server {
+	t.Helper()
+	r := gin.New()
+	Register(r, a)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func socketURL(srv *httptest.Server, query string) string {
+	u := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	if query != "" {
+		u += "?" + query
+	}
+	return u
+}
+
+func readSocketMessage(t *testing.T, conn *websocket.Conn) Message {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var m Message
+	require.NoError(t, conn.ReadJSON(&m))
+	return m
+}
+
+func TestServeWebSocket_Authentication(t *testing.T) {
+	userToken := func(t *testing.T, s *fakeStore, status domain.UserStatus) (string, *domain.User) {
+		user := &domain.User{ID: uuid.New(), Name: "Hanako", Email: "hanako@example.com", Role: domain.UserRoleUser, Status: status}
+		s.users[user.ID] = user
+		return signTestToken(t, jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}), user
+	}
+
+	t.Run("QueryToken", func(t *testing.T) {
+		srv, s := newSocketServer(t)
+		token, user := userToken(t, s, domain.UserStatusActive)
+
+		conn, _, err := websocket.DefaultDialer.Dial(socketURL(srv, "token="+token), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		m := readSocketMessage(t, conn)
+		assert.Equal(t, "connected", m.Type)
+		var p map[string]string
+		require.NoError(t, json.Unmarshal(m.Payload, &p))
+		assert.Equal(t, user.ID.String(), p["userId"])
+		assert.Equal(t, "Hanako", p["userName"])
+		assert.NotEmpty(t, p["id"])
+	})
+
+	t.Run("AuthMessage", func(t *testing.T) {
+		srv, s := newSocketServer(t)
+		token, _ := userToken(t, s, domain.UserStatusActive)
+
+		conn, _, err := websocket.DefaultDialer.Dial(socketURL(srv, ""), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "auth", "payload": AuthPayload{Token: token}}))
+
+		assert.Equal(t, "connected", readSocketMessage(t, conn).Type)
+	})
+
+	t.Run("InvalidQueryToken", func(t *testing.T) {
+		srv, _ := newSocketServer(t)
+
+		_, resp, err := websocket.DefaultDialer.Dial(socketURL(srv, "token=garbage"), nil)
+
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("BannedUser", func(t *testing.T) {
+		srv, s := newSocketServer(t)
+		token, _ := userToken(t, s, domain.UserStatusBanned)
+
+		_, resp, err := websocket.DefaultDialer.Dial(socketURL(srv, "token="+token), nil)
+
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("FirstMessageNotAuth", func(t *testing.T) {
+		srv, _ := newSocketServer(t)
+
+		conn, _, err := websocket.DefaultDialer.Dial(socketURL(srv, ""), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "join-room", "payload": JoinPayload{EventID: uuid.NewString()}}))
+
+		m := readSocketMessage(t, conn)
+		assert.Equal(t, "error", m.Type)
+		assert.JSONEq(t, `{"code":"unauthorized","message":"authentication required"}`, string(m.Payload))
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "%v", err)
+	})
+}
@@ -13,26 +13,21 @@ import (
 	"jpcorrect-backend/internal/api"
 	"jpcorrect-backend/internal/database"
 	"jpcorrect-backend/internal/domain"
+	"jpcorrect-backend/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Execute() {
-	db, err := database.NewGormDB(os.Getenv("DATABASE_URL"))
+	databaseURL := os.Getenv("DATABASE_URL")
+	db, err := database.NewGormDB(databaseURL)
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 
-	if err := db.AutoMigrate(
-		&domain.User{},
-		&domain.Guild{},
-		&domain.GuildAttendee{},
-		&domain.Event{},
-		&domain.EventAttendee{},
-		&domain.Transcript{},
-		&domain.Mistake{},
-	); err != nil {
-		log.Fatalf("failed to run auto migrate: %v", err)
+	// Schema changes are applied by `jpcorrect migrate up`, never at startup
+	if err := database.CheckSchema(databaseURL); err != nil {
+		log.Fatalf("database schema check failed: %v (run `jpcorrect migrate up`)", err)
 	}
 
 	transport := &http.Transport{
@@ -46,17 +41,68 @@ func Execute() {
 		log.Fatalf("JWKS_URL environment variable is required")
 	}
 
-	allowedOrigins := []string{}
-	if originsEnv := os.Getenv("ALLOWED_ORIGINS"); originsEnv != "" {
-		allowedOrigins = strings.Split(originsEnv, ",")
-		for i, origin := range allowedOrigins {
-			allowedOrigins[i] = strings.TrimSpace(origin)
+	allowedOrigins := splitList(os.Getenv("ALLOWED_ORIGINS"))
+
+	lateGrace := service.DefaultLateGrace
+	if graceEnv := os.Getenv("LATE_GRACE_PERIOD"); graceEnv != "" {
+		lateGrace, err = time.ParseDuration(graceEnv)
+		if err != nil || lateGrace < 0 {
+			log.Fatalf("invalid LATE_GRACE_PERIOD %q: expected a duration such as 5m", graceEnv)
+		}
+	}
+
+	levelCurves := service.DefaultLevelCurves
+	for env, curve := range map[string]*domain.LevelCurve{
+		"LEVEL_CURVE":       &levelCurves.User,
+		"GUILD_LEVEL_CURVE": &levelCurves.Guild,
+	} {
+		if curveEnv := os.Getenv(env); curveEnv != "" {
+			*curve, err = domain.ParseLevelCurve(curveEnv)
+			if err != nil {
+				log.Fatalf("invalid %s %q: %v", env, curveEnv, err)
+			}
 		}
 	}
 
-	a := api.NewAPI(os.Getenv("API_TOOLS_URL"), transport, db, jwksURL, allowedOrigins)
+	icalSecret := os.Getenv("ICAL_SECRET")
+	if icalSecret == "" {
+		log.Println("ICAL_SECRET is not set; calendar feeds are disabled")
+	}
+
+	ice := api.ICEConfig{
+		STUNURLs:      splitList(os.Getenv("STUN_URLS")),
+		TURNURLs:      splitList(os.Getenv("TURN_URLS")),
+		TURNSecret:    os.Getenv("TURN_SECRET"),
+		CredentialTTL: api.DefaultTURNCredentialTTL,
+	}
+	if len(ice.TURNURLs) > 0 && ice.TURNSecret == "" {
+		log.Fatalf("TURN_SECRET is required with TURN_URLS")
+	}
+	if ttlEnv := os.Getenv("TURN_CREDENTIAL_TTL"); ttlEnv != "" {
+		ice.CredentialTTL, err = time.ParseDuration(ttlEnv)
+		if err != nil || ice.CredentialTTL <= 0 {
+			log.Fatalf("invalid TURN_CREDENTIAL_TTL %q: expected a duration such as 1h", ttlEnv)
+		}
+	}
+
+	a := api.NewAPI(os.Getenv("API_TOOLS_URL"), transport, db, jwksURL, allowedOrigins, lateGrace, levelCurves, icalSecret, ice)
 	defer a.Close()
 
+	// With several instances behind a load balancer, room members may be
+	// connected to different instances
+	switch backend := os.Getenv("WEBRTC_HUB_BACKEND"); backend {
+	case "", "memory":
+	case "postgres":
+		pubsub, err := database.NewPubSub(context.Background(), databaseURL, "webrtc_hub")
+		if err != nil {
+			log.Fatalf("failed to connect WebRTC hub backend: %v", err)
+		}
+		defer pubsub.Close()
+		a.ConnectHub(pubsub)
+	default:
+		log.Fatalf("invalid WEBRTC_HUB_BACKEND %q: expected memory or postgres", backend)
+	}
+
 	initCtx, initCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer initCancel()
 	if err := a.InitializeJWKS(initCtx); err != nil {
@@ -126,3 +172,14 @@ func Execute() {
 
 	log.Println("Server exiting")
 }
+
+// splitList parses a comma-separated environment variable, dropping blanks
+func splitList(env string) []string {
+	list := []string{}
+	for _, item := range strings.Split(env, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
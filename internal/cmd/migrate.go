@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"jpcorrect-backend/internal/database"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+const migrateUsage = `usage: jpcorrect migrate <command>
+
+commands:
+  up [N]      apply all (or the next N) pending migrations
+  down [N]    roll back the last N migrations (default 1), or "all"
+  status      print the current and latest schema versions
+  force V     mark the schema as version V without running migrations
+              (use after repairing a dirty schema by hand; -1 clears it)`
+
+// Migrate runs the `jpcorrect migrate` subcommand with the given arguments.
+func Migrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	m, err := database.NewMigrator(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to create migrator: %v", err)
+	}
+	defer func() { _, _ = m.Close() }()
+	m.Log = migrateLogger{}
+
+	switch args[0] {
+	case "up":
+		n, all := stepsArg(args)
+		if all {
+			err = m.Up()
+		} else {
+			err = m.Steps(n)
+		}
+	case "down":
+		n, all := stepsArg(args)
+		if all {
+			err = m.Down()
+		} else if n == 0 {
+			err = m.Steps(-1)
+		} else {
+			err = m.Steps(-n)
+		}
+	case "force":
+		if len(args) != 2 {
+			log.Fatalf("force requires a version\n\n%s", migrateUsage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			log.Fatalf("invalid version %q", args[1])
+		}
+		err = m.Force(version)
+	case "status":
+		status, statusErr := database.Status(m)
+		if statusErr != nil {
+			log.Fatalf("failed to read schema version: %v", statusErr)
+		}
+		fmt.Printf("current version: %d\n", status.Version)
+		fmt.Printf("latest version:  %d\n", status.Latest)
+		fmt.Printf("dirty:           %t\n", status.Dirty)
+		if status.Pending() {
+			fmt.Printf("pending:         %d migration(s)\n", status.Latest-status.Version)
+		}
+		return
+	default:
+		log.Fatalf("unknown migrate command %q\n\n%s", args[0], migrateUsage)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		log.Println("no change")
+		return
+	}
+	if err != nil {
+		log.Fatalf("migrate %s failed: %v", args[0], err)
+	}
+	log.Printf("migrate %s done", args[0])
+}
+
+// stepsArg parses the optional step count of up/down. all is true when no
+// count was given (up) or "all" was passed.
+func stepsArg(args []string) (n int, all bool) {
+	if len(args) < 2 {
+		return 0, args[0] == "up"
+	}
+	if args[1] == "all" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n <= 0 {
+		log.Fatalf("invalid step count %q", args[1])
+	}
+	return n, false
+}
+
+// migrateLogger forwards migration progress to the standard logger.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+const migrationDir = "migrations"
+
+var (
+	// ErrSchemaDirty is returned when a previous migration failed halfway and
+	// the schema must be repaired by hand (then marked with `migrate force`).
+	ErrSchemaDirty = errors.New("database schema is dirty")
+	// ErrSchemaOutdated is returned when migrations are pending.
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+)
+
+// SchemaStatus describes the migration state of a database.
+type SchemaStatus struct {
+	Version uint // 0 when no migration has been applied
+	Dirty   bool
+	Latest  uint // highest embedded migration version
+}
+
+// Pending reports whether embedded migrations have not been applied yet.
+func (s SchemaStatus) Pending() bool {
+	return s.Version < s.Latest
+}
+
+// NewMigrator creates a migrator for the embedded migrations. It opens its
+// own connection, which is released by the migrator's Close method.
+func NewMigrator(databaseURL string) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrationFS, migrationDir)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := pgx.WithInstance(db, &pgx.Config{})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", src, "pgx5", driver)
+}
+
+// LatestVersion returns the highest embedded migration version.
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(migrationFS, migrationDir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		m, err := source.Parse(entry.Name())
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration file name %q: %w", entry.Name(), err)
+		}
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest, nil
+}
+
+// Status reports the migration state of the database behind m.
+func Status(m *migrate.Migrate) (SchemaStatus, error) {
+	latest, err := LatestVersion()
+	if err != nil {
+		return SchemaStatus{}, err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return SchemaStatus{}, err
+	}
+
+	return SchemaStatus{Version: version, Dirty: dirty, Latest: latest}, nil
+}
+
+// CheckSchema returns an error unless every embedded migration has been
+// applied cleanly. The server calls it at startup instead of migrating.
+func CheckSchema(databaseURL string) error {
+	m, err := NewMigrator(databaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _, _ = m.Close() }()
+
+	status, err := Status(m)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w: version %d", ErrSchemaDirty, status.Version)
+	}
+	if status.Pending() {
+		return fmt.Errorf("%w: at version %d, want %d", ErrSchemaOutdated, status.Version, status.Latest)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// models lists every GORM model whose table is owned by the migrations.
+var models = []interface{}{
+	&domain.User{},
+	&domain.Guild{},
+	&domain.GuildAttendee{},
+	&domain.Event{},
+	&domain.EventAttendee{},
+	&domain.Transcript{},
+	&domain.Mistake{},
+	&domain.AttendanceLog{},
+	&domain.PointTransaction{},
+	&domain.GuildInvite{},
+	&domain.GuildJoinRequest{},
+	&domain.EventSeries{},
+	&domain.EventMessage{},
+}
+
+func TestMigrationFiles(t *testing.T) {
+	entries, err := fs.ReadDir(migrationFS, migrationDir)
+	require.NoError(t, err)
+
+	directions := map[uint]map[source.Direction]bool{}
+	for _, entry := range entries {
+		m, err := source.Parse(entry.Name())
+		require.NoError(t, err, entry.Name())
+		assert.True(t, strings.HasSuffix(entry.Name(), ".sql"), entry.Name())
+
+		if directions[m.Version] == nil {
+			directions[m.Version] = map[source.Direction]bool{}
+		}
+		assert.False(t, directions[m.Version][m.Direction], "duplicate %s migration for version %d", m.Direction, m.Version)
+		directions[m.Version][m.Direction] = true
+
+		content, err := fs.ReadFile(migrationFS, migrationDir+"/"+entry.Name())
+		require.NoError(t, err)
+		assert.NotEmpty(t, strings.TrimSpace(string(content)), entry.Name())
+	}
+
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(directions)), latest, "migration versions must be sequential")
+	for v := uint(1); v <= latest; v++ {
+		assert.True(t, directions[v][source.Up], "missing up migration for version %d", v)
+		assert.True(t, directions[v][source.Down], "missing down migration for version %d", v)
+	}
+}
+
+func TestSchemaStatus_Pending(t *testing.T) {
+	assert.True(t, SchemaStatus{Version: 0, Latest: 2}.Pending())
+	assert.True(t, SchemaStatus{Version: 1, Latest: 2}.Pending())
+	assert.False(t, SchemaStatus{Version: 2, Latest: 2}.Pending())
+}
+
+// TestMigratedSchemaMatchesModels applies the migrations to DATABASE_URL and
+// checks that every model column and index exists, and that the tables have
+// no columns the models do not know about.
+func TestMigratedSchemaMatchesModels(t *testing.T) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	m, err := NewMigrator(databaseURL)
+	require.NoError(t, err)
+	defer func() { _, _ = m.Close() }()
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	require.NoError(t, CheckSchema(databaseURL))
+
+	db, err := NewGormDB(databaseURL)
+	require.NoError(t, err)
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		table := stmt.Schema.Table
+
+		t.Run(table, func(t *testing.T) {
+			migrator := db.Migrator()
+			require.True(t, migrator.HasTable(model), "missing table %s", table)
+
+			columnTypes, err := migrator.ColumnTypes(model)
+			require.NoError(t, err)
+			columns := map[string]bool{}
+			for _, ct := range columnTypes {
+				columns[ct.Name()] = true
+			}
+
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName == "" {
+					continue
+				}
+				assert.True(t, columns[field.DBName], "missing column %s.%s", table, field.DBName)
+				delete(columns, field.DBName)
+			}
+			for column := range columns {
+				t.Errorf("column %s.%s is not mapped by the model", table, column)
+			}
+
+			for _, idx := range stmt.Schema.ParseIndexes() {
+				assert.True(t, migrator.HasIndex(model, idx.Name), "missing index %s", idx.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "mistake";
+DROP TABLE IF EXISTS "transcript";
+DROP TABLE IF EXISTS "event_attendee";
+DROP TABLE IF EXISTS "event";
+DROP TABLE IF EXISTS "guild_attendee";
+DROP TABLE IF EXISTS "guild";
+DROP TABLE IF EXISTS "user";
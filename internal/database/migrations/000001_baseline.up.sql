@@ -0,0 +1,110 @@
+-- Baseline schema, equivalent to what GORM AutoMigrate created before
+-- versioned migrations were introduced. IF NOT EXISTS lets databases that
+-- were created by AutoMigrate adopt this migration as is.
+
+CREATE TABLE IF NOT EXISTS "user" (
+    "id"                uuid,
+    "email"             text,
+    "name"              text,
+    "avatar_url"        text,
+    "password_hash"     text,
+    "is_email_verified" boolean DEFAULT false,
+    "role"              text DEFAULT 'user',
+    "status"            text DEFAULT 'active',
+    "timezone"          text DEFAULT 'Asia/Taipei',
+    "late_streak"       bigint DEFAULT 0,
+    "points"            bigint DEFAULT 0,
+    "level"             bigint DEFAULT 0,
+    "created_at"        timestamptz,
+    "updated_at"        timestamptz,
+    "deleted_at"        timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_email" ON "user" ("email");
+CREATE INDEX IF NOT EXISTS "idx_user_deleted_at" ON "user" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "guild" (
+    "id"          uuid,
+    "name"        text,
+    "description" text,
+    "avatar_url"  text,
+    "level"       bigint DEFAULT 0,
+    "created_at"  timestamptz,
+    "updated_at"  timestamptz,
+    "deleted_at"  timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_guild_deleted_at" ON "guild" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "guild_attendee" (
+    "id"        uuid,
+    "guild_id"  uuid,
+    "user_id"   uuid,
+    "role"      text DEFAULT 'member',
+    "joined_at" timestamptz,
+    "left_at"   timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_guild_attendee_guild_user" ON "guild_attendee" ("guild_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "event" (
+    "id"                uuid,
+    "title"             text,
+    "description"       text,
+    "start_time"        timestamptz,
+    "expected_duration" decimal,
+    "actual_duration"   decimal,
+    "record_link"       text,
+    "mode"              text DEFAULT 'report',
+    "note"              text,
+    "created_at"        timestamptz,
+    "updated_at"        timestamptz,
+    "deleted_at"        timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_event_deleted_at" ON "event" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "event_attendee" (
+    "id"        uuid,
+    "event_id"  uuid,
+    "user_id"   uuid,
+    "role"      text DEFAULT 'member',
+    "joined_at" timestamptz,
+    "left_at"   timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_event_attendee_event_user" ON "event_attendee" ("event_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "transcript" (
+    "id"               uuid,
+    "event_id"         uuid,
+    "user_id"          uuid,
+    "content"          text,
+    "accent"           jsonb,
+    "start_offset_sec" decimal,
+    "end_offset_sec"   decimal,
+    "note"             text,
+    "created_at"       timestamptz,
+    "updated_at"       timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_transcript_event_id" ON "transcript" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_transcript_user_id" ON "transcript" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "mistake" (
+    "id"               uuid,
+    "event_id"         uuid,
+    "user_id"          uuid,
+    "type"             text DEFAULT 'grammar',
+    "origin_text"      text,
+    "fixed_text"       text,
+    "start_offset_sec" decimal,
+    "end_offset_sec"   decimal,
+    "comment"          text,
+    "note"             text,
+    "created_at"       timestamptz,
+    "updated_at"       timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_mistake_event_id" ON "mistake" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_mistake_user_id" ON "mistake" ("user_id");
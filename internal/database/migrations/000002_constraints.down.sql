@@ -0,0 +1,25 @@
+ALTER TABLE "mistake"
+    DROP CONSTRAINT IF EXISTS "fk_mistake_user",
+    DROP CONSTRAINT IF EXISTS "fk_mistake_event",
+    DROP CONSTRAINT IF EXISTS "chk_mistake_type";
+
+ALTER TABLE "transcript"
+    DROP CONSTRAINT IF EXISTS "fk_transcript_user",
+    DROP CONSTRAINT IF EXISTS "fk_transcript_event";
+
+ALTER TABLE "event_attendee"
+    DROP CONSTRAINT IF EXISTS "fk_event_attendee_user",
+    DROP CONSTRAINT IF EXISTS "fk_event_attendee_event",
+    DROP CONSTRAINT IF EXISTS "chk_event_attendee_role";
+
+ALTER TABLE "event"
+    DROP CONSTRAINT IF EXISTS "chk_event_mode";
+
+ALTER TABLE "guild_attendee"
+    DROP CONSTRAINT IF EXISTS "fk_guild_attendee_user",
+    DROP CONSTRAINT IF EXISTS "fk_guild_attendee_guild",
+    DROP CONSTRAINT IF EXISTS "chk_guild_attendee_role";
+
+ALTER TABLE "user"
+    DROP CONSTRAINT IF EXISTS "chk_user_status",
+    DROP CONSTRAINT IF EXISTS "chk_user_role";
@@ -0,0 +1,34 @@
+-- Enum values accepted by the domain layer (internal/domain).
+ALTER TABLE "user"
+    ADD CONSTRAINT "chk_user_role" CHECK ("role" IN ('user', 'admin', 'staff')),
+    ADD CONSTRAINT "chk_user_status" CHECK ("status" IN ('active', 'banned', 'suspended'));
+
+ALTER TABLE "guild_attendee"
+    ADD CONSTRAINT "chk_guild_attendee_role" CHECK ("role" IN ('member', 'master'));
+
+ALTER TABLE "event"
+    ADD CONSTRAINT "chk_event_mode" CHECK ("mode" IN ('report', 'conversation', 'discussion', 'review'));
+
+ALTER TABLE "event_attendee"
+    ADD CONSTRAINT "chk_event_attendee_role" CHECK ("role" IN ('member', 'emcee'));
+
+ALTER TABLE "mistake"
+    ADD CONSTRAINT "chk_mistake_type" CHECK ("type" IN ('grammar', 'vocab', 'pronunciation', 'advanced'));
+
+-- Foreign keys declared by the model constraint tags. AutoMigrate never
+-- created them because the models have no association fields.
+ALTER TABLE "guild_attendee"
+    ADD CONSTRAINT "fk_guild_attendee_guild" FOREIGN KEY ("guild_id") REFERENCES "guild" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT "fk_guild_attendee_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE "event_attendee"
+    ADD CONSTRAINT "fk_event_attendee_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT "fk_event_attendee_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE "transcript"
+    ADD CONSTRAINT "fk_transcript_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT "fk_transcript_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+
+ALTER TABLE "mistake"
+    ADD CONSTRAINT "fk_mistake_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    ADD CONSTRAINT "fk_mistake_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
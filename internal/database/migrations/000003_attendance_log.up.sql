@@ -0,0 +1,15 @@
+-- Join/leave log of the WebRTC signaling rooms. EventAttendee.joined_at and
+-- left_at keep the first join and the last leave; this table keeps every one.
+CREATE TABLE "attendance_log" (
+    "id"       uuid,
+    "event_id" uuid,
+    "user_id"  uuid,
+    "action"   text,
+    "at"       timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_attendance_log_action" CHECK ("action" IN ('join', 'leave')),
+    CONSTRAINT "fk_attendance_log_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_attendance_log_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX "idx_attendance_log_event_at" ON "attendance_log" ("event_id", "at");
+CREATE INDEX "idx_attendance_log_user_id" ON "attendance_log" ("user_id");
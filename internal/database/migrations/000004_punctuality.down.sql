@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS "attendance_record";
+
+ALTER TABLE "event_attendee"
+    DROP CONSTRAINT IF EXISTS "chk_event_attendee_punctuality",
+    DROP COLUMN IF EXISTS "punctuality";
@@ -0,0 +1,22 @@
+-- Arrival of each attendee, decided when the event closes (NULL until then).
+ALTER TABLE "event_attendee"
+    ADD COLUMN "punctuality" text,
+    ADD CONSTRAINT "chk_event_attendee_punctuality" CHECK ("punctuality" IN ('on_time', 'late', 'absent'));
+
+-- Punctuality history: attendances of closed events with their event's
+-- title and start time, listed by GET /v1/users/:id/attendance.
+CREATE VIEW "attendance_record" AS
+SELECT
+    "event_attendee"."id",
+    "event_attendee"."event_id",
+    "event_attendee"."user_id",
+    "event_attendee"."role",
+    "event"."title",
+    "event"."start_time",
+    "event_attendee"."joined_at",
+    "event_attendee"."left_at",
+    "event_attendee"."punctuality"
+FROM "event_attendee"
+JOIN "event" ON "event"."id" = "event_attendee"."event_id"
+WHERE "event_attendee"."punctuality" IS NOT NULL
+  AND "event"."deleted_at" IS NULL;
@@ -0,0 +1,17 @@
+-- Points ledger. user.points is the sum of a user's entries and user.level /
+-- guild.level are derived from it; clients can no longer write them.
+CREATE TABLE "point_transaction" (
+    "id"         uuid,
+    "user_id"    uuid,
+    "event_id"   uuid,
+    "reason"     text,
+    "amount"     bigint,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_point_transaction_reason" CHECK ("reason" IN ('attended_event', 'emcee', 'mistake_reviewed', 'streak_bonus')),
+    CONSTRAINT "fk_point_transaction_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_point_transaction_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+-- A user earns each reason at most once per event, which makes awarding idempotent
+CREATE UNIQUE INDEX "idx_point_transaction_user_event_reason" ON "point_transaction" ("user_id", "event_id", "reason");
+CREATE INDEX "idx_point_transaction_user_created" ON "point_transaction" ("user_id", "created_at");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_event_start_time";
+DROP INDEX IF EXISTS "idx_point_transaction_created_at";
@@ -0,0 +1,3 @@
+-- Leaderboard windows scan the ledger and events by time across all users
+CREATE INDEX "idx_point_transaction_created_at" ON "point_transaction" ("created_at");
+CREATE INDEX "idx_event_start_time" ON "event" ("start_time");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "guild_join_request";
+DROP TABLE IF EXISTS "guild_invite";
@@ -0,0 +1,39 @@
+-- Guild membership is granted through invites and approved join requests
+-- instead of clients creating guild_attendee rows themselves.
+CREATE TABLE "guild_invite" (
+    "id"         uuid,
+    "guild_id"   uuid,
+    "code"       text,
+    "created_by" uuid,
+    "expires_at" timestamptz,
+    "max_uses"   bigint,
+    "uses"       bigint DEFAULT 0,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_guild_invite_max_uses" CHECK ("max_uses" IS NULL OR "max_uses" > 0),
+    CONSTRAINT "fk_guild_invite_guild" FOREIGN KEY ("guild_id") REFERENCES "guild" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_guild_invite_created_by" FOREIGN KEY ("created_by") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE UNIQUE INDEX "idx_guild_invite_code" ON "guild_invite" ("code");
+CREATE INDEX "idx_guild_invite_guild_id" ON "guild_invite" ("guild_id");
+
+CREATE TABLE "guild_join_request" (
+    "id"          uuid,
+    "guild_id"    uuid,
+    "user_id"     uuid,
+    "status"      text DEFAULT 'pending',
+    "message"     text,
+    "reviewed_by" uuid,
+    "reviewed_at" timestamptz,
+    "created_at"  timestamptz,
+    "updated_at"  timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_guild_join_request_status" CHECK ("status" IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT "fk_guild_join_request_guild" FOREIGN KEY ("guild_id") REFERENCES "guild" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_guild_join_request_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_guild_join_request_reviewed_by" FOREIGN KEY ("reviewed_by") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+-- At most one pending request per user and guild
+CREATE UNIQUE INDEX "idx_guild_join_request_pending" ON "guild_join_request" ("guild_id", "user_id") WHERE "status" = 'pending';
+CREATE INDEX "idx_guild_join_request_user_id" ON "guild_join_request" ("user_id");
@@ -0,0 +1,7 @@
+-- Guild search matches substrings of name and description with ILIKE,
+-- which a trigram index serves. The expression must match guildSearchText
+-- in internal/repository/gorm_guild.go.
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+CREATE INDEX "idx_guild_search" ON "guild"
+    USING gin ((COALESCE("name", '') || ' ' || COALESCE("description", '')) gin_trgm_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_event_guild_start";
+
+ALTER TABLE "event"
+    DROP CONSTRAINT IF EXISTS "fk_event_guild",
+    DROP COLUMN IF EXISTS "guild_id";
@@ -0,0 +1,7 @@
+-- Events can belong to a guild; NULL for personal practices.
+ALTER TABLE "event"
+    ADD COLUMN "guild_id" uuid,
+    ADD CONSTRAINT "fk_event_guild" FOREIGN KEY ("guild_id") REFERENCES "guild" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+
+-- Guild calendars list a guild's events by start time
+CREATE INDEX "idx_event_guild_start" ON "event" ("guild_id", "start_time");
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "idx_event_series_occurrence";
+
+ALTER TABLE "event"
+    DROP CONSTRAINT IF EXISTS "fk_event_series",
+    DROP COLUMN IF EXISTS "detached",
+    DROP COLUMN IF EXISTS "occurrence_start",
+    DROP COLUMN IF EXISTS "series_id";
+
+DROP TABLE IF EXISTS "event_series";
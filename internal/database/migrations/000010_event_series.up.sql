@@ -0,0 +1,37 @@
+-- Recurring practices. Occurrences are materialized ahead of time as event
+-- rows pointing back at their series.
+CREATE TABLE "event_series" (
+    "id"                 uuid,
+    "guild_id"           uuid,
+    "created_by"         uuid,
+    "title"              text,
+    "description"        text,
+    "expected_duration"  decimal,
+    "mode"               text DEFAULT 'report',
+    "note"               text,
+    "start_time"         timestamptz,
+    "timezone"           text DEFAULT 'UTC',
+    "rrule"              text,
+    "exdates"            jsonb,
+    "materialized_until" timestamptz,
+    "created_at"         timestamptz,
+    "updated_at"         timestamptz,
+    "deleted_at"         timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_event_series_mode" CHECK ("mode" IN ('report', 'conversation', 'discussion', 'review')),
+    CONSTRAINT "fk_event_series_guild" FOREIGN KEY ("guild_id") REFERENCES "guild" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_event_series_created_by" FOREIGN KEY ("created_by") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX "idx_event_series_guild_id" ON "event_series" ("guild_id");
+CREATE INDEX "idx_event_series_created_by" ON "event_series" ("created_by");
+CREATE INDEX "idx_event_series_materialized_until" ON "event_series" ("materialized_until");
+CREATE INDEX "idx_event_series_deleted_at" ON "event_series" ("deleted_at");
+
+ALTER TABLE "event"
+    ADD COLUMN "series_id" uuid,
+    ADD COLUMN "occurrence_start" timestamptz,
+    ADD COLUMN "detached" boolean DEFAULT false,
+    ADD CONSTRAINT "fk_event_series" FOREIGN KEY ("series_id") REFERENCES "event_series" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+-- Keeps materialization idempotent; soft-deleted occurrences stay in the
+-- index so they are not materialized again
+CREATE UNIQUE INDEX "idx_event_series_occurrence" ON "event" ("series_id", "occurrence_start");
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "idx_event_status";
+
+ALTER TABLE "event"
+    DROP CONSTRAINT IF EXISTS "chk_event_status",
+    DROP COLUMN IF EXISTS "cancelled_at",
+    DROP COLUMN IF EXISTS "reviewed_at",
+    DROP COLUMN IF EXISTS "ended_at",
+    DROP COLUMN IF EXISTS "started_at",
+    DROP COLUMN IF EXISTS "status";
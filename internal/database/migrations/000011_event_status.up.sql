@@ -0,0 +1,17 @@
+-- Event lifecycle: scheduled -> live -> ended -> reviewed, or cancelled,
+-- with the time each status was entered.
+ALTER TABLE "event"
+    ADD COLUMN "status"       text DEFAULT 'scheduled',
+    ADD COLUMN "started_at"   timestamptz,
+    ADD COLUMN "ended_at"     timestamptz,
+    ADD COLUMN "reviewed_at"  timestamptz,
+    ADD COLUMN "cancelled_at" timestamptz,
+    ADD CONSTRAINT "chk_event_status" CHECK ("status" IN ('scheduled', 'live', 'ended', 'reviewed', 'cancelled'));
+
+-- Events closed before statuses existed have an actual duration
+UPDATE "event"
+SET "status" = 'ended',
+    "ended_at" = "updated_at"
+WHERE "actual_duration" IS NOT NULL;
+
+CREATE INDEX "idx_event_status" ON "event" ("status");
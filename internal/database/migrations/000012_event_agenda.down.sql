@@ -0,0 +1,11 @@
+ALTER TABLE "event_attendee"
+    DROP CONSTRAINT IF EXISTS "chk_event_attendee_speaking_order",
+    DROP COLUMN IF EXISTS "speaking_order";
+
+DROP INDEX IF EXISTS "idx_event_review_of_id";
+
+ALTER TABLE "event"
+    DROP CONSTRAINT IF EXISTS "fk_event_review_of",
+    DROP CONSTRAINT IF EXISTS "chk_event_slot_minutes",
+    DROP COLUMN IF EXISTS "review_of_id",
+    DROP COLUMN IF EXISTS "slot_minutes";
@@ -0,0 +1,13 @@
+-- Mode-specific agenda settings: per-speaker slots of report events, the
+-- past event a review event goes through, and the attendees' speaking order.
+ALTER TABLE "event"
+    ADD COLUMN "slot_minutes" decimal,
+    ADD COLUMN "review_of_id" uuid,
+    ADD CONSTRAINT "chk_event_slot_minutes" CHECK ("slot_minutes" > 0),
+    ADD CONSTRAINT "fk_event_review_of" FOREIGN KEY ("review_of_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE INDEX "idx_event_review_of_id" ON "event" ("review_of_id");
+
+ALTER TABLE "event_attendee"
+    ADD COLUMN "speaking_order" integer,
+    ADD CONSTRAINT "chk_event_attendee_speaking_order" CHECK ("speaking_order" > 0);
@@ -0,0 +1,16 @@
+-- Chat messages of the WebRTC signaling rooms, replayed to whoever joins
+-- and readable after the practice.
+CREATE TABLE "event_message" (
+    "id"         uuid,
+    "event_id"   uuid,
+    "user_id"    uuid,
+    "content"    text,
+    "created_at" timestamptz,
+    "edited_at"  timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_event_message_content" CHECK (char_length("content") BETWEEN 1 AND 2000),
+    CONSTRAINT "fk_event_message_event" FOREIGN KEY ("event_id") REFERENCES "event" ("id") ON UPDATE CASCADE ON DELETE RESTRICT,
+    CONSTRAINT "fk_event_message_user" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON UPDATE CASCADE ON DELETE RESTRICT
+);
+CREATE INDEX "idx_event_message_event_created_at" ON "event_message" ("event_id", "created_at");
+CREATE INDEX "idx_event_message_user_id" ON "event_message" ("user_id");
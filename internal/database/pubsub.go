@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxNotifyChunk keeps a NOTIFY payload, chunk header included, below
+	// the 8000 byte limit of Postgres
+	maxNotifyChunk = 7800
+	// maxNotifyChunks bounds how many chunks a message may be split into
+	maxNotifyChunks = 1024
+	// listenRetry is how long Subscribe waits before listening again after
+	// losing its connection
+	listenRetry = time.Second
+	// partialTimeout drops split messages whose other chunks never arrive
+	partialTimeout = time.Minute
+)
+
+// PubSub sends messages over a Postgres LISTEN/NOTIFY channel. A message
+// longer than a NOTIFY payload is split into chunks, sent in one
+// transaction and joined again by Subscribe.
+type PubSub struct {
+	databaseURL string
+	channel     string
+	pool        *pgxpool.Pool
+}
+
+// NewPubSub connects to DATABASE_URL for publishing on channel. Subscribe
+// opens a connection of its own.
+func NewPubSub(ctx context.Context, databaseURL, channel string) (*PubSub, error) {
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxConns = 4
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &PubSub{databaseURL: databaseURL, channel: channel, pool: pool}, nil
+}
+
+// Close releases the publishing connections.
+func (p *PubSub) Close() {
+	p.pool.Close()
+}
+
+// Publish notifies every listener of the channel, this process included.
+func (p *PubSub) Publish(ctx context.Context, msg []byte) error {
+	chunks := splitPayload(string(msg), maxNotifyChunk)
+	if len(chunks) > maxNotifyChunks {
+		return fmt.Errorf("message of %d bytes is too large to publish", len(msg))
+	}
+	id := uuid.New().String()
+	if len(chunks) == 1 {
+		_, err := p.pool.Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, formatChunk(id, 0, 1, chunks[0]))
+		return err
+	}
+	// Notifications of one transaction are delivered together, in order
+	return pgx.BeginFunc(ctx, p.pool, func(tx pgx.Tx) error {
+		for i, chunk := range chunks {
+			if _, err := tx.Exec(ctx, "SELECT pg_notify($1, $2)", p.channel, formatChunk(id, i, len(chunks), chunk)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Subscribe calls fn with every message published on the channel until ctx
+// ends. A lost connection is reopened; messages published in the meantime
+// are missed.
+func (p *PubSub) Subscribe(ctx context.Context, fn func(msg []byte)) error {
+	buf := newChunkBuffer()
+	for {
+		err := p.listen(ctx, buf, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("LISTEN %s 中斷，%s 後重新連線: %v", p.channel, listenRetry, err)
+		select {
+		case <-time.After(listenRetry):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// listen receives notifications on one connection until it fails.
+func (p *PubSub) listen(ctx context.Context, buf *chunkBuffer, fn func(msg []byte)) error {
+	conn, err := pgx.Connect(ctx, p.databaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		msg, ok, err := buf.add(n.Payload, time.Now())
+		if err != nil {
+			log.Printf("忽略無效的 %s 通知: %v", p.channel, err)
+			continue
+		}
+		if ok {
+			fn(msg)
+		}
+	}
+}
+
+// formatChunk prefixes chunk i of n of message id with its header.
+func formatChunk(id string, i, n int, chunk string) string {
+	return fmt.Sprintf("%s %d %d %s", id, i, n, chunk)
+}
+
+// splitPayload cuts s into chunks of at most size bytes, never inside a
+// UTF-8 sequence, as NOTIFY payloads must be valid text.
+func splitPayload(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	return append(chunks, s)
+}
+
+// chunkBuffer joins the chunks of split messages.
+type chunkBuffer struct {
+	partial map[string]*partialMessage
+}
+
+type partialMessage struct {
+	chunks   []string
+	seen     []bool
+	received int
+	first    time.Time
+}
+
+func newChunkBuffer() *chunkBuffer {
+	return &chunkBuffer{partial: make(map[string]*partialMessage)}
+}
+
+// add takes a notification payload and returns the message once all of its
+// chunks arrived.
+func (b *chunkBuffer) add(payload string, now time.Time) ([]byte, bool, error) {
+	fields := strings.SplitN(payload, " ", 4)
+	if len(fields) != 4 {
+		return nil, false, fmt.Errorf("missing chunk header")
+	}
+	id := fields[0]
+	i, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid chunk index: %w", err)
+	}
+	n, err := strconv.Atoi(fields[2])
+	if err != nil || n < 1 || n > maxNotifyChunks || i < 0 || i >= n {
+		return nil, false, fmt.Errorf("invalid chunk %s of %s", fields[1], fields[2])
+	}
+	if n == 1 {
+		return []byte(fields[3]), true, nil
+	}
+
+	for other, m := range b.partial {
+		if now.Sub(m.first) > partialTimeout {
+			delete(b.partial, other)
+		}
+	}
+	m, ok := b.partial[id]
+	if !ok {
+		m = &partialMessage{chunks: make([]string, n), seen: make([]bool, n), first: now}
+		b.partial[id] = m
+	}
+	if len(m.chunks) != n {
+		return nil, false, fmt.Errorf("chunk count of %s changed", id)
+	}
+	if !m.seen[i] {
+		m.chunks[i], m.seen[i] = fields[3], true
+		m.received++
+	}
+	if m.received < n {
+		return nil, false, nil
+	}
+	delete(b.partial, id)
+	return []byte(strings.Join(m.chunks, "")), true, nil
+}
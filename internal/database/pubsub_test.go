@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPayload(t *testing.T) {
+	assert.Equal(t, []string{""}, splitPayload("", 4))
+	assert.Equal(t, []string{"abcd", "ef"}, splitPayload("abcdef", 4))
+
+	// 練 is three bytes and must not be cut
+	chunks := splitPayload("ab練習", 4)
+	assert.Equal(t, []string{"ab", "練", "習"}, chunks)
+	for _, chunk := range chunks {
+		assert.True(t, utf8.ValidString(chunk), chunk)
+	}
+}
+
+func TestChunkBuffer(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Single", func(t *testing.T) {
+		buf := newChunkBuffer()
+		msg, ok, err := buf.add(formatChunk("a", 0, 1, "hello world"), now)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "hello world", string(msg))
+	})
+
+	t.Run("OutOfOrder", func(t *testing.T) {
+		buf := newChunkBuffer()
+		_, ok, err := buf.add(formatChunk("a", 1, 2, "world"), now)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, _ = buf.add(formatChunk("b", 0, 2, "other "), now)
+		assert.False(t, ok)
+
+		msg, ok, err := buf.add(formatChunk("a", 0, 2, "hello "), now)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "hello world", string(msg))
+		assert.Len(t, buf.partial, 1)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		buf := newChunkBuffer()
+		_, _, _ = buf.add(formatChunk("a", 0, 2, "lost"), now)
+		_, _, _ = buf.add(formatChunk("b", 0, 2, "new"), now.Add(2*partialTimeout))
+
+		assert.NotContains(t, buf.partial, "a")
+		assert.Contains(t, buf.partial, "b")
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		buf := newChunkBuffer()
+		for _, payload := range []string{"", "a 0", "a x 1 data", "a 1 1 data", "a 0 0 data"} {
+			_, ok, err := buf.add(payload, now)
+			assert.Error(t, err, payload)
+			assert.False(t, ok, payload)
+		}
+	})
+}
+
+func TestPubSub(t *testing.T) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	channel := fmt.Sprintf("test_%s", strings.ReplaceAll(uuid.NewString(), "-", ""))
+	ps, err := NewPubSub(ctx, databaseURL, channel)
+	require.NoError(t, err)
+	defer ps.Close()
+
+	received := make(chan []byte, 10)
+	subCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() { _ = ps.Subscribe(subCtx, func(msg []byte) { received <- msg }) }()
+
+	// Keep publishing until the subscription is listening
+	ready := false
+	for !ready {
+		require.NoError(t, ps.Publish(ctx, []byte("ping")))
+		select {
+		case <-received:
+			ready = true
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("subscription never received a message")
+		}
+	}
+
+	large := strings.Repeat("練習", 5000) // 30000 bytes, four chunks
+	require.NoError(t, ps.Publish(ctx, []byte(large)))
+	for {
+		select {
+		case msg := <-received:
+			if string(msg) == "ping" {
+				continue
+			}
+			assert.Equal(t, large, string(msg))
+			return
+		case <-ctx.Done():
+			t.Fatal("large message never arrived")
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAgenda means the agenda settings of an event are not usable,
+// such as a speaking order naming a user twice.
+var ErrInvalidAgenda = errors.New("invalid agenda")
+
+// Agenda is how a session runs in the event's mode. It is derived from the
+// event and its attendees rather than stored.
+type Agenda struct {
+	EventID uuid.UUID `json:"event_id"`
+	Mode    EventMode `json:"mode"`
+	// Speakers are the attendees other than the emcee, in speaking order
+	Speakers []AgendaSpeaker `json:"speakers"`
+	// Slots gives every speaker of a report event a turn of its own
+	Slots []AgendaSlot `json:"slots,omitempty"`
+	// Pairs groups the speakers of a conversation event two by two; with
+	// an odd number of speakers the last group has three
+	Pairs [][]AgendaSpeaker `json:"pairs,omitempty"`
+	// Review is the past event a review event goes through, if it has one
+	Review *AgendaReview `json:"review,omitempty"`
+}
+
+type AgendaSpeaker struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+}
+
+// AgendaSlot is a speaker's turn in a report event.
+type AgendaSlot struct {
+	AgendaSpeaker
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// AgendaReview lists the mistakes the attendees of a review event made at
+// the event under review.
+type AgendaReview struct {
+	EventID   uuid.UUID  `json:"event_id"`
+	Title     string     `json:"title"`
+	StartTime time.Time  `json:"start_time"`
+	Mistakes  []*Mistake `json:"mistakes"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AttendanceAction represents what an attendance log entry records.
+type AttendanceAction string
+
+const (
+	AttendanceActionJoin  AttendanceAction = "join"
+	AttendanceActionLeave AttendanceAction = "leave"
+)
+
+// AttendanceLog is one join or leave of a user in an event's signaling room.
+// Maps to jpcorrect.attendance_log table.
+type AttendanceLog struct {
+	ID      uuid.UUID        `gorm:"type:uuid;primaryKey" json:"attendance_log_id"`
+	EventID uuid.UUID        `gorm:"type:uuid;index:idx_attendance_log_event_at,priority:1" json:"event_id"`
+	UserID  uuid.UUID        `gorm:"type:uuid;index:idx_attendance_log_user_id" json:"user_id"`
+	Action  AttendanceAction `json:"action"`
+	At      time.Time        `gorm:"index:idx_attendance_log_event_at,priority:2" json:"at"`
+}
+
+// AttendanceRecord is a user's arrival at a closed event.
+// Maps to the jpcorrect.attendance_record view.
+type AttendanceRecord struct {
+	ID          uuid.UUID         `gorm:"type:uuid;primaryKey" json:"event_attendee_id"`
+	EventID     uuid.UUID         `gorm:"type:uuid" json:"event_id"`
+	UserID      uuid.UUID         `gorm:"type:uuid" json:"user_id"`
+	Role        EventAttendeeRole `json:"role"`
+	Title       string            `json:"title"`
+	StartTime   time.Time         `json:"start_time"`
+	JoinedAt    *time.Time        `json:"joined_at"`
+	LeftAt      *time.Time        `json:"left_at"`
+	Punctuality Punctuality       `json:"punctuality"`
+}
+
+type AttendanceRepository interface {
+	// Record appends the entry to the log and folds it into the matching
+	// EventAttendee: JoinedAt keeps the first join, LeftAt the last leave.
+	Record(ctx context.Context, entry *AttendanceLog) error
+	// ListByEventID returns the event's log in chronological order.
+	ListByEventID(ctx context.Context, eventID uuid.UUID) ([]*AttendanceLog, error)
+	// ListRecordsByUserID returns the user's punctuality history.
+	ListRecordsByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*AttendanceRecord], error)
+}
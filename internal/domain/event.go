@@ -2,6 +2,8 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,28 +20,138 @@ const (
 	EventModeReview       EventMode = "review"
 )
 
-// Event represents an event in the jpcorrect system.
+// EventStatus is the lifecycle state of an event.
+type EventStatus string
+
+const (
+	EventStatusScheduled EventStatus = "scheduled"
+	EventStatusLive      EventStatus = "live"
+	EventStatusEnded     EventStatus = "ended"
+	// Reviewed events have had their recording and mistakes gone through
+	EventStatusReviewed  EventStatus = "reviewed"
+	EventStatusCancelled EventStatus = "cancelled"
+)
+
+// ErrInvalidTransition means an event cannot move to the requested status
+// from its current one.
+var ErrInvalidTransition = errors.New("invalid event status transition")
+
+// eventTransitions lists the statuses each status can move to.
+var eventTransitions = map[EventStatus][]EventStatus{
+	EventStatusScheduled: {EventStatusLive, EventStatusCancelled},
+	EventStatusLive:      {EventStatusEnded},
+	EventStatusEnded:     {EventStatusReviewed},
+}
+
+// CanTransitionTo reports whether an event can move from s to to.
+func (s EventStatus) CanTransitionTo(to EventStatus) bool {
+	for _, next := range eventTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether attendees can still enter the event's room.
+func (s EventStatus) IsOpen() bool {
+	return s == EventStatusScheduled || s == EventStatusLive
+}
+
+// Event represents an event in the jpcorrect system. GuildID is set for
+// events owned by a guild, which only its masters can create or cancel; it
+// cannot change after creation.
+// SeriesID and OccurrenceStart are set for occurrences of an EventSeries.
+// Detached marks an occurrence edited on its own, which edits of the whole
+// series no longer change.
+// Status only changes through Transition, which records when each status
+// was entered; ActualDuration is the time between StartedAt and EndedAt.
+// SlotMinutes and ReviewOfID configure the Agenda of report and review events.
 // Maps to jpcorrect.event table.
 type Event struct {
 	ID               uuid.UUID      `gorm:"type:uuid;primaryKey" json:"event_id"`
+	GuildID          *uuid.UUID     `gorm:"type:uuid;index:idx_event_guild_start,priority:1" json:"guild_id"`
+	SeriesID         *uuid.UUID     `gorm:"type:uuid;uniqueIndex:idx_event_series_occurrence,priority:1" json:"series_id"`
+	OccurrenceStart  *time.Time     `gorm:"uniqueIndex:idx_event_series_occurrence,priority:2" json:"occurrence_start"`
+	Detached         bool           `gorm:"default:false" json:"detached"`
 	Title            string         `json:"title"`
 	Description      *string        `gorm:"type:text" json:"description"`
-	StartTime        time.Time      `json:"start_time"`
+	StartTime        time.Time      `gorm:"index;index:idx_event_guild_start,priority:2" json:"start_time"`
 	ExpectedDuration float64        `json:"expected_duration"`
 	ActualDuration   *float64       `json:"actual_duration"`
 	RecordLink       *string        `json:"record_link"`
 	Mode             EventMode      `gorm:"default:report" json:"mode"`
 	Note             *string        `gorm:"type:text" json:"note"`
+	SlotMinutes      *float64       `json:"slot_minutes"`
+	ReviewOfID       *uuid.UUID     `gorm:"type:uuid;index" json:"review_of_id"`
+	Status           EventStatus    `gorm:"default:scheduled;index" json:"status"`
+	StartedAt        *time.Time     `json:"started_at"`
+	EndedAt          *time.Time     `json:"ended_at"`
+	ReviewedAt       *time.Time     `json:"reviewed_at"`
+	CancelledAt      *time.Time     `json:"cancelled_at"`
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
+// Transition moves the event to status at the given time and records it.
+// Ending the event also sets ActualDuration in minutes.
+func (e *Event) Transition(to EventStatus, at time.Time) error {
+	if !e.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, e.Status, to)
+	}
+	switch to {
+	case EventStatusLive:
+		e.StartedAt = &at
+	case EventStatusEnded:
+		e.EndedAt = &at
+		if e.StartedAt != nil {
+			duration := at.Sub(*e.StartedAt).Minutes()
+			e.ActualDuration = &duration
+		}
+	case EventStatusReviewed:
+		e.ReviewedAt = &at
+	case EventStatusCancelled:
+		e.CancelledAt = &at
+	}
+	e.Status = to
+	return nil
+}
+
 type EventRepository interface {
 	GetByID(ctx context.Context, eventID uuid.UUID) (*Event, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Event, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*Event], error)
+	// ListByGuildID lists the guild's events, by start time by default.
+	ListByGuildID(ctx context.Context, guildID uuid.UUID, opts ListOptions) (*Page[*Event], error)
+	// ListBySeriesID lists the series' occurrences, by start time by default.
+	ListBySeriesID(ctx context.Context, seriesID uuid.UUID, opts ListOptions) (*Page[*Event], error)
+	// ListFeedByUserID lists the events userID attends starting at or after
+	// since, by start time. Cancelled and soft-deleted events are included.
+	ListFeedByUserID(ctx context.Context, userID uuid.UUID, since time.Time) ([]*Event, error)
+	// ListFeedByGuildID lists the guild's events starting at or after since,
+	// by start time. Cancelled and soft-deleted events are included.
+	ListFeedByGuildID(ctx context.Context, guildID uuid.UUID, since time.Time) ([]*Event, error)
 
 	Create(ctx context.Context, event *Event) error
+	// CreateWithEmcee creates the event and registers emceeID as its emcee in one transaction.
+	CreateWithEmcee(ctx context.Context, event *Event, emceeID uuid.UUID) error
+	// Update saves the event except GuildID, SeriesID, OccurrenceStart and
+	// its lifecycle fields, ActualDuration included.
 	Update(ctx context.Context, event *Event) error
+	// Transition saves the event's status, transition timestamps,
+	// ActualDuration and RecordLink if its stored status is still from, and
+	// fails with ErrInvalidTransition otherwise.
+	Transition(ctx context.Context, event *Event, from EventStatus) error
+	// Close transitions the event from live like Transition, stores each
+	// attendee's punctuality and updates their LateStreak in one
+	// transaction. Attendees that were already evaluated are left untouched.
+	Close(ctx context.Context, event *Event, arrivals []Arrival) error
 	Delete(ctx context.Context, eventID uuid.UUID) error
 }
+
+// Arrival is the punctuality of one attendee of a closing event.
+type Arrival struct {
+	UserID      uuid.UUID
+	Punctuality Punctuality
+}
@@ -16,6 +16,17 @@ const (
 	EventAttendeeRoleEmcee EventAttendeeRole = "emcee"
 )
 
+// Punctuality is how an attendee arrived at an event, decided when the
+// event closes.
+type Punctuality string
+
+const (
+	PunctualityOnTime Punctuality = "on_time"
+	PunctualityLate   Punctuality = "late"
+	// Absent attendees never joined the event's room
+	PunctualityAbsent Punctuality = "absent"
+)
+
 // EventAttendee represents an attendee of an event.
 // Maps to jpcorrect.event_attendee table.
 type EventAttendee struct {
@@ -25,14 +36,26 @@ type EventAttendee struct {
 	Role     EventAttendeeRole `gorm:"default:member" json:"role"`
 	JoinedAt *time.Time        `json:"joined_at"`
 	LeftAt   *time.Time        `json:"left_at"`
+	// Punctuality is nil until the event closes
+	Punctuality *Punctuality `json:"punctuality"`
+	// SpeakingOrder places the attendee on the event's Agenda, starting
+	// from 1; attendees without one speak after those with one
+	SpeakingOrder *int `json:"speaking_order"`
 }
 
 type EventAttendeeRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*EventAttendee, error)
 	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*EventAttendee, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*EventAttendee, error)
+	GetByEventAndUser(ctx context.Context, eventID, userID uuid.UUID) (*EventAttendee, error)
+	ListByEventID(ctx context.Context, eventID uuid.UUID, opts ListOptions) (*Page[*EventAttendee], error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*EventAttendee], error)
 
 	Create(ctx context.Context, attendee *EventAttendee) error
 	Update(ctx context.Context, attendee *EventAttendee) error
+	// SetSpeakingOrder numbers the attendees userIDs of the event from 1 in
+	// order and clears the order of the others, in one transaction. It fails
+	// with ErrNotFound if one of the users does not attend the event.
+	SetSpeakingOrder(ctx context.Context, eventID uuid.UUID, userIDs []uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxEventMessageLength bounds the characters of a chat message.
+const MaxEventMessageLength = 2000
+
+// EventMessage is a chat message sent in an event's signaling room.
+// Maps to jpcorrect.event_message table.
+type EventMessage struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"event_message_id"`
+	EventID   uuid.UUID  `gorm:"type:uuid;index:idx_event_message_event_created_at,priority:1" json:"event_id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index:idx_event_message_user_id" json:"user_id"`
+	Content   string     `gorm:"type:text" json:"content"`
+	CreatedAt time.Time  `gorm:"index:idx_event_message_event_created_at,priority:2" json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
+
+// EventMessageView is a chat message with the name of its author.
+type EventMessageView struct {
+	EventMessage
+	UserName string `json:"user_name"`
+}
+
+type EventMessageRepository interface {
+	GetByID(ctx context.Context, messageID uuid.UUID) (*EventMessageView, error)
+	// ListRecent returns the last limit messages of the event, oldest first.
+	ListRecent(ctx context.Context, eventID uuid.UUID, limit int) ([]*EventMessageView, error)
+	// ListByEventID pages through the messages of the event. Filters are
+	// user_id, created_after and created_before; the only sort is created_at
+	// (default, ascending).
+	ListByEventID(ctx context.Context, eventID uuid.UUID, opts ListOptions) (*Page[*EventMessageView], error)
+
+	Create(ctx context.Context, message *EventMessage) error
+	Update(ctx context.Context, message *EventMessage) error
+	Delete(ctx context.Context, messageID uuid.UUID) error
+}
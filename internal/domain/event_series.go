@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRecurrence means a series' RRule is outside the supported subset.
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// EventSeries is a recurring practice. It holds the template and schedule
+// its occurrences are materialized from as Event rows ahead of time; the
+// creator hosts every occurrence. StartTime is the first occurrence, and
+// RRule repeats its wall-clock time in Timezone. GuildID, CreatedBy,
+// StartTime and Timezone cannot change after creation.
+// Maps to jpcorrect.event_series table.
+type EventSeries struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"series_id"`
+	GuildID          *uuid.UUID `gorm:"type:uuid;index" json:"guild_id"`
+	CreatedBy        uuid.UUID  `gorm:"type:uuid;index" json:"created_by"`
+	Title            string     `json:"title"`
+	Description      *string    `gorm:"type:text" json:"description"`
+	ExpectedDuration float64    `json:"expected_duration"`
+	Mode             EventMode  `gorm:"default:report" json:"mode"`
+	Note             *string    `gorm:"type:text" json:"note"`
+	StartTime        time.Time  `json:"start_time"`
+	Timezone         string     `gorm:"default:UTC" json:"timezone"`
+	// RRule is an RFC 5545 recurrence rule such as "FREQ=WEEKLY;INTERVAL=2;COUNT=10"
+	RRule string `gorm:"column:rrule" json:"rrule"`
+	// ExDates are occurrence start times that are skipped
+	ExDates datatypes.JSONSlice[time.Time] `gorm:"column:exdates;type:jsonb" json:"exdates"`
+	// MaterializedUntil is the end of the range whose occurrences exist as
+	// Event rows; nil before the first materialization
+	MaterializedUntil *time.Time     `gorm:"index" json:"materialized_until"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// IsExcluded reports whether the occurrence starting at t is an exception.
+func (s *EventSeries) IsExcluded(t time.Time) bool {
+	for _, d := range s.ExDates {
+		if d.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Occurrence returns the series' occurrence starting at start, built from
+// its template.
+func (s *EventSeries) Occurrence(start time.Time) *Event {
+	occurrenceStart := start
+	return &Event{
+		GuildID:          s.GuildID,
+		SeriesID:         &s.ID,
+		OccurrenceStart:  &occurrenceStart,
+		Title:            s.Title,
+		Description:      s.Description,
+		StartTime:        start,
+		ExpectedDuration: s.ExpectedDuration,
+		Mode:             s.Mode,
+		Note:             s.Note,
+	}
+}
+
+type EventSeriesRepository interface {
+	GetByID(ctx context.Context, seriesID uuid.UUID) (*EventSeries, error)
+	// ListDue lists the series not yet materialized up to before.
+	ListDue(ctx context.Context, before time.Time) ([]*EventSeries, error)
+	Create(ctx context.Context, series *EventSeries) error
+	// Materialize creates the occurrences that do not exist yet, each hosted
+	// by the series creator, and advances MaterializedUntil to until in one
+	// transaction. Occurrences that were removed are not created again.
+	Materialize(ctx context.Context, series *EventSeries, occurrences []*Event, until time.Time) error
+	// Update saves the series' template, RRule, ExDates and
+	// MaterializedUntil, copies the template to its occurrences starting at
+	// or after from that were not edited on their own, and removes those of
+	// them starting at one of removed, in one transaction.
+	Update(ctx context.Context, series *EventSeries, from time.Time, removed []time.Time) error
+	// Delete removes the series and its occurrences starting at or after
+	// from that were not edited on their own.
+	Delete(ctx context.Context, seriesID uuid.UUID, from time.Time) error
+}
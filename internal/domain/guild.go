@@ -18,6 +18,7 @@ const (
 )
 
 // Guild represents a guild in the jpcorrect system.
+// Level is derived from the members' points by the server.
 // Maps to jpcorrect.guild table.
 type Guild struct {
 	ID          uuid.UUID      `gorm:"type:uuid;primaryKey" json:"guild_id"`
@@ -30,10 +31,30 @@ type Guild struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
+// GuildActivityWindow is the period GuildSummary.Activity covers.
+const GuildActivityWindow = 30 * 24 * time.Hour
+
+// GuildSummary is a guild as listed by guild discovery.
+type GuildSummary struct {
+	Guild
+	// MemberCount counts the current members
+	MemberCount int `json:"member_count"`
+	// Activity is the points the current members earned in the guild during
+	// the last GuildActivityWindow
+	Activity int `json:"activity"`
+}
+
 type GuildRepository interface {
 	GetByID(ctx context.Context, guildID uuid.UUID) (*Guild, error)
+	// List searches guilds. Filters are q (substring of name or description),
+	// min_level, max_level, min_members and max_members; sorts are activity
+	// (default, descending), member_count, level, created_at and name.
+	List(ctx context.Context, opts ListOptions) (*Page[*GuildSummary], error)
 
 	Create(ctx context.Context, guild *Guild) error
+	// CreateWithMaster creates the guild and registers masterID as its master in one transaction.
+	CreateWithMaster(ctx context.Context, guild *Guild, masterID uuid.UUID) error
+	// Update saves the guild except Level.
 	Update(ctx context.Context, guild *Guild) error
 	Delete(ctx context.Context, guildID uuid.UUID) error
 }
@@ -53,6 +74,9 @@ type GuildAttendeeRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*GuildAttendee, error)
 	GetByGuildID(ctx context.Context, guildID uuid.UUID) ([]*GuildAttendee, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*GuildAttendee, error)
+	GetByGuildAndUser(ctx context.Context, guildID, userID uuid.UUID) (*GuildAttendee, error)
+	ListByGuildID(ctx context.Context, guildID uuid.UUID, opts ListOptions) (*Page[*GuildAttendee], error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*GuildAttendee], error)
 
 	Create(ctx context.Context, attendee *GuildAttendee) error
 	Update(ctx context.Context, attendee *GuildAttendee) error
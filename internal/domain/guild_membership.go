@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInviteUnavailable means the invite was revoked, has expired or has
+	// no uses left.
+	ErrInviteUnavailable = errors.New("invite is no longer valid")
+	ErrAlreadyMember     = errors.New("already a member of this guild")
+	// ErrRequestResolved means the join request was already approved or
+	// rejected.
+	ErrRequestResolved = errors.New("join request already resolved")
+	// ErrLastMaster means the change would leave the guild without a master.
+	ErrLastMaster = errors.New("guild must keep at least one master")
+)
+
+// GuildInvite is a shareable code that lets users join a guild as members.
+// Maps to jpcorrect.guild_invite table.
+type GuildInvite struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"guild_invite_id"`
+	GuildID   uuid.UUID  `gorm:"type:uuid;index" json:"guild_id"`
+	Code      string     `gorm:"uniqueIndex" json:"code"`
+	CreatedBy uuid.UUID  `gorm:"type:uuid" json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// MaxUses is nil for an invite without a limit
+	MaxUses   *int       `json:"max_uses"`
+	Uses      int        `gorm:"default:0" json:"uses"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the invite can still be redeemed at the given time.
+func (i *GuildInvite) Usable(at time.Time) bool {
+	return i.RevokedAt == nil &&
+		(i.ExpiresAt == nil || at.Before(*i.ExpiresAt)) &&
+		(i.MaxUses == nil || i.Uses < *i.MaxUses)
+}
+
+// GuildJoinRequestStatus represents the state of a join request.
+type GuildJoinRequestStatus string
+
+const (
+	GuildJoinRequestStatusPending  GuildJoinRequestStatus = "pending"
+	GuildJoinRequestStatusApproved GuildJoinRequestStatus = "approved"
+	GuildJoinRequestStatusRejected GuildJoinRequestStatus = "rejected"
+)
+
+// GuildJoinRequest is a user's request to join a guild, resolved by a master.
+// A user has at most one pending request per guild.
+// Maps to jpcorrect.guild_join_request table.
+type GuildJoinRequest struct {
+	ID         uuid.UUID              `gorm:"type:uuid;primaryKey" json:"guild_join_request_id"`
+	GuildID    uuid.UUID              `gorm:"type:uuid;uniqueIndex:idx_guild_join_request_pending,where:status = 'pending',priority:1" json:"guild_id"`
+	UserID     uuid.UUID              `gorm:"type:uuid;uniqueIndex:idx_guild_join_request_pending,where:status = 'pending',priority:2;index" json:"user_id"`
+	Status     GuildJoinRequestStatus `gorm:"default:pending" json:"status"`
+	Message    string                 `gorm:"type:text" json:"message"`
+	ReviewedBy *uuid.UUID             `gorm:"type:uuid" json:"reviewed_by"`
+	ReviewedAt *time.Time             `json:"reviewed_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+// GuildMembershipRepository stores invites and join requests and applies
+// membership changes. Joining adds a GuildAttendee as member, or reactivates
+// the user's earlier membership; leaving sets LeftAt instead of deleting it.
+type GuildMembershipRepository interface {
+	CreateInvite(ctx context.Context, invite *GuildInvite) error
+	GetInviteByID(ctx context.Context, id uuid.UUID) (*GuildInvite, error)
+	GetInviteByCode(ctx context.Context, code string) (*GuildInvite, error)
+	ListInvitesByGuildID(ctx context.Context, guildID uuid.UUID, opts ListOptions) (*Page[*GuildInvite], error)
+	RevokeInvite(ctx context.Context, id uuid.UUID, at time.Time) error
+	// RedeemInvite uses up one use of the invite and joins userID in one
+	// transaction. It returns ErrInviteUnavailable when the invite cannot be
+	// used anymore and ErrAlreadyMember for active members.
+	RedeemInvite(ctx context.Context, inviteID, userID uuid.UUID, at time.Time) (*GuildAttendee, error)
+
+	CreateJoinRequest(ctx context.Context, request *GuildJoinRequest) error
+	GetJoinRequestByID(ctx context.Context, id uuid.UUID) (*GuildJoinRequest, error)
+	ListJoinRequestsByGuildID(ctx context.Context, guildID uuid.UUID, opts ListOptions) (*Page[*GuildJoinRequest], error)
+	// ResolveJoinRequest approves (joining the user) or rejects a pending
+	// request in one transaction. It returns ErrRequestResolved when the
+	// request is not pending anymore.
+	ResolveJoinRequest(ctx context.Context, request *GuildJoinRequest) (*GuildAttendee, error)
+
+	// Leave sets LeftAt of the active membership. A master cannot leave when
+	// no other active master remains (ErrLastMaster).
+	Leave(ctx context.Context, attendeeID uuid.UUID, at time.Time) error
+	// ChangeRole updates the role of an active membership, keeping at least
+	// one active master (ErrLastMaster).
+	ChangeRole(ctx context.Context, attendeeID uuid.UUID, role GuildAttendeeRole) error
+}
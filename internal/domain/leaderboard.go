@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LeaderboardWindow is the period a leaderboard ranks.
+type LeaderboardWindow string
+
+const (
+	LeaderboardWindowWeek    LeaderboardWindow = "week"
+	LeaderboardWindowMonth   LeaderboardWindow = "month"
+	LeaderboardWindowAllTime LeaderboardWindow = "all"
+)
+
+var ErrInvalidWindow = errors.New("invalid leaderboard window")
+
+// Since returns the start of the window ending at now: the last 7 or 30
+// days, or nil for all time.
+func (w LeaderboardWindow) Since(now time.Time) (*time.Time, error) {
+	var since time.Time
+	switch w {
+	case LeaderboardWindowWeek:
+		since = now.AddDate(0, 0, -7)
+	case LeaderboardWindowMonth:
+		since = now.AddDate(0, 0, -30)
+	case LeaderboardWindowAllTime:
+		return nil, nil
+	default:
+		return nil, ErrInvalidWindow
+	}
+	return &since, nil
+}
+
+// LeaderboardEntry is one ranked user. Points and EventsAttended only count
+// the leaderboard's window. Users with equal points and attendance share a
+// Rank and are listed by UserID.
+type LeaderboardEntry struct {
+	Rank           int       `json:"rank"`
+	UserID         uuid.UUID `gorm:"type:uuid" json:"user_id"`
+	Name           string    `json:"name"`
+	AvatarURL      *string   `json:"avatar_url"`
+	Level          int       `json:"level"`
+	Points         int       `json:"points"`
+	EventsAttended int       `json:"events_attended"`
+	// Position is the 1-based place in the listing, which pages resume from
+	Position int `json:"-"`
+}
+
+type LeaderboardRepository interface {
+	// ListGlobal ranks all users that earned points or attended events in
+	// the window starting at since (all time when nil). Only Limit and Cursor
+	// of opts are supported.
+	ListGlobal(ctx context.Context, since *time.Time, opts ListOptions) (*Page[*LeaderboardEntry], error)
+	// ListByGuildID ranks the current members of the guild like ListGlobal.
+	ListByGuildID(ctx context.Context, guildID uuid.UUID, since *time.Time, opts ListOptions) (*Page[*LeaderboardEntry], error)
+}
@@ -38,6 +38,8 @@ type MistakeRepository interface {
 	GetByID(ctx context.Context, mistakeID uuid.UUID) (*Mistake, error)
 	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*Mistake, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Mistake, error)
+	ListByEventID(ctx context.Context, eventID uuid.UUID, opts ListOptions) (*Page[*Mistake], error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*Mistake], error)
 
 	Create(ctx context.Context, m *Mistake) error
 	Update(ctx context.Context, m *Mistake) error
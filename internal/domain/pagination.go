@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+)
+
+const (
+	// DefaultPageLimit is used when a list request does not specify a limit.
+	DefaultPageLimit = 50
+	// MaxPageLimit caps the number of items a single page may contain.
+	MaxPageLimit = 200
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// ListOptions describes a paginated, sorted and filtered list request.
+//
+// Sort is a whitelisted field name, optionally prefixed with "-" for
+// descending order (e.g. "-created_at"). Cursor is the opaque value returned
+// as NextCursor by the previous page and must be used with the same Sort.
+// Filters maps whitelisted filter names to their raw query values.
+type ListOptions struct {
+	Limit   int
+	Cursor  string
+	Sort    string
+	Filters map[string]string
+}
+
+// Page is a single page of results returned by a paginated list method.
+// NextCursor is empty when there are no more results.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PointReason represents why points were awarded.
+type PointReason string
+
+const (
+	PointReasonAttendedEvent   PointReason = "attended_event"
+	PointReasonEmcee           PointReason = "emcee"
+	PointReasonMistakeReviewed PointReason = "mistake_reviewed"
+	PointReasonStreakBonus     PointReason = "streak_bonus"
+)
+
+// PointTransaction is one entry of the points ledger. User.Points is the sum
+// of the user's entries; a user earns each reason at most once per event.
+// Maps to jpcorrect.point_transaction table.
+type PointTransaction struct {
+	ID        uuid.UUID   `gorm:"type:uuid;primaryKey" json:"point_transaction_id"`
+	UserID    uuid.UUID   `gorm:"type:uuid;uniqueIndex:idx_point_transaction_user_event_reason,priority:1;index:idx_point_transaction_user_created,priority:1" json:"user_id"`
+	EventID   *uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_point_transaction_user_event_reason,priority:2" json:"event_id"`
+	Reason    PointReason `gorm:"uniqueIndex:idx_point_transaction_user_event_reason,priority:3" json:"reason"`
+	Amount    int         `json:"amount"`
+	CreatedAt time.Time   `gorm:"index:idx_point_transaction_user_created,priority:2;index:idx_point_transaction_created_at" json:"created_at"`
+}
+
+// LevelCurve lists the points needed to reach each level in ascending
+// order: reaching curve[i] points means level i+1.
+type LevelCurve []int
+
+// Level returns the level reached with the given points.
+func (c LevelCurve) Level(points int) int {
+	level := 0
+	for _, threshold := range c {
+		if points < threshold {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// ParseLevelCurve parses comma separated, strictly ascending positive
+// thresholds such as "100,300,600".
+func ParseLevelCurve(s string) (LevelCurve, error) {
+	var curve LevelCurve
+	for _, field := range strings.Split(s, ",") {
+		threshold, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid level threshold %q", field)
+		}
+		if threshold <= 0 || (len(curve) > 0 && threshold <= curve[len(curve)-1]) {
+			return nil, fmt.Errorf("level thresholds must be positive and ascending, got %d", threshold)
+		}
+		curve = append(curve, threshold)
+	}
+	return curve, nil
+}
+
+// LevelCurves holds the curves for user levels (from User.Points) and guild
+// levels (from the points members earned while in the guild).
+type LevelCurves struct {
+	User  LevelCurve
+	Guild LevelCurve
+}
+
+type PointRepository interface {
+	// Award appends the entries to the ledger and, in the same transaction,
+	// adds them to the users' Points and recomputes the levels of the users
+	// and their guilds. Entries the ledger already holds are skipped.
+	Award(ctx context.Context, entries []*PointTransaction, curves LevelCurves) error
+	// Recompute rebuilds every user's Points and Level and every guild's
+	// Level from the ledger.
+	Recompute(ctx context.Context, curves LevelCurves) error
+	// HasReason reports whether the user earned reason at any of the events.
+	HasReason(ctx context.Context, userID uuid.UUID, reason PointReason, eventIDs []uuid.UUID) (bool, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*PointTransaction], error)
+}
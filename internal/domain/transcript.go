@@ -27,6 +27,8 @@ type TranscriptRepository interface {
 	GetByID(ctx context.Context, transcriptID uuid.UUID) (*Transcript, error)
 	GetByEventID(ctx context.Context, eventID uuid.UUID) ([]*Transcript, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*Transcript, error)
+	ListByEventID(ctx context.Context, eventID uuid.UUID, opts ListOptions) (*Page[*Transcript], error)
+	ListByUserID(ctx context.Context, userID uuid.UUID, opts ListOptions) (*Page[*Transcript], error)
 
 	Create(ctx context.Context, transcript *Transcript) error
 	Update(ctx context.Context, transcript *Transcript) error
@@ -27,6 +27,7 @@ const (
 )
 
 // User represents a user in the jpcorrect system.
+// LateStreak, Points and Level are maintained by the server.
 // Maps to jpcorrect.user table.
 type User struct {
 	ID              uuid.UUID      `gorm:"type:uuid;primaryKey" json:"user_id"`
@@ -48,10 +49,15 @@ type User struct {
 
 type UserRepository interface {
 	GetByID(ctx context.Context, userID uuid.UUID) (*User, error)
+	// GetDeletedByID returns the user if it was deleted, and ErrNotFound
+	// otherwise.
+	GetDeletedByID(ctx context.Context, userID uuid.UUID) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByName(ctx context.Context, name string) ([]*User, error)
+	ListByName(ctx context.Context, name string, opts ListOptions) (*Page[*User], error)
 
 	Create(ctx context.Context, user *User) error
+	// Update saves the user except LateStreak, Points and Level.
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, userID uuid.UUID) error
 }
@@ -1,16 +1,64 @@
 package domain
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
-// Client represents a connected websocket client
+// Client represents a connected websocket client. ID identifies the
+// connection (a user may hold several); UserID and Name come from the
+// authenticated User.
 type Client struct {
-	ID   string
-	Conn *websocket.Conn
-	Send chan []byte
-	Done chan struct{}
-	Name string
+	ID     string
+	UserID uuid.UUID
+	Name   string
+	Conn   *websocket.Conn
+	Send   chan []byte
+	Done   chan struct{}
+	// Stopped is closed once the writer no longer takes from Send
+	Stopped chan struct{}
+	// Unsent is a message the writer took from Send but could not write.
+	// It goes out first when another connection resumes the client.
+	Unsent []byte
+	// RoomID is set while the client is in a room. It is only written by the
+	// hub under its lock, which may happen on any goroutine (a room closing,
+	// another instance evicting the client), so read it through
+	// WebRTCHub.RoomOf outside the hub.
+	RoomID string
+	// Dropped counts the messages missed in a row because Send was full
+	Dropped atomic.Int32
+	// Chat throttles the chat messages the client sends
+	Chat TokenBucket
+}
+
+// TokenBucket allows bursts of actions while limiting their rate. The zero
+// value is a full bucket.
+type TokenBucket struct {
+	mu     sync.Mutex
+	used   float64
+	filled time.Time
+}
+
+// Take uses a token if one is left in a bucket of burst tokens refilled at
+// rate tokens per second, and reports whether it did.
+func (b *TokenBucket) Take(now time.Time, rate, burst float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.filled.IsZero() {
+		b.used = max(0, b.used-now.Sub(b.filled).Seconds()*rate)
+	}
+	b.filled = now
+	if b.used+1 > burst {
+		return false
+	}
+	b.used++
+	return true
 }
 
 type OnlineUser struct {
@@ -18,10 +66,162 @@ type OnlineUser struct {
 	UserName string `json:"userName"`
 }
 
+// RoomState is what the hub keeps for a room across reconnects: who has
+// the floor, the speaking queue, the turn timer, and who was kicked.
+type RoomState struct {
+	Floor *AgendaSpeaker  `json:"floor"`
+	Queue []AgendaSpeaker `json:"queue"`
+	Timer *RoomTimer      `json:"timer"`
+	// Kicked lists the users the emcee removed, who cannot rejoin until the
+	// session ends
+	Kicked []uuid.UUID `json:"kicked,omitempty"`
+}
+
+// RoomTimer is a countdown of Seconds running until EndsAt.
+type RoomTimer struct {
+	Seconds int       `json:"seconds"`
+	EndsAt  time.Time `json:"endsAt"`
+}
+
+// IsEmpty reports whether there is nothing to replay to a joining client.
+func (s *RoomState) IsEmpty() bool {
+	return s.Floor == nil && len(s.Queue) == 0 && s.Timer == nil && len(s.Kicked) == 0
+}
+
+// Queued reports whether userID is in the speaking queue.
+func (s *RoomState) Queued(userID uuid.UUID) bool {
+	for _, speaker := range s.Queue {
+		if speaker.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RaiseHand puts speaker at the end of the queue unless it is already
+// queued or has the floor.
+func (s *RoomState) RaiseHand(speaker AgendaSpeaker) {
+	if s.Queued(speaker.UserID) || (s.Floor != nil && s.Floor.UserID == speaker.UserID) {
+		return
+	}
+	s.Queue = append(s.Queue, speaker)
+}
+
+// LowerHand removes userID from the queue and reports whether it was in it.
+func (s *RoomState) LowerHand(userID uuid.UUID) bool {
+	for i, speaker := range s.Queue {
+		if speaker.UserID == userID {
+			s.Queue = append(s.Queue[:i:i], s.Queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reorder puts the queue in the order of userIDs, which must list every
+// queued user exactly once.
+func (s *RoomState) Reorder(userIDs []uuid.UUID) bool {
+	if len(userIDs) != len(s.Queue) {
+		return false
+	}
+	byID := make(map[uuid.UUID]AgendaSpeaker, len(s.Queue))
+	for _, speaker := range s.Queue {
+		byID[speaker.UserID] = speaker
+	}
+	queue := make([]AgendaSpeaker, 0, len(userIDs))
+	for _, id := range userIDs {
+		speaker, ok := byID[id]
+		if !ok {
+			return false
+		}
+		delete(byID, id)
+		queue = append(queue, speaker)
+	}
+	s.Queue = queue
+	return true
+}
+
+// Kick keeps userID out of the room, taking it out of the queue and off
+// the floor.
+func (s *RoomState) Kick(userID uuid.UUID) {
+	s.LowerHand(userID)
+	if s.Floor != nil && s.Floor.UserID == userID {
+		s.GiveFloor(nil)
+	}
+	if !s.IsKicked(userID) {
+		s.Kicked = append(s.Kicked, userID)
+	}
+}
+
+// IsKicked reports whether the emcee removed userID from the room.
+func (s *RoomState) IsKicked(userID uuid.UUID) bool {
+	for _, id := range s.Kicked {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// GiveFloor gives speaker the floor, taking it out of the queue. A nil
+// speaker releases the floor.
+func (s *RoomState) GiveFloor(speaker *AgendaSpeaker) {
+	s.Floor = speaker
+	if speaker != nil {
+		s.LowerHand(speaker.UserID)
+	}
+}
+
+// WebRTCHub tracks connected clients and the rooms they joined. A room is
+// keyed by Event.ID; presence and signaling never cross rooms.
 type WebRTCHub interface {
 	AddClient(c *Client)
 	RemoveClient(id string)
 	GetClient(id string) (*Client, bool)
-	ListUsers() []OnlineUser
-	BroadcastExcept(senderID string, msgType string, payload interface{})
+	// RoomOf returns the room c is in, or "" if none.
+	RoomOf(c *Client) string
+	// ResumeClient hands the ID and room of client id over to c, the same
+	// client on a new connection. ok is false when id is not connected.
+	ResumeClient(id string, c *Client) (ok bool)
+
+	// JoinRoom moves the client into roomID and returns the room it was in
+	// before ("" if none). ok is false when the client is not connected.
+	JoinRoom(clientID, roomID string) (prevRoomID string, ok bool)
+	// LeaveRoom removes the client from its room and returns that room.
+	LeaveRoom(clientID string) (roomID string, ok bool)
+	// CloseRoom removes every client from roomID, sends each a message of
+	// msgType and returns them.
+	CloseRoom(roomID, msgType string, payload interface{}) []*Client
+	// GetRoomClient returns a client in roomID. Clients held by another
+	// instance come without a connection; use SendToClient to reach them.
+	GetRoomClient(roomID, clientID string) (*Client, bool)
+	// GetRoomClientByUser returns a connection of userID in roomID.
+	GetRoomClientByUser(roomID string, userID uuid.UUID) (*Client, bool)
+	ListRoomUsers(roomID string) []OnlineUser
+	BroadcastToRoom(roomID, senderID string, msgType string, payload interface{})
+	// SendToClient sends a message to a client in roomID.
+	SendToClient(roomID, clientID, msgType string, payload interface{}) error
+
+	// RoomState returns a copy of the state of roomID. The state outlives
+	// the clients of the room; it is dropped by CloseRoom, or once the room
+	// has stayed empty for a while.
+	RoomState(roomID string) RoomState
+	// UpdateRoomState applies fn to a copy of the state of roomID and keeps
+	// the result unless fn fails. fn runs under the hub's lock and cannot
+	// change the timer.
+	UpdateRoomState(roomID string, fn func(*RoomState) error) (RoomState, error)
+	// StartTimer starts the turn timer of roomID, replacing a running one.
+	// When it runs out the room is sent timer-expired and room-state.
+	StartTimer(roomID string, d time.Duration) RoomState
+	// StopTimer stops the turn timer of roomID.
+	StopTimer(roomID string) RoomState
+}
+
+// HubBackend carries hub traffic between the instances serving the API, so
+// that rooms span all of them.
+type HubBackend interface {
+	// Publish sends msg to every instance subscribed, this one included.
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe calls fn with every message published until ctx ends.
+	Subscribe(ctx context.Context, fn func(msg []byte)) error
 }
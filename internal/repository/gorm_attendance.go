@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormAttendanceRepository struct {
+	db *gorm.DB
+}
+
+var attendanceRecordListSpec = listSpec{
+	sorts: map[string]string{
+		"start_time": "start_time",
+	},
+	defaultSort: "-start_time",
+	filters: map[string]filterSpec{
+		"punctuality":  {cond: "punctuality = ?", parse: parseString},
+		"start_after":  {cond: "start_time >= ?", parse: parseTime},
+		"start_before": {cond: "start_time < ?", parse: parseTime},
+	},
+}
+
+func NewGormAttendanceRepository(db *gorm.DB) domain.AttendanceRepository {
+	return &gormAttendanceRepository{db: db}
+}
+
+func (r *gormAttendanceRepository) Record(ctx context.Context, entry *domain.AttendanceLog) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	// Folded in SQL so concurrent connections of the same user cannot
+	// overwrite an earlier join or a later leave
+	var column string
+	var value interface{}
+	switch entry.Action {
+	case domain.AttendanceActionJoin:
+		column, value = "joined_at", gorm.Expr("COALESCE(joined_at, ?)", entry.At)
+	case domain.AttendanceActionLeave:
+		column, value = "left_at", gorm.Expr("GREATEST(left_at, ?)", entry.At)
+	default:
+		return fmt.Errorf("invalid attendance action %q", entry.Action)
+	}
+
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&domain.EventAttendee{}).
+			Where("event_id = ? AND user_id = ?", entry.EventID, entry.UserID).
+			Update(column, value)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrNotFound
+		}
+		return nil
+	}))
+}
+
+func (r *gormAttendanceRepository) ListByEventID(ctx context.Context, eventID uuid.UUID) ([]*domain.AttendanceLog, error) {
+	var entries []*domain.AttendanceLog
+	err := r.db.WithContext(ctx).
+		Where("event_id = ?", eventID).
+		Order("at, id").
+		Find(&entries).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return entries, nil
+}
+
+func (r *gormAttendanceRepository) ListRecordsByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.AttendanceRecord], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.AttendanceRecord](ctx, query, opts, attendanceRecordListSpec)
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+func TestGormAttendanceRepository_Record(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormAttendanceRepository(db)
+	eventID, userID := uuid.New(), uuid.New()
+	at := time.Date(2025, 1, 7, 19, 3, 0, 0, time.UTC)
+
+	t.Run("JoinKeepsFirst", func(t *testing.T) {
+		entry := &domain.AttendanceLog{EventID: eventID, UserID: userID, Action: domain.AttendanceActionJoin, At: at}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "attendance_log"`)).
+			WithArgs(sqlmock.AnyArg(), eventID, userID, domain.AttendanceActionJoin, at).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee" SET "joined_at"=COALESCE(joined_at, $1) WHERE event_id = $2 AND user_id = $3`)).
+			WithArgs(at, eventID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Record(context.Background(), entry)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, entry.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LeaveKeepsLast", func(t *testing.T) {
+		entry := &domain.AttendanceLog{EventID: eventID, UserID: userID, Action: domain.AttendanceActionLeave, At: at}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "attendance_log"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee" SET "left_at"=GREATEST(left_at, $1) WHERE event_id = $2 AND user_id = $3`)).
+			WithArgs(at, eventID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Record(context.Background(), entry)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotAttendee", func(t *testing.T) {
+		entry := &domain.AttendanceLog{EventID: eventID, UserID: uuid.New(), Action: domain.AttendanceActionJoin, At: at}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "attendance_log"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Record(context.Background(), entry)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		entry := &domain.AttendanceLog{EventID: eventID, UserID: userID, Action: "wave", At: at}
+
+		err := repo.Record(context.Background(), entry)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		entry := &domain.AttendanceLog{EventID: eventID, UserID: userID, Action: domain.AttendanceActionJoin, At: at}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "attendance_log"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Record(context.Background(), entry)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormAttendanceRepository_ListByEventID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormAttendanceRepository(db)
+	eventID, userID := uuid.New(), uuid.New()
+	at := time.Date(2025, 1, 7, 19, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "attendance_log" WHERE event_id = $1 ORDER BY at, id`)).
+			WithArgs(eventID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "user_id", "action", "at"}).
+				AddRow(uuid.New(), eventID, userID, domain.AttendanceActionJoin, at).
+				AddRow(uuid.New(), eventID, userID, domain.AttendanceActionLeave, at.Add(time.Hour)))
+
+		entries, err := repo.ListByEventID(context.Background(), eventID)
+
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, domain.AttendanceActionLeave, entries[1].Action)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "attendance_log" WHERE event_id = $1 ORDER BY at, id`)).
+			WithArgs(eventID).
+			WillReturnError(fmt.Errorf("db error"))
+
+		entries, err := repo.ListByEventID(context.Background(), eventID)
+
+		assert.Error(t, err)
+		assert.Nil(t, entries)
+	})
+}
+
+func TestGormAttendanceRepository_ListRecordsByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormAttendanceRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "attendance_record" WHERE user_id = $1 AND punctuality = $2 ORDER BY "attendance_record"."start_time" DESC, "attendance_record"."id" DESC LIMIT $3`)).
+			WithArgs(userID, "late", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "user_id", "title", "start_time", "punctuality"}).
+				AddRow(uuid.New(), uuid.New(), userID, "Tuesday practice", time.Now(), domain.PunctualityLate))
+
+		page, err := repo.ListRecordsByUserID(context.Background(), userID, domain.ListOptions{
+			Filters: map[string]string{"punctuality": "late"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, domain.PunctualityLate, page.Items[0].Punctuality)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "attendance_record" WHERE user_id = $1`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListRecordsByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -13,6 +14,36 @@ type gormEventRepository struct {
 	db *gorm.DB
 }
 
+var eventListSpec = listSpec{
+	sorts: map[string]string{
+		"start_time": "start_time",
+		"created_at": "created_at",
+		"title":      "title",
+	},
+	defaultSort: "-start_time",
+	filters: map[string]filterSpec{
+		"mode":         {cond: "event.mode = ?", parse: parseString},
+		"start_after":  {cond: "event.start_time >= ?", parse: parseTime},
+		"start_before": {cond: "event.start_time < ?", parse: parseTime},
+		"role":         {cond: "event_attendee.role = ?", parse: parseString},
+	},
+}
+
+// calendarListSpec lists the events of a guild or series, soonest first.
+var calendarListSpec = listSpec{
+	sorts: map[string]string{
+		"start_time": "start_time",
+		"created_at": "created_at",
+		"title":      "title",
+	},
+	defaultSort: "start_time",
+	filters: map[string]filterSpec{
+		"mode":         {cond: "mode = ?", parse: parseString},
+		"start_after":  {cond: "start_time >= ?", parse: parseTime},
+		"start_before": {cond: "start_time < ?", parse: parseTime},
+	},
+}
+
 func NewGormEventRepository(db *gorm.DB) domain.EventRepository {
 	return &gormEventRepository{db: db}
 }
@@ -38,6 +69,48 @@ func (r *gormEventRepository) GetByUserID(ctx context.Context, userID uuid.UUID)
 	return events, nil
 }
 
+func (r *gormEventRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	query := r.db.WithContext(ctx).
+		Joins("JOIN event_attendee ON event_attendee.event_id = event.id").
+		Where("event_attendee.user_id = ?", userID)
+	return paginate[domain.Event](ctx, query, opts, eventListSpec)
+}
+
+func (r *gormEventRepository) ListByGuildID(ctx context.Context, guildID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	return paginate[domain.Event](ctx, query, opts, calendarListSpec)
+}
+
+func (r *gormEventRepository) ListBySeriesID(ctx context.Context, seriesID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Event], error) {
+	query := r.db.WithContext(ctx).Where("series_id = ?", seriesID)
+	return paginate[domain.Event](ctx, query, opts, calendarListSpec)
+}
+
+func (r *gormEventRepository) ListFeedByUserID(ctx context.Context, userID uuid.UUID, since time.Time) ([]*domain.Event, error) {
+	var events []*domain.Event
+	err := r.db.WithContext(ctx).Unscoped().
+		Joins("JOIN event_attendee ON event_attendee.event_id = event.id").
+		Where("event_attendee.user_id = ? AND event.start_time >= ?", userID, since).
+		Order("event.start_time").
+		Find(&events).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return events, nil
+}
+
+func (r *gormEventRepository) ListFeedByGuildID(ctx context.Context, guildID uuid.UUID, since time.Time) ([]*domain.Event, error) {
+	var events []*domain.Event
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("guild_id = ? AND start_time >= ?", guildID, since).
+		Order("start_time").
+		Find(&events).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return events, nil
+}
+
 func (r *gormEventRepository) Create(ctx context.Context, event *domain.Event) error {
 	if event.ID == uuid.Nil {
 		event.ID = uuid.New()
@@ -45,8 +118,84 @@ func (r *gormEventRepository) Create(ctx context.Context, event *domain.Event) e
 	return MapGormError(r.db.WithContext(ctx).Create(event).Error)
 }
 
+func (r *gormEventRepository) CreateWithEmcee(ctx context.Context, event *domain.Event, emceeID uuid.UUID) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.EventAttendee{
+			ID:      uuid.New(),
+			EventID: event.ID,
+			UserID:  emceeID,
+			Role:    domain.EventAttendeeRoleEmcee,
+		}).Error
+	}))
+}
+
 func (r *gormEventRepository) Update(ctx context.Context, event *domain.Event) error {
-	return MapGormError(r.db.WithContext(ctx).Save(event).Error)
+	return MapGormError(r.db.WithContext(ctx).
+		Omit("guild_id", "series_id", "occurrence_start",
+			"status", "started_at", "ended_at", "reviewed_at", "cancelled_at", "actual_duration").
+		Save(event).Error)
+}
+
+func (r *gormEventRepository) Transition(ctx context.Context, event *domain.Event, from domain.EventStatus) error {
+	return MapGormError(transitionEvent(r.db.WithContext(ctx), event, from))
+}
+
+// transitionEvent saves the lifecycle fields of event only if its stored
+// status is still from, so concurrent transitions cannot both succeed.
+func transitionEvent(db *gorm.DB, event *domain.Event, from domain.EventStatus) error {
+	result := db.Model(event).Where("status = ?", from).Updates(map[string]interface{}{
+		"status":          event.Status,
+		"started_at":      event.StartedAt,
+		"ended_at":        event.EndedAt,
+		"reviewed_at":     event.ReviewedAt,
+		"cancelled_at":    event.CancelledAt,
+		"actual_duration": event.ActualDuration,
+		"record_link":     event.RecordLink,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInvalidTransition
+	}
+	return nil
+}
+
+func (r *gormEventRepository) Close(ctx context.Context, event *domain.Event, arrivals []domain.Arrival) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := transitionEvent(tx, event, domain.EventStatusLive); err != nil {
+			return err
+		}
+		for _, arrival := range arrivals {
+			// Only attendees that were not evaluated yet, so closing an
+			// event twice cannot count the same arrival twice
+			result := tx.Model(&domain.EventAttendee{}).
+				Where("event_id = ? AND user_id = ? AND punctuality IS NULL", event.ID, arrival.UserID).
+				Update("punctuality", arrival.Punctuality)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			var streak interface{} = 0
+			if arrival.Punctuality != domain.PunctualityOnTime {
+				streak = gorm.Expr("late_streak + 1")
+			}
+			err := tx.Model(&domain.User{}).Where("id = ?", arrival.UserID).Update("late_streak", streak).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
 }
 
 func (r *gormEventRepository) Delete(ctx context.Context, eventID uuid.UUID) error {
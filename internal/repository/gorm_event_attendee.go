@@ -13,6 +13,16 @@ type gormEventAttendeeRepository struct {
 	db *gorm.DB
 }
 
+var eventAttendeeListSpec = listSpec{
+	sorts: map[string]string{
+		"id": "id",
+	},
+	defaultSort: "id",
+	filters: map[string]filterSpec{
+		"role": {cond: "role = ?", parse: parseString},
+	},
+}
+
 func NewGormEventAttendeeRepository(db *gorm.DB) domain.EventAttendeeRepository {
 	return &gormEventAttendeeRepository{db: db}
 }
@@ -44,6 +54,25 @@ func (r *gormEventAttendeeRepository) GetByUserID(ctx context.Context, userID uu
 	return attendees, nil
 }
 
+func (r *gormEventAttendeeRepository) GetByEventAndUser(ctx context.Context, eventID, userID uuid.UUID) (*domain.EventAttendee, error) {
+	var attendee domain.EventAttendee
+	err := r.db.WithContext(ctx).Where("event_id = ? AND user_id = ?", eventID, userID).First(&attendee).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &attendee, nil
+}
+
+func (r *gormEventAttendeeRepository) ListByEventID(ctx context.Context, eventID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.EventAttendee], error) {
+	query := r.db.WithContext(ctx).Where("event_id = ?", eventID)
+	return paginate[domain.EventAttendee](ctx, query, opts, eventAttendeeListSpec)
+}
+
+func (r *gormEventAttendeeRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.EventAttendee], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.EventAttendee](ctx, query, opts, eventAttendeeListSpec)
+}
+
 func (r *gormEventAttendeeRepository) Create(ctx context.Context, attendee *domain.EventAttendee) error {
 	if attendee.ID == uuid.Nil {
 		attendee.ID = uuid.New()
@@ -57,6 +86,26 @@ func (r *gormEventAttendeeRepository) Update(ctx context.Context, attendee *doma
 	return MapGormError(err)
 }
 
+func (r *gormEventAttendeeRepository) SetSpeakingOrder(ctx context.Context, eventID uuid.UUID, userIDs []uuid.UUID) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.EventAttendee{}).Where("event_id = ?", eventID).Update("speaking_order", nil).Error; err != nil {
+			return err
+		}
+		for i, userID := range userIDs {
+			result := tx.Model(&domain.EventAttendee{}).
+				Where("event_id = ? AND user_id = ?", eventID, userID).
+				Update("speaking_order", i+1)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return domain.ErrNotFound
+			}
+		}
+		return nil
+	}))
+}
+
 func (r *gormEventAttendeeRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	err := r.db.WithContext(ctx).Delete(&domain.EventAttendee{}, "id = ?", id).Error
 	return MapGormError(err)
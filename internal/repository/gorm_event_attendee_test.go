@@ -272,3 +272,117 @@ func TestGormEventAttendeeRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormEventAttendeeRepository_ListByEventID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventAttendeeRepository(db)
+	eventID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_attendee" WHERE event_id = $1 AND role = $2 ORDER BY "event_attendee"."id" ASC LIMIT $3`)).
+			WithArgs(eventID, "emcee", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "role"}).
+				AddRow(uuid.New(), eventID, "emcee"))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{
+			Filters: map[string]string{"role": "emcee"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventAttendeeRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventAttendeeRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_attendee" WHERE user_id = $1 ORDER BY "event_attendee"."id" ASC LIMIT $2`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventAttendeeRepository_GetByEventAndUser(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventAttendeeRepository(db)
+	eventID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_attendee" WHERE event_id = $1 AND user_id = $2 ORDER BY "event_attendee"."id" LIMIT $3`)).
+			WithArgs(eventID, userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "user_id", "role"}).
+				AddRow(uuid.New(), eventID, userID, "emcee"))
+
+		attendee, err := repo.GetByEventAndUser(context.Background(), eventID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.EventAttendeeRoleEmcee, attendee.Role)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_attendee" WHERE event_id = $1 AND user_id = $2`)).
+			WithArgs(eventID, userID, 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		attendee, err := repo.GetByEventAndUser(context.Background(), eventID, userID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, attendee)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventAttendeeRepository_SetSpeakingOrder(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventAttendeeRepository(db)
+	eventID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	clear := regexp.QuoteMeta(`UPDATE "event_attendee" SET "speaking_order"=$1 WHERE event_id = $2`)
+	set := regexp.QuoteMeta(`UPDATE "event_attendee" SET "speaking_order"=$1 WHERE event_id = $2 AND user_id = $3`)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(clear).
+			WithArgs(nil, eventID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(set).
+			WithArgs(1, eventID, first).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(set).
+			WithArgs(2, eventID, second).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.SetSpeakingOrder(context.Background(), eventID, []uuid.UUID{first, second})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotAnAttendee", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(clear).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(set).
+			WithArgs(1, eventID, first).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.SetSpeakingOrder(context.Background(), eventID, []uuid.UUID{first})
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
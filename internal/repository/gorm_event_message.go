@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormEventMessageRepository struct {
+	db *gorm.DB
+}
+
+var eventMessageListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "created_at",
+	filters: map[string]filterSpec{
+		"user_id":        {cond: "user_id = ?", parse: parseUUID},
+		"created_after":  {cond: "created_at >= ?", parse: parseTime},
+		"created_before": {cond: "created_at < ?", parse: parseTime},
+	},
+}
+
+func NewGormEventMessageRepository(db *gorm.DB) domain.EventMessageRepository {
+	return &gormEventMessageRepository{db: db}
+}
+
+// views selects the messages with the names of their authors, named after
+// EventMessageView's table so paginate can qualify its columns.
+func (r *gormEventMessageRepository) views(ctx context.Context) *gorm.DB {
+	views := r.db.Model(&domain.EventMessage{}).
+		Select(`event_message.*, "user".name AS user_name`).
+		Joins(`JOIN "user" ON "user".id = event_message.user_id`)
+	return r.db.WithContext(ctx).Table("(?) AS event_message_view", views)
+}
+
+func (r *gormEventMessageRepository) GetByID(ctx context.Context, messageID uuid.UUID) (*domain.EventMessageView, error) {
+	var message domain.EventMessageView
+	err := r.views(ctx).Where("id = ?", messageID).Take(&message).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &message, nil
+}
+
+func (r *gormEventMessageRepository) ListRecent(ctx context.Context, eventID uuid.UUID, limit int) ([]*domain.EventMessageView, error) {
+	var messages []*domain.EventMessageView
+	err := r.views(ctx).Where("event_id = ?", eventID).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&messages).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+func (r *gormEventMessageRepository) ListByEventID(ctx context.Context, eventID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.EventMessageView], error) {
+	query := r.views(ctx).Where("event_id = ?", eventID)
+	return paginate[domain.EventMessageView](ctx, query, opts, eventMessageListSpec)
+}
+
+func (r *gormEventMessageRepository) Create(ctx context.Context, message *domain.EventMessage) error {
+	if message.ID == uuid.Nil {
+		message.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Create(message).Error)
+}
+
+func (r *gormEventMessageRepository) Update(ctx context.Context, message *domain.EventMessage) error {
+	return MapGormError(r.db.WithContext(ctx).Save(message).Error)
+}
+
+func (r *gormEventMessageRepository) Delete(ctx context.Context, messageID uuid.UUID) error {
+	return MapGormError(r.db.WithContext(ctx).Delete(&domain.EventMessage{}, "id = ?", messageID).Error)
+}
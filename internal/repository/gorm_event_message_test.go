@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+const eventMessageViews = `SELECT * FROM (SELECT event_message.*, "user".name AS user_name FROM "event_message" JOIN "user" ON "user".id = event_message.user_id) AS event_message_view`
+
+func TestGormEventMessageRepository_GetByID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+	messageID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(eventMessageViews+` WHERE id = $1 LIMIT $2`)).
+			WithArgs(messageID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "content", "user_name"}).
+				AddRow(messageID, "よろしく", "Alice"))
+
+		message, err := repo.GetByID(context.Background(), messageID)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, message) {
+			assert.Equal(t, messageID, message.ID)
+			assert.Equal(t, "よろしく", message.Content)
+			assert.Equal(t, "Alice", message.UserName)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(eventMessageViews+` WHERE id = $1`)).
+			WithArgs(messageID, 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		message, err := repo.GetByID(context.Background(), messageID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, message)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventMessageRepository_ListRecent(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+	eventID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	now := time.Now()
+
+	t.Run("OldestFirst", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(eventMessageViews+` WHERE event_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2`)).
+			WithArgs(eventID, 50).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "created_at"}).
+				AddRow(second, eventID, now).
+				AddRow(first, eventID, now.Add(-time.Minute)))
+
+		messages, err := repo.ListRecent(context.Background(), eventID, 50)
+
+		assert.NoError(t, err)
+		if assert.Len(t, messages, 2) {
+			assert.Equal(t, first, messages[0].ID)
+			assert.Equal(t, second, messages[1].ID)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(eventMessageViews)).
+			WithArgs(eventID, 50).
+			WillReturnError(fmt.Errorf("db error"))
+
+		messages, err := repo.ListRecent(context.Background(), eventID, 50)
+
+		assert.Error(t, err)
+		assert.Nil(t, messages)
+	})
+}
+
+func TestGormEventMessageRepository_ListByEventID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+	eventID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(eventMessageViews+` WHERE event_id = $1 AND user_id = $2 ORDER BY "event_message_view"."created_at" ASC, "event_message_view"."id" ASC LIMIT $3`)).
+			WithArgs(eventID, userID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "user_id", "user_name"}).
+				AddRow(uuid.New(), eventID, userID, "Alice"))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Filters: map[string]string{"user_id": userID.String()}})
+
+		assert.NoError(t, err)
+		if assert.Len(t, page.Items, 1) {
+			assert.Equal(t, "Alice", page.Items[0].UserName)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Sort: "content"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidSort)
+		assert.Nil(t, page)
+	})
+}
+
+func TestGormEventMessageRepository_Create(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+
+	t.Run("Success", func(t *testing.T) {
+		message := &domain.EventMessage{EventID: uuid.New(), UserID: uuid.New(), Content: "https://example.com"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event_message"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Create(context.Background(), message)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, message.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event_message"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Create(context.Background(), &domain.EventMessage{Content: "x"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestGormEventMessageRepository_Update(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+	editedAt := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_message"`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Update(context.Background(), &domain.EventMessage{ID: uuid.New(), Content: "edited", EditedAt: &editedAt})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGormEventMessageRepository_Delete(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventMessageRepository(db)
+	messageID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "event_message" WHERE id = $1`)).
+		WithArgs(messageID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(context.Background(), messageID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormEventSeriesRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEventSeriesRepository(db *gorm.DB) domain.EventSeriesRepository {
+	return &gormEventSeriesRepository{db: db}
+}
+
+func (r *gormEventSeriesRepository) GetByID(ctx context.Context, seriesID uuid.UUID) (*domain.EventSeries, error) {
+	var series domain.EventSeries
+	err := r.db.WithContext(ctx).First(&series, "id = ?", seriesID).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &series, nil
+}
+
+func (r *gormEventSeriesRepository) ListDue(ctx context.Context, before time.Time) ([]*domain.EventSeries, error) {
+	var series []*domain.EventSeries
+	err := r.db.WithContext(ctx).
+		Where("materialized_until IS NULL OR materialized_until < ?", before).
+		Find(&series).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return series, nil
+}
+
+func (r *gormEventSeriesRepository) Create(ctx context.Context, series *domain.EventSeries) error {
+	if series.ID == uuid.Nil {
+		series.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Create(series).Error)
+}
+
+func (r *gormEventSeriesRepository) Materialize(ctx context.Context, series *domain.EventSeries, occurrences []*domain.Event, until time.Time) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, occurrence := range occurrences {
+			if occurrence.ID == uuid.Nil {
+				occurrence.ID = uuid.New()
+			}
+			// The unique (series_id, occurrence_start) index also holds
+			// removed occurrences, so they are not created again
+			result := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "series_id"}, {Name: "occurrence_start"}},
+				DoNothing: true,
+			}).Create(occurrence)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			err := tx.Create(&domain.EventAttendee{
+				ID:      uuid.New(),
+				EventID: occurrence.ID,
+				UserID:  series.CreatedBy,
+				Role:    domain.EventAttendeeRoleEmcee,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(series).Update("materialized_until", until).Error
+	}))
+}
+
+func (r *gormEventSeriesRepository) Update(ctx context.Context, series *domain.EventSeries, from time.Time, removed []time.Time) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(series).
+			Select("title", "description", "expected_duration", "mode", "note", "rrule", "exdates", "materialized_until").
+			Updates(series).Error
+		if err != nil {
+			return err
+		}
+
+		upcoming := tx.Model(&domain.Event{}).
+			Where("series_id = ? AND detached = ? AND start_time >= ?", series.ID, false, from).
+			Session(&gorm.Session{})
+		err = upcoming.Updates(map[string]interface{}{
+			"title":             series.Title,
+			"description":       series.Description,
+			"expected_duration": series.ExpectedDuration,
+			"mode":              series.Mode,
+			"note":              series.Note,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+		return upcoming.Where("occurrence_start IN ?", removed).Delete(&domain.Event{}).Error
+	}))
+}
+
+func (r *gormEventSeriesRepository) Delete(ctx context.Context, seriesID uuid.UUID, from time.Time) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("series_id = ? AND detached = ? AND start_time >= ?", seriesID, false, from).
+			Delete(&domain.Event{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&domain.EventSeries{}, "id = ?", seriesID).Error
+	}))
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+func TestGormEventSeriesRepository_GetByID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventSeriesRepository(db)
+	seriesID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_series" WHERE id = $1 AND "event_series"."deleted_at" IS NULL ORDER BY "event_series"."id" LIMIT $2`)).
+			WithArgs(seriesID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rrule", "exdates"}).
+				AddRow(seriesID, "FREQ=WEEKLY", `["2025-05-13T12:00:00Z"]`))
+
+		series, err := repo.GetByID(context.Background(), seriesID)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, series) {
+			assert.Equal(t, "FREQ=WEEKLY", series.RRule)
+			assert.True(t, series.IsExcluded(time.Date(2025, 5, 13, 12, 0, 0, 0, time.UTC)))
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_series"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.GetByID(context.Background(), seriesID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventSeriesRepository_ListDue(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventSeriesRepository(db)
+	before := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_series" WHERE (materialized_until IS NULL OR materialized_until < $1) AND "event_series"."deleted_at" IS NULL`)).
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+
+	due, err := repo.ListDue(context.Background(), before)
+
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGormEventSeriesRepository_Materialize(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventSeriesRepository(db)
+	creatorID := uuid.New()
+	series := &domain.EventSeries{ID: uuid.New(), CreatedBy: creatorID, Title: "Weekly practice"}
+	first := time.Date(2025, 5, 6, 12, 0, 0, 0, time.UTC)
+	until := first.AddDate(0, 0, 14)
+
+	t.Run("Success", func(t *testing.T) {
+		occurrences := []*domain.Event{series.Occurrence(first), series.Occurrence(first.AddDate(0, 0, 7))}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event"`) + `.*` + regexp.QuoteMeta(`ON CONFLICT ("series_id","occurrence_start") DO NOTHING`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event_attendee"`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), creatorID, domain.EventAttendeeRoleEmcee, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		// The second occurrence was removed before
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_series" SET "materialized_until"=$1,"updated_at"=$2 WHERE "event_series"."deleted_at" IS NULL AND "id" = $3`)).
+			WithArgs(until, sqlmock.AnyArg(), series.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Materialize(context.Background(), series, occurrences, until)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, occurrences[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Materialize(context.Background(), series, []*domain.Event{series.Occurrence(first)}, until)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventSeriesRepository_Update(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventSeriesRepository(db)
+	from := time.Now()
+	series := &domain.EventSeries{ID: uuid.New(), Title: "Biweekly practice", Mode: domain.EventModeReport, RRule: "FREQ=WEEKLY;INTERVAL=2"}
+	removed := []time.Time{from.AddDate(0, 0, 1), from.AddDate(0, 0, 15)}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_series" SET "title"=$1,"description"=$2,"expected_duration"=$3,"mode"=$4,"note"=$5,"rrule"=$6,"exdates"=$7,"materialized_until"=$8,"updated_at"=$9 WHERE "event_series"."deleted_at" IS NULL AND "id" = $10`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event" SET "description"=$1,"expected_duration"=$2,"mode"=$3,"note"=$4,"title"=$5,"updated_at"=$6 WHERE (series_id = $7 AND detached = $8 AND start_time >= $9) AND "event"."deleted_at" IS NULL`)).
+			WithArgs(nil, 0.0, domain.EventModeReport, nil, "Biweekly practice", sqlmock.AnyArg(), series.ID, false, from).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event" SET "deleted_at"=$1 WHERE (series_id = $2 AND detached = $3 AND start_time >= $4) AND occurrence_start IN ($5,$6) AND "event"."deleted_at" IS NULL`)).
+			WithArgs(sqlmock.AnyArg(), series.ID, false, from, removed[0], removed[1]).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), series, from, removed)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NothingRemoved", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_series"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event" SET "description"=$1`)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), series, from, nil)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventSeriesRepository_Delete(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventSeriesRepository(db)
+	seriesID := uuid.New()
+	from := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event" SET "deleted_at"=$1 WHERE (series_id = $2 AND detached = $3 AND start_time >= $4) AND "event"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), seriesID, false, from).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_series" SET "deleted_at"=$1 WHERE id = $2 AND "event_series"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), seriesID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Delete(context.Background(), seriesID, from)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -65,7 +66,7 @@ func TestGormEventRepository_GetByUserID(t *testing.T) {
 	userID := uuid.New()
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."guild_id","event"."series_id","event"."occurrence_start","event"."detached","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."slot_minutes","event"."review_of_id","event"."status","event"."started_at","event"."ended_at","event"."reviewed_at","event"."cancelled_at","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
 			WithArgs(userID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
 				AddRow(uuid.New(), "Event 1").
@@ -79,7 +80,7 @@ func TestGormEventRepository_GetByUserID(t *testing.T) {
 	})
 
 	t.Run("EmptyResult", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."guild_id","event"."series_id","event"."occurrence_start","event"."detached","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."slot_minutes","event"."review_of_id","event"."status","event"."started_at","event"."ended_at","event"."reviewed_at","event"."cancelled_at","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
 			WithArgs(userID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
 
@@ -91,7 +92,7 @@ func TestGormEventRepository_GetByUserID(t *testing.T) {
 	})
 
 	t.Run("DBError", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id","event"."guild_id","event"."series_id","event"."occurrence_start","event"."detached","event"."title","event"."description","event"."start_time","event"."expected_duration","event"."actual_duration","event"."record_link","event"."mode","event"."note","event"."slot_minutes","event"."review_of_id","event"."status","event"."started_at","event"."ended_at","event"."reviewed_at","event"."cancelled_at","event"."created_at","event"."updated_at","event"."deleted_at" FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND "event"."deleted_at" IS NULL`)).
 			WithArgs(userID).
 			WillReturnError(fmt.Errorf("db error"))
 
@@ -179,6 +180,26 @@ func TestGormEventRepository_Update(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("KeepsGuildAndSeries", func(t *testing.T) {
+		guildID, seriesID := uuid.New(), uuid.New()
+		event := &domain.Event{
+			ID:       eventID,
+			GuildID:  &guildID,
+			SeriesID: &seriesID,
+			Title:    "Moved Event",
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "event" SET "detached"=\$1,"title"=\$2,.* WHERE "event"."deleted_at" IS NULL AND "id" = \$\d+`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), event)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("DBError", func(t *testing.T) {
 		event := &domain.Event{
 			ID:    eventID,
@@ -284,3 +305,275 @@ func TestGormEventRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormEventRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND event.mode = $2 AND "event"."deleted_at" IS NULL ORDER BY "event"."start_time" DESC, "event"."id" DESC LIMIT $3`)).
+			WithArgs(userID, "review", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+				AddRow(uuid.New(), "Event 1"))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{
+			Filters: map[string]string{"mode": "review"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
+
+func TestGormEventRepository_ListByGuildID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	guildID := uuid.New()
+	after := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event" WHERE guild_id = $1 AND start_time >= $2 AND start_time < $3 AND "event"."deleted_at" IS NULL ORDER BY "event"."start_time" ASC, "event"."id" ASC LIMIT $4`)).
+			WithArgs(guildID, after, before, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "title"}).
+				AddRow(uuid.New(), guildID, "Event 1").
+				AddRow(uuid.New(), guildID, "Event 2"))
+
+		page, err := repo.ListByGuildID(context.Background(), guildID, domain.ListOptions{
+			Filters: map[string]string{
+				"start_after":  after.Format(time.RFC3339),
+				"start_before": before.Format(time.RFC3339),
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.Equal(t, guildID, *page.Items[0].GuildID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		page, err := repo.ListByGuildID(context.Background(), guildID, domain.ListOptions{
+			Filters: map[string]string{"role": "emcee"},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		assert.Nil(t, page)
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event" WHERE guild_id = $1`)).
+			WithArgs(guildID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByGuildID(context.Background(), guildID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
+
+func TestGormEventRepository_ListFeed(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cancelledAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("ByUserIncludesCancelled", func(t *testing.T) {
+		userID := uuid.New()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "event"."id",`)+`.*`+regexp.QuoteMeta(`FROM "event" JOIN event_attendee ON event_attendee.event_id = event.id WHERE event_attendee.user_id = $1 AND event.start_time >= $2 ORDER BY event.start_time`)).
+			WithArgs(userID, since).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "deleted_at"}).
+				AddRow(uuid.New(), "Event 1", nil).
+				AddRow(uuid.New(), "Event 2", cancelledAt))
+
+		events, err := repo.ListFeedByUserID(context.Background(), userID, since)
+
+		assert.NoError(t, err)
+		if assert.Len(t, events, 2) {
+			assert.True(t, events[1].DeletedAt.Valid)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ByGuild", func(t *testing.T) {
+		guildID := uuid.New()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event" WHERE guild_id = $1 AND start_time >= $2 ORDER BY start_time`)).
+			WithArgs(guildID, since).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id"}).AddRow(uuid.New(), guildID))
+
+		events, err := repo.ListFeedByGuildID(context.Background(), guildID, since)
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event" WHERE guild_id = $1`)).
+			WillReturnError(fmt.Errorf("db error"))
+
+		events, err := repo.ListFeedByGuildID(context.Background(), uuid.New(), since)
+
+		assert.Error(t, err)
+		assert.Nil(t, events)
+	})
+}
+
+func TestGormEventRepository_CreateWithEmcee(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	emceeID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		event := &domain.Event{Title: "New Event"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event_attendee"`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), emceeID, domain.EventAttendeeRoleEmcee, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.CreateWithEmcee(context.Background(), event, emceeID)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, event.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AttendeeInsertFails", func(t *testing.T) {
+		event := &domain.Event{Title: "New Event"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "event_attendee"`)).
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+		mock.ExpectRollback()
+
+		err := repo.CreateWithEmcee(context.Background(), event, emceeID)
+
+		assert.ErrorIs(t, err, domain.ErrHasRelatedRecords)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventRepository_Close(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	duration := 45.0
+	event := &domain.Event{ID: uuid.New(), Title: "Closing Event", ActualDuration: &duration}
+	onTime, late, evaluated := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee" SET "punctuality"=$1 WHERE event_id = $2 AND user_id = $3 AND punctuality IS NULL`)).
+			WithArgs(domain.PunctualityOnTime, event.ID, onTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "late_streak"=$1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(0, sqlmock.AnyArg(), onTime).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee" SET "punctuality"=$1 WHERE event_id = $2 AND user_id = $3 AND punctuality IS NULL`)).
+			WithArgs(domain.PunctualityLate, event.ID, late).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "late_streak"=late_streak + 1,"updated_at"=$1 WHERE id = $2`)).
+			WithArgs(sqlmock.AnyArg(), late).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// Already evaluated: the streak is not touched again
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee" SET "punctuality"=$1 WHERE event_id = $2 AND user_id = $3 AND punctuality IS NULL`)).
+			WithArgs(domain.PunctualityAbsent, event.ID, evaluated).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Close(context.Background(), event, []domain.Arrival{
+			{UserID: onTime, Punctuality: domain.PunctualityOnTime},
+			{UserID: late, Punctuality: domain.PunctualityLate},
+			{UserID: evaluated, Punctuality: domain.PunctualityAbsent},
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StreakUpdateFails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_attendee"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Close(context.Background(), event, []domain.Arrival{
+			{UserID: late, Punctuality: domain.PunctualityLate},
+		})
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotLive", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Close(context.Background(), event, []domain.Arrival{
+			{UserID: late, Punctuality: domain.PunctualityLate},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormEventRepository_Transition(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormEventRepository(db)
+	startedAt := time.Date(2025, 3, 4, 11, 0, 0, 0, time.UTC)
+	event := &domain.Event{ID: uuid.New(), Status: domain.EventStatusLive, StartedAt: &startedAt}
+	query := regexp.QuoteMeta(`UPDATE "event" SET "actual_duration"=$1,"cancelled_at"=$2,"ended_at"=$3,"record_link"=$4,"reviewed_at"=$5,"started_at"=$6,"status"=$7,"updated_at"=$8 WHERE status = $9 AND "event"."deleted_at" IS NULL AND "id" = $10`)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WithArgs(nil, nil, nil, nil, nil, startedAt, domain.EventStatusLive, sqlmock.AnyArg(), domain.EventStatusScheduled, event.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Transition(context.Background(), event, domain.EventStatusScheduled)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyTransitioned", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(query).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Transition(context.Background(), event, domain.EventStatusScheduled)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
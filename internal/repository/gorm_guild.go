@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -13,6 +14,44 @@ type gormGuildRepository struct {
 	db *gorm.DB
 }
 
+var guildListSpec = listSpec{
+	sorts: map[string]string{
+		"activity":     "activity",
+		"member_count": "member_count",
+		"level":        "level",
+		"created_at":   "created_at",
+		"name":         "name",
+	},
+	defaultSort: "-activity",
+	filters: map[string]filterSpec{
+		"q":           {cond: guildSearchText + " ILIKE ?", parse: parseContains},
+		"min_level":   {cond: "level >= ?", parse: parseInt},
+		"max_level":   {cond: "level <= ?", parse: parseInt},
+		"min_members": {cond: "member_count >= ?", parse: parseInt},
+		"max_members": {cond: "member_count <= ?", parse: parseInt},
+	},
+}
+
+// guildSearchText is the text guild search matches. It must stay identical
+// to the expression of idx_guild_search for the index to be used.
+const guildSearchText = `(COALESCE(name, '') || ' ' || COALESCE(description, ''))`
+
+// guildMemberCounts counts the current members per guild.
+const guildMemberCounts = `SELECT guild_id, COUNT(*) AS count
+FROM guild_attendee
+WHERE left_at IS NULL
+GROUP BY guild_id`
+
+// guildRecentActivity sums per guild the points its current members earned
+// since joining and since the placeholder time, like guildActivity.
+const guildRecentActivity = `SELECT guild_attendee.guild_id, SUM(point_transaction.amount) AS points
+FROM guild_attendee
+JOIN point_transaction ON point_transaction.user_id = guild_attendee.user_id
+WHERE guild_attendee.left_at IS NULL
+AND (guild_attendee.joined_at IS NULL OR point_transaction.created_at >= guild_attendee.joined_at)
+AND point_transaction.created_at >= ?
+GROUP BY guild_attendee.guild_id`
+
 func NewGormGuildRepository(db *gorm.DB) domain.GuildRepository {
 	return &gormGuildRepository{db: db}
 }
@@ -26,6 +65,17 @@ func (r *gormGuildRepository) GetByID(ctx context.Context, guildID uuid.UUID) (*
 	return &guild, nil
 }
 
+func (r *gormGuildRepository) List(ctx context.Context, opts domain.ListOptions) (*domain.Page[*domain.GuildSummary], error) {
+	since := time.Now().Add(-domain.GuildActivityWindow)
+	summaries := r.db.Model(&domain.Guild{}).
+		Select("guild.*, COALESCE(members.count, 0) AS member_count, COALESCE(activity.points, 0) AS activity").
+		Joins("LEFT JOIN ("+guildMemberCounts+") AS members ON members.guild_id = guild.id").
+		Joins("LEFT JOIN ("+guildRecentActivity+") AS activity ON activity.guild_id = guild.id", since)
+	// Named after GuildSummary's table so paginate can qualify its columns
+	query := r.db.WithContext(ctx).Table("(?) AS guild_summary", summaries)
+	return paginate[domain.GuildSummary](ctx, query, opts, guildListSpec)
+}
+
 func (r *gormGuildRepository) Create(ctx context.Context, guild *domain.Guild) error {
 	if guild.ID == uuid.Nil {
 		guild.ID = uuid.New()
@@ -33,8 +83,27 @@ func (r *gormGuildRepository) Create(ctx context.Context, guild *domain.Guild) e
 	return MapGormError(r.db.WithContext(ctx).Create(guild).Error)
 }
 
+func (r *gormGuildRepository) CreateWithMaster(ctx context.Context, guild *domain.Guild, masterID uuid.UUID) error {
+	if guild.ID == uuid.Nil {
+		guild.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(guild).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Create(&domain.GuildAttendee{
+			ID:       uuid.New(),
+			GuildID:  guild.ID,
+			UserID:   masterID,
+			Role:     domain.GuildAttendeeRoleMaster,
+			JoinedAt: &now,
+		}).Error
+	}))
+}
+
 func (r *gormGuildRepository) Update(ctx context.Context, guild *domain.Guild) error {
-	return MapGormError(r.db.WithContext(ctx).Save(guild).Error)
+	return MapGormError(r.db.WithContext(ctx).Omit("level").Save(guild).Error)
 }
 
 func (r *gormGuildRepository) Delete(ctx context.Context, guildID uuid.UUID) error {
@@ -47,6 +116,14 @@ func (r *gormGuildRepository) Delete(ctx context.Context, guildID uuid.UUID) err
 		if attendeeCount > 0 {
 			return domain.ErrHasRelatedRecords
 		}
+		var eventCount int64
+		err = tx.Model(&domain.Event{}).Where("guild_id = ?", guildID).Count(&eventCount).Error
+		if err != nil {
+			return MapGormError(err)
+		}
+		if eventCount > 0 {
+			return domain.ErrHasRelatedRecords
+		}
 		return MapGormError(tx.Delete(&domain.Guild{}, "id = ?", guildID).Error)
 	})
 }
@@ -55,6 +132,16 @@ type gormGuildAttendeeRepository struct {
 	db *gorm.DB
 }
 
+var guildAttendeeListSpec = listSpec{
+	sorts: map[string]string{
+		"id": "id",
+	},
+	defaultSort: "id",
+	filters: map[string]filterSpec{
+		"role": {cond: "role = ?", parse: parseString},
+	},
+}
+
 func NewGormGuildAttendeeRepository(db *gorm.DB) domain.GuildAttendeeRepository {
 	return &gormGuildAttendeeRepository{db: db}
 }
@@ -86,6 +173,25 @@ func (r *gormGuildAttendeeRepository) GetByUserID(ctx context.Context, userID uu
 	return attendees, nil
 }
 
+func (r *gormGuildAttendeeRepository) GetByGuildAndUser(ctx context.Context, guildID, userID uuid.UUID) (*domain.GuildAttendee, error) {
+	var attendee domain.GuildAttendee
+	err := r.db.WithContext(ctx).Where("guild_id = ? AND user_id = ?", guildID, userID).First(&attendee).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &attendee, nil
+}
+
+func (r *gormGuildAttendeeRepository) ListByGuildID(ctx context.Context, guildID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.GuildAttendee], error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	return paginate[domain.GuildAttendee](ctx, query, opts, guildAttendeeListSpec)
+}
+
+func (r *gormGuildAttendeeRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.GuildAttendee], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.GuildAttendee](ctx, query, opts, guildAttendeeListSpec)
+}
+
 func (r *gormGuildAttendeeRepository) Create(ctx context.Context, attendee *domain.GuildAttendee) error {
 	if attendee.ID == uuid.Nil {
 		attendee.ID = uuid.New()
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormGuildMembershipRepository struct {
+	db *gorm.DB
+}
+
+var guildInviteListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "-created_at",
+}
+
+var guildJoinRequestListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "created_at",
+	filters: map[string]filterSpec{
+		"status": {cond: "status = ?", parse: parseString},
+	},
+}
+
+func NewGormGuildMembershipRepository(db *gorm.DB) domain.GuildMembershipRepository {
+	return &gormGuildMembershipRepository{db: db}
+}
+
+func (r *gormGuildMembershipRepository) CreateInvite(ctx context.Context, invite *domain.GuildInvite) error {
+	if invite.ID == uuid.Nil {
+		invite.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Create(invite).Error)
+}
+
+func (r *gormGuildMembershipRepository) GetInviteByID(ctx context.Context, id uuid.UUID) (*domain.GuildInvite, error) {
+	var invite domain.GuildInvite
+	err := r.db.WithContext(ctx).First(&invite, "id = ?", id).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &invite, nil
+}
+
+func (r *gormGuildMembershipRepository) GetInviteByCode(ctx context.Context, code string) (*domain.GuildInvite, error) {
+	var invite domain.GuildInvite
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&invite).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &invite, nil
+}
+
+func (r *gormGuildMembershipRepository) ListInvitesByGuildID(ctx context.Context, guildID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.GuildInvite], error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	return paginate[domain.GuildInvite](ctx, query, opts, guildInviteListSpec)
+}
+
+func (r *gormGuildMembershipRepository) RevokeInvite(ctx context.Context, id uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&domain.GuildInvite{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return MapGormError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormGuildMembershipRepository) RedeemInvite(ctx context.Context, inviteID, userID uuid.UUID, at time.Time) (*domain.GuildAttendee, error) {
+	var attendee *domain.GuildAttendee
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Checked and counted in one statement, so concurrent redemptions
+		// cannot exceed MaxUses
+		result := tx.Model(&domain.GuildInvite{}).
+			Where("id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?) AND (max_uses IS NULL OR uses < max_uses)", inviteID, at).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrInviteUnavailable
+		}
+
+		var invite domain.GuildInvite
+		if err := tx.First(&invite, "id = ?", inviteID).Error; err != nil {
+			return err
+		}
+		var err error
+		attendee, err = joinGuild(tx, invite.GuildID, userID, at)
+		return err
+	})
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return attendee, nil
+}
+
+func (r *gormGuildMembershipRepository) CreateJoinRequest(ctx context.Context, request *domain.GuildJoinRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+	return MapGormError(r.db.WithContext(ctx).Create(request).Error)
+}
+
+func (r *gormGuildMembershipRepository) GetJoinRequestByID(ctx context.Context, id uuid.UUID) (*domain.GuildJoinRequest, error) {
+	var request domain.GuildJoinRequest
+	err := r.db.WithContext(ctx).First(&request, "id = ?", id).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &request, nil
+}
+
+func (r *gormGuildMembershipRepository) ListJoinRequestsByGuildID(ctx context.Context, guildID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.GuildJoinRequest], error) {
+	query := r.db.WithContext(ctx).Where("guild_id = ?", guildID)
+	return paginate[domain.GuildJoinRequest](ctx, query, opts, guildJoinRequestListSpec)
+}
+
+func (r *gormGuildMembershipRepository) ResolveJoinRequest(ctx context.Context, request *domain.GuildJoinRequest) (*domain.GuildAttendee, error) {
+	var attendee *domain.GuildAttendee
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only a pending request can be resolved, and only once
+		result := tx.Model(&domain.GuildJoinRequest{}).
+			Where("id = ? AND status = ?", request.ID, domain.GuildJoinRequestStatusPending).
+			Updates(map[string]interface{}{
+				"status":      request.Status,
+				"reviewed_by": request.ReviewedBy,
+				"reviewed_at": request.ReviewedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrRequestResolved
+		}
+		if request.Status != domain.GuildJoinRequestStatusApproved {
+			return nil
+		}
+		var err error
+		attendee, err = joinGuild(tx, request.GuildID, request.UserID, *request.ReviewedAt)
+		return err
+	})
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return attendee, nil
+}
+
+func (r *gormGuildMembershipRepository) Leave(ctx context.Context, attendeeID uuid.UUID, at time.Time) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attendee, err := lockActiveAttendee(tx, attendeeID)
+		if err != nil {
+			return err
+		}
+		if attendee.Role == domain.GuildAttendeeRoleMaster {
+			if err := ensureOtherMaster(tx, attendee); err != nil {
+				return err
+			}
+		}
+		return tx.Model(attendee).Update("left_at", at).Error
+	}))
+}
+
+func (r *gormGuildMembershipRepository) ChangeRole(ctx context.Context, attendeeID uuid.UUID, role domain.GuildAttendeeRole) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		attendee, err := lockActiveAttendee(tx, attendeeID)
+		if err != nil {
+			return err
+		}
+		if attendee.Role == domain.GuildAttendeeRoleMaster && role != domain.GuildAttendeeRoleMaster {
+			if err := ensureOtherMaster(tx, attendee); err != nil {
+				return err
+			}
+		}
+		return tx.Model(attendee).Update("role", role).Error
+	}))
+}
+
+// joinGuild adds userID to the guild as a member. An earlier membership is
+// reactivated with a new JoinedAt, so points earned while away do not count
+// towards the guild.
+func joinGuild(tx *gorm.DB, guildID, userID uuid.UUID, at time.Time) (*domain.GuildAttendee, error) {
+	var attendee domain.GuildAttendee
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("guild_id = ? AND user_id = ?", guildID, userID).
+		First(&attendee).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		attendee = domain.GuildAttendee{
+			ID:       uuid.New(),
+			GuildID:  guildID,
+			UserID:   userID,
+			Role:     domain.GuildAttendeeRoleMember,
+			JoinedAt: &at,
+		}
+		if err := tx.Create(&attendee).Error; err != nil {
+			return nil, err
+		}
+		return &attendee, nil
+	case err != nil:
+		return nil, err
+	case attendee.LeftAt == nil:
+		return nil, domain.ErrAlreadyMember
+	}
+
+	attendee.Role = domain.GuildAttendeeRoleMember
+	attendee.JoinedAt = &at
+	attendee.LeftAt = nil
+	err = tx.Model(&attendee).Updates(map[string]interface{}{
+		"role":      attendee.Role,
+		"joined_at": attendee.JoinedAt,
+		"left_at":   nil,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attendee, nil
+}
+
+// lockActiveAttendee loads the active membership after locking its guild
+// row, which serializes membership changes within a guild so two masters
+// cannot step down at the same time.
+func lockActiveAttendee(tx *gorm.DB, attendeeID uuid.UUID) (*domain.GuildAttendee, error) {
+	var ref domain.GuildAttendee
+	if err := tx.Select("guild_id").Where("id = ?", attendeeID).Take(&ref).Error; err != nil {
+		return nil, err
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&domain.Guild{}, "id = ?", ref.GuildID).Error
+	if err != nil {
+		return nil, err
+	}
+	var attendee domain.GuildAttendee
+	if err := tx.Where("id = ? AND left_at IS NULL", attendeeID).First(&attendee).Error; err != nil {
+		return nil, err
+	}
+	return &attendee, nil
+}
+
+func ensureOtherMaster(tx *gorm.DB, attendee *domain.GuildAttendee) error {
+	var count int64
+	err := tx.Model(&domain.GuildAttendee{}).
+		Where("guild_id = ? AND id <> ? AND role = ? AND left_at IS NULL", attendee.GuildID, attendee.ID, domain.GuildAttendeeRoleMaster).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrLastMaster
+	}
+	return nil
+}
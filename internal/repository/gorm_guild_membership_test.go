@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+func TestGormGuildMembershipRepository_RevokeInvite(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildMembershipRepository(db)
+	inviteID := uuid.New()
+	at := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "revoked_at"=$1 WHERE id = $2 AND revoked_at IS NULL`)).
+			WithArgs(at, inviteID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.RevokeInvite(context.Background(), inviteID, at)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyRevoked", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "revoked_at"=$1`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.RevokeInvite(context.Background(), inviteID, at)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildMembershipRepository_RedeemInvite(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildMembershipRepository(db)
+	inviteID, guildID, userID := uuid.New(), uuid.New(), uuid.New()
+	at := time.Now()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "uses"=uses + 1 WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2) AND (max_uses IS NULL OR uses < max_uses)`)).
+			WithArgs(inviteID, at).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_invite" WHERE id = $1`)).
+			WithArgs(inviteID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "uses"}).AddRow(inviteID, guildID, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 AND user_id = $2`)).
+			WithArgs(guildID, userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "guild_attendee"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		attendee, err := repo.RedeemInvite(context.Background(), inviteID, userID, at)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, attendee) {
+			assert.Equal(t, guildID, attendee.GuildID)
+			assert.Equal(t, domain.GuildAttendeeRoleMember, attendee.Role)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rejoin", func(t *testing.T) {
+		attendeeID := uuid.New()
+		left := at.Add(-time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "uses"=uses + 1`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_invite"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id"}).AddRow(inviteID, guildID))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 AND user_id = $2`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "user_id", "role", "left_at"}).
+				AddRow(attendeeID, guildID, userID, domain.GuildAttendeeRoleMaster, left))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_attendee" SET "joined_at"=$1,"left_at"=$2,"role"=$3 WHERE "id" = $4`)).
+			WithArgs(at, nil, domain.GuildAttendeeRoleMember, attendeeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		attendee, err := repo.RedeemInvite(context.Background(), inviteID, userID, at)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, attendee) {
+			assert.Nil(t, attendee.LeftAt)
+			assert.Equal(t, domain.GuildAttendeeRoleMember, attendee.Role)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "uses"=uses + 1`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.RedeemInvite(context.Background(), inviteID, userID, at)
+
+		assert.ErrorIs(t, err, domain.ErrInviteUnavailable)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyMember", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_invite" SET "uses"=uses + 1`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_invite"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id"}).AddRow(inviteID, guildID))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "user_id", "role"}).
+				AddRow(uuid.New(), guildID, userID, domain.GuildAttendeeRoleMember))
+		mock.ExpectRollback()
+
+		_, err := repo.RedeemInvite(context.Background(), inviteID, userID, at)
+
+		// Rolled back, so the use is not counted
+		assert.ErrorIs(t, err, domain.ErrAlreadyMember)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildMembershipRepository_ResolveJoinRequest(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildMembershipRepository(db)
+	reviewerID := uuid.New()
+	at := time.Now()
+	request := func(status domain.GuildJoinRequestStatus) *domain.GuildJoinRequest {
+		return &domain.GuildJoinRequest{ID: uuid.New(), GuildID: uuid.New(), UserID: uuid.New(), Status: status, ReviewedBy: &reviewerID, ReviewedAt: &at}
+	}
+
+	t.Run("Rejected", func(t *testing.T) {
+		req := request(domain.GuildJoinRequestStatusRejected)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_join_request" SET "reviewed_at"=$1,"reviewed_by"=$2,"status"=$3,"updated_at"=$4 WHERE id = $5 AND status = $6`)).
+			WithArgs(&at, &reviewerID, domain.GuildJoinRequestStatusRejected, sqlmock.AnyArg(), req.ID, domain.GuildJoinRequestStatusPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		attendee, err := repo.ResolveJoinRequest(context.Background(), req)
+
+		assert.NoError(t, err)
+		assert.Nil(t, attendee)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Approved", func(t *testing.T) {
+		req := request(domain.GuildJoinRequestStatusApproved)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_join_request"`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 AND user_id = $2`)).
+			WithArgs(req.GuildID, req.UserID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "guild_attendee"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		attendee, err := repo.ResolveJoinRequest(context.Background(), req)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, attendee) {
+			assert.Equal(t, req.UserID, attendee.UserID)
+			assert.Equal(t, &at, attendee.JoinedAt)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyResolved", func(t *testing.T) {
+		req := request(domain.GuildJoinRequestStatusApproved)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_join_request"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.ResolveJoinRequest(context.Background(), req)
+
+		assert.ErrorIs(t, err, domain.ErrRequestResolved)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildMembershipRepository_Leave(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildMembershipRepository(db)
+	attendeeID, guildID := uuid.New(), uuid.New()
+	at := time.Now()
+
+	expectLock := func(role domain.GuildAttendeeRole) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "guild_id" FROM "guild_attendee" WHERE id = $1 LIMIT $2`)).
+			WithArgs(attendeeID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"guild_id"}).AddRow(guildID))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "guild" WHERE id = $1 AND "guild"."deleted_at" IS NULL ORDER BY "guild"."id" LIMIT $2 FOR UPDATE`)).
+			WithArgs(guildID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(guildID))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE id = $1 AND left_at IS NULL`)).
+			WithArgs(attendeeID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "role"}).AddRow(attendeeID, guildID, role))
+	}
+
+	t.Run("Member", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(domain.GuildAttendeeRoleMember)
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_attendee" SET "left_at"=$1 WHERE "id" = $2`)).
+			WithArgs(at, attendeeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Leave(context.Background(), attendeeID, at)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LastMaster", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock(domain.GuildAttendeeRoleMaster)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "guild_attendee" WHERE guild_id = $1 AND id <> $2 AND role = $3 AND left_at IS NULL`)).
+			WithArgs(guildID, attendeeID, domain.GuildAttendeeRoleMaster).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := repo.Leave(context.Background(), attendeeID, at)
+
+		assert.ErrorIs(t, err, domain.ErrLastMaster)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "guild_id" FROM "guild_attendee"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"guild_id"}))
+		mock.ExpectRollback()
+
+		err := repo.Leave(context.Background(), attendeeID, at)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "guild_id" FROM "guild_attendee"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Leave(context.Background(), attendeeID, at)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildMembershipRepository_ChangeRole(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildMembershipRepository(db)
+	attendeeID, guildID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "guild_id" FROM "guild_attendee"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"guild_id"}).AddRow(guildID))
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(guildID))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE id = $1 AND left_at IS NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "role"}).AddRow(attendeeID, guildID, domain.GuildAttendeeRoleMaster))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "guild_attendee"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild_attendee" SET "role"=$1 WHERE "id" = $2`)).
+		WithArgs(domain.GuildAttendeeRoleMember, attendeeID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.ChangeRole(context.Background(), attendeeID, domain.GuildAttendeeRoleMember)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
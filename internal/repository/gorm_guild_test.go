@@ -138,6 +138,20 @@ func TestGormGuildRepository_Update(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("KeepsLevel", func(t *testing.T) {
+		guild := &domain.Guild{ID: guildID, Name: "Updated Guild", Level: 99}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "guild" SET .*"avatar_url"=\$\d+,"created_at"=`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), guild)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("DBError", func(t *testing.T) {
 		guild := &domain.Guild{
 			ID:          guildID,
@@ -166,6 +180,9 @@ func TestGormGuildRepository_Delete(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "guild_attendee" WHERE guild_id = $1`)).
 			WithArgs(guildID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "event" WHERE guild_id = $1 AND "event"."deleted_at" IS NULL`)).
+			WithArgs(guildID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild" SET "deleted_at"=$1 WHERE id = $2 AND "guild"."deleted_at" IS NULL`)).
 			WithArgs(sqlmock.AnyArg(), guildID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
@@ -190,6 +207,22 @@ func TestGormGuildRepository_Delete(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("HasEvents", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "guild_attendee" WHERE guild_id = $1`)).
+			WithArgs(guildID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "event" WHERE guild_id = $1 AND "event"."deleted_at" IS NULL`)).
+			WithArgs(guildID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		err := repo.Delete(context.Background(), guildID)
+
+		assert.ErrorIs(t, err, domain.ErrHasRelatedRecords)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("DBError", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "guild_attendee" WHERE guild_id = $1`)).
@@ -460,3 +493,155 @@ func TestGormGuildAttendeeRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormGuildAttendeeRepository_ListByGuildID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildAttendeeRepository(db)
+	guildID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 ORDER BY "guild_attendee"."id" ASC LIMIT $2`)).
+			WithArgs(guildID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id"}).
+				AddRow(uuid.New(), guildID))
+
+		page, err := repo.ListByGuildID(context.Background(), guildID, domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1`)).
+			WithArgs(guildID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByGuildID(context.Background(), guildID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
+
+func TestGormGuildAttendeeRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildAttendeeRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE user_id = $1 ORDER BY "guild_attendee"."id" ASC LIMIT $2`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildAttendeeRepository_GetByGuildAndUser(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildAttendeeRepository(db)
+	guildID := uuid.New()
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 AND user_id = $2 ORDER BY "guild_attendee"."id" LIMIT $3`)).
+			WithArgs(guildID, userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "guild_id", "user_id", "role"}).
+				AddRow(uuid.New(), guildID, userID, "master"))
+
+		attendee, err := repo.GetByGuildAndUser(context.Background(), guildID, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.GuildAttendeeRoleMaster, attendee.Role)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "guild_attendee" WHERE guild_id = $1 AND user_id = $2`)).
+			WithArgs(guildID, userID, 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		attendee, err := repo.GetByGuildAndUser(context.Background(), guildID, userID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, attendee)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildRepository_CreateWithMaster(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildRepository(db)
+	masterID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		guild := &domain.Guild{Name: "New Guild"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "guild"`)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "guild_attendee"`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), masterID, domain.GuildAttendeeRoleMaster, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.CreateWithMaster(context.Background(), guild, masterID)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, guild.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		guild := &domain.Guild{Name: "New Guild"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "guild"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.CreateWithMaster(context.Background(), guild, masterID)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormGuildRepository_List(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormGuildRepository(db)
+
+	t.Run("Success", func(t *testing.T) {
+		guildID := uuid.New()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM (SELECT guild.*, COALESCE(members.count, 0) AS member_count, COALESCE(activity.points, 0) AS activity FROM "guild" LEFT JOIN (SELECT guild_id, COUNT(*) AS count`)).
+			WithArgs(sqlmock.AnyArg(), 2, 5, "%日本\\_語%", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "level", "member_count", "activity"}).
+				AddRow(guildID, "日本_語", 3, 12, 240))
+
+		page, err := repo.List(context.Background(), domain.ListOptions{Filters: map[string]string{
+			"q":           "日本_語",
+			"min_level":   "2",
+			"min_members": "5",
+		}})
+
+		assert.NoError(t, err)
+		if assert.Len(t, page.Items, 1) {
+			assert.Equal(t, guildID, page.Items[0].ID)
+			assert.Equal(t, 12, page.Items[0].MemberCount)
+			assert.Equal(t, 240, page.Items[0].Activity)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		page, err := repo.List(context.Background(), domain.ListOptions{Filters: map[string]string{"min_level": "high"}})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		assert.Nil(t, page)
+	})
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormLeaderboardRepository struct {
+	db *gorm.DB
+}
+
+func NewGormLeaderboardRepository(db *gorm.DB) domain.LeaderboardRepository {
+	return &gormLeaderboardRepository{db: db}
+}
+
+func (r *gormLeaderboardRepository) ListGlobal(ctx context.Context, since *time.Time, opts domain.ListOptions) (*domain.Page[*domain.LeaderboardEntry], error) {
+	return r.list(ctx, nil, since, opts)
+}
+
+func (r *gormLeaderboardRepository) ListByGuildID(ctx context.Context, guildID uuid.UUID, since *time.Time, opts domain.ListOptions) (*domain.Page[*domain.LeaderboardEntry], error) {
+	return r.list(ctx, &guildID, since, opts)
+}
+
+// leaderboardSort names the fixed order of leaderboard cursors
+const leaderboardSort = "position"
+
+// list ranks users in a single query: points and attendance are aggregated
+// once per user, RANK() gives tied users the same rank and ROW_NUMBER()
+// breaks ties by user ID for a stable order that the cursor resumes from.
+func (r *gormLeaderboardRepository) list(ctx context.Context, guildID *uuid.UUID, since *time.Time, opts domain.ListOptions) (*domain.Page[*domain.LeaderboardEntry], error) {
+	if opts.Sort != "" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidSort, opts.Sort)
+	}
+	for name := range opts.Filters {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidFilter, name)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = domain.DefaultPageLimit
+	}
+	if limit > domain.MaxPageLimit {
+		limit = domain.MaxPageLimit
+	}
+	after := 0
+	if opts.Cursor != "" {
+		cur, err := decodeCursor(opts.Cursor, leaderboardSort)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(cur.Value, &after); err != nil || after < 0 {
+			return nil, domain.ErrInvalidCursor
+		}
+	}
+
+	var sql strings.Builder
+	var args []interface{}
+	sql.WriteString(`WITH attended AS (
+SELECT event_attendee.user_id, COUNT(*) AS events
+FROM event_attendee
+JOIN event ON event.id = event_attendee.event_id AND event.deleted_at IS NULL
+WHERE event_attendee.punctuality IN ('on_time', 'late')`)
+	if since != nil {
+		sql.WriteString(" AND event.start_time >= ?")
+		args = append(args, *since)
+	}
+	sql.WriteString("\nGROUP BY event_attendee.user_id\n)")
+
+	// All time points are User.Points, which the ledger keeps in step
+	points := `"user".points`
+	if since != nil {
+		sql.WriteString(`, earned AS (
+SELECT user_id, SUM(amount) AS points
+FROM point_transaction
+WHERE created_at >= ?
+GROUP BY user_id
+)`)
+		args = append(args, *since)
+		points = "COALESCE(earned.points, 0)"
+	}
+
+	sql.WriteString(`, scored AS (
+SELECT "user".id AS user_id, "user".name, "user".avatar_url, "user".level,
+` + points + ` AS points, COALESCE(attended.events, 0) AS events_attended
+FROM "user"
+LEFT JOIN attended ON attended.user_id = "user".id`)
+	if since != nil {
+		sql.WriteString(`
+LEFT JOIN earned ON earned.user_id = "user".id`)
+	}
+	sql.WriteString(`
+WHERE "user".deleted_at IS NULL`)
+	if guildID != nil {
+		sql.WriteString(` AND "user".id IN (SELECT user_id FROM guild_attendee WHERE guild_id = ? AND left_at IS NULL)`)
+		args = append(args, *guildID)
+	}
+	sql.WriteString(`
+), ranked AS (
+SELECT *,
+RANK() OVER (ORDER BY points DESC, events_attended DESC) AS rank,
+ROW_NUMBER() OVER (ORDER BY points DESC, events_attended DESC, user_id) AS position
+FROM scored
+WHERE points > 0 OR events_attended > 0
+)
+SELECT * FROM ranked WHERE position > ? ORDER BY position LIMIT ?`)
+	args = append(args, after, limit+1)
+
+	entries := make([]*domain.LeaderboardEntry, 0, limit+1)
+	if err := r.db.WithContext(ctx).Raw(sql.String(), args...).Scan(&entries).Error; err != nil {
+		return nil, MapGormError(err)
+	}
+
+	page := &domain.Page[*domain.LeaderboardEntry]{Items: entries}
+	if len(entries) > limit {
+		page.Items = entries[:limit]
+		last := page.Items[limit-1]
+		next, err := encodeCursor(leaderboardSort, last.Position, last.UserID)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+var leaderboardColumns = []string{"user_id", "name", "avatar_url", "level", "points", "events_attended", "rank", "position"}
+
+func TestGormLeaderboardRepository_ListGlobal(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormLeaderboardRepository(db)
+	first, tiedA, tiedB := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("AllTime", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT "user".id AS user_id, "user".name, "user".avatar_url, "user".level,
+"user".points AS points, COALESCE(attended.events, 0) AS events_attended`)).
+			WithArgs(0, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows(leaderboardColumns).
+				AddRow(first, "first", nil, 3, 500, 10, 1, 1).
+				AddRow(tiedA, "tied", nil, 2, 300, 6, 2, 2).
+				AddRow(tiedB, "tied", nil, 2, 300, 6, 2, 3))
+
+		page, err := repo.ListGlobal(context.Background(), nil, domain.ListOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 3)
+		assert.Equal(t, 1, page.Items[0].Rank)
+		assert.Equal(t, 2, page.Items[1].Rank)
+		assert.Equal(t, 2, page.Items[2].Rank)
+		assert.Equal(t, 300, page.Items[2].Points)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WindowAndCursor", func(t *testing.T) {
+		since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, SUM(amount) AS points
+FROM point_transaction
+WHERE created_at >= $2`)).
+			WithArgs(since, since, 0, 3).
+			WillReturnRows(sqlmock.NewRows(leaderboardColumns).
+				AddRow(first, "first", nil, 3, 50, 2, 1, 1).
+				AddRow(tiedA, "tied", nil, 2, 30, 1, 2, 2).
+				AddRow(tiedB, "tied", nil, 2, 30, 1, 2, 3))
+
+		page, err := repo.ListGlobal(context.Background(), &since, domain.ListOptions{Limit: 2})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		require.NotEmpty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		// The next page resumes after the last position, not the last rank
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM ranked WHERE position > $3 ORDER BY position LIMIT $4`)).
+			WithArgs(since, since, 2, 3).
+			WillReturnRows(sqlmock.NewRows(leaderboardColumns).
+				AddRow(tiedB, "tied", nil, 2, 30, 1, 2, 3))
+
+		page, err = repo.ListGlobal(context.Background(), &since, domain.ListOptions{Limit: 2, Cursor: page.NextCursor})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, tiedB, page.Items[0].UserID)
+		assert.Equal(t, 2, page.Items[0].Rank)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		_, err := repo.ListGlobal(context.Background(), nil, domain.ListOptions{Cursor: "not-a-cursor"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	})
+
+	t.Run("FilterNotSupported", func(t *testing.T) {
+		_, err := repo.ListGlobal(context.Background(), nil, domain.ListOptions{Filters: map[string]string{"role": "admin"}})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`WITH attended AS`)).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListGlobal(context.Background(), nil, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormLeaderboardRepository_ListByGuildID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormLeaderboardRepository(db)
+	guildID, userID := uuid.New(), uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`AND "user".id IN (SELECT user_id FROM guild_attendee WHERE guild_id = $1 AND left_at IS NULL)`)).
+			WithArgs(guildID, 0, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows(leaderboardColumns).
+				AddRow(userID, "member", nil, 1, 120, 4, 1, 1))
+
+		page, err := repo.ListByGuildID(context.Background(), guildID, nil, domain.ListOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, userID, page.Items[0].UserID)
+		assert.Equal(t, 4, page.Items[0].EventsAttended)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -13,6 +13,22 @@ type gormMistakeRepository struct {
 	db *gorm.DB
 }
 
+var mistakeListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at":       "created_at",
+		"updated_at":       "updated_at",
+		"start_offset_sec": "start_offset_sec",
+	},
+	defaultSort: "-created_at",
+	filters: map[string]filterSpec{
+		"type":           {cond: "type = ?", parse: parseString},
+		"event_id":       {cond: "event_id = ?", parse: parseUUID},
+		"user_id":        {cond: "user_id = ?", parse: parseUUID},
+		"created_after":  {cond: "created_at >= ?", parse: parseTime},
+		"created_before": {cond: "created_at < ?", parse: parseTime},
+	},
+}
+
 // NewGormMistakeRepository creates a new GORM-based mistake repository.
 func NewGormMistakeRepository(db *gorm.DB) domain.MistakeRepository {
 	return &gormMistakeRepository{db: db}
@@ -45,6 +61,16 @@ func (r *gormMistakeRepository) GetByUserID(ctx context.Context, userID uuid.UUI
 	return mistakes, nil
 }
 
+func (r *gormMistakeRepository) ListByEventID(ctx context.Context, eventID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Mistake], error) {
+	query := r.db.WithContext(ctx).Where("event_id = ?", eventID)
+	return paginate[domain.Mistake](ctx, query, opts, mistakeListSpec)
+}
+
+func (r *gormMistakeRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Mistake], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.Mistake](ctx, query, opts, mistakeListSpec)
+}
+
 func (r *gormMistakeRepository) Create(ctx context.Context, m *domain.Mistake) error {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
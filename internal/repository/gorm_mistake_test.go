@@ -279,3 +279,34 @@ func TestGormMistakeRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormMistakeRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormMistakeRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE user_id = $1 ORDER BY "mistake"."created_at" DESC, "mistake"."id" DESC LIMIT $2`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+				AddRow(uuid.New(), userID))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE user_id = $1`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
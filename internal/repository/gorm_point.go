@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+type gormPointRepository struct {
+	db *gorm.DB
+}
+
+var pointTransactionListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "-created_at",
+	filters: map[string]filterSpec{
+		"reason":   {cond: "reason = ?", parse: parseString},
+		"event_id": {cond: "event_id = ?", parse: parseUUID},
+	},
+}
+
+// guildActivity sums the points that the current members of guild.id earned
+// since they joined it, so leaving a guild takes one's points along.
+const guildActivity = `SELECT COALESCE(SUM(point_transaction.amount), 0) AS points
+FROM point_transaction
+JOIN guild_attendee ON guild_attendee.user_id = point_transaction.user_id
+WHERE guild_attendee.guild_id = guild.id
+AND guild_attendee.left_at IS NULL
+AND (guild_attendee.joined_at IS NULL OR point_transaction.created_at >= guild_attendee.joined_at)`
+
+func NewGormPointRepository(db *gorm.DB) domain.PointRepository {
+	return &gormPointRepository{db: db}
+}
+
+// levelCase maps value to a level through curve in SQL, mirroring
+// LevelCurve.Level.
+func levelCase(value string, curve domain.LevelCurve) clause.Expr {
+	if len(curve) == 0 {
+		return gorm.Expr("0")
+	}
+	var sql strings.Builder
+	vars := make([]interface{}, 0, 2*len(curve))
+	sql.WriteString("CASE")
+	for i := len(curve) - 1; i >= 0; i-- {
+		sql.WriteString(" WHEN " + value + " >= ? THEN ?")
+		vars = append(vars, curve[i], i+1)
+	}
+	sql.WriteString(" ELSE 0 END")
+	return gorm.Expr(sql.String(), vars...)
+}
+
+// guildLevel is the level of guild.id computed from guildActivity.
+func guildLevel(curve domain.LevelCurve) clause.Expr {
+	level := levelCase("activity.points", curve)
+	return gorm.Expr("(SELECT "+level.SQL+" FROM ("+guildActivity+") AS activity)", level.Vars...)
+}
+
+func (r *gormPointRepository) Award(ctx context.Context, entries []*domain.PointTransaction, curves domain.LevelCurves) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		totals := make(map[uuid.UUID]int)
+		for _, entry := range entries {
+			if entry.ID == uuid.Nil {
+				entry.ID = uuid.New()
+			}
+			// Entries already in the ledger are skipped and not counted,
+			// so awarding the same event twice changes nothing
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				totals[entry.UserID] += entry.Amount
+			}
+		}
+		if len(totals) == 0 {
+			return nil
+		}
+
+		// Sorted so concurrent awards lock users in the same order
+		userIDs := make([]uuid.UUID, 0, len(totals))
+		for userID := range totals {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Slice(userIDs, func(i, j int) bool { return userIDs[i].String() < userIDs[j].String() })
+
+		for _, userID := range userIDs {
+			err := tx.Model(&domain.User{}).Where("id = ?", userID).
+				Update("points", gorm.Expr("points + ?", totals[userID])).Error
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Model(&domain.User{}).Where("id IN ?", userIDs).
+			Update("level", levelCase("points", curves.User)).Error
+		if err != nil {
+			return err
+		}
+
+		members := tx.Model(&domain.GuildAttendee{}).Select("guild_id").Where("user_id IN ?", userIDs)
+		return tx.Model(&domain.Guild{}).Where("id IN (?)", members).
+			Update("level", guildLevel(curves.Guild)).Error
+	}))
+}
+
+func (r *gormPointRepository) Recompute(ctx context.Context, curves domain.LevelCurves) error {
+	return MapGormError(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		err := all.Model(&domain.User{}).
+			Update("points", gorm.Expr(`(SELECT COALESCE(SUM(amount), 0) FROM point_transaction WHERE point_transaction.user_id = "user".id)`)).Error
+		if err != nil {
+			return err
+		}
+		if err := all.Model(&domain.User{}).Update("level", levelCase("points", curves.User)).Error; err != nil {
+			return err
+		}
+		return all.Model(&domain.Guild{}).Update("level", guildLevel(curves.Guild)).Error
+	}))
+}
+
+func (r *gormPointRepository) HasReason(ctx context.Context, userID uuid.UUID, reason domain.PointReason, eventIDs []uuid.UUID) (bool, error) {
+	if len(eventIDs) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.PointTransaction{}).
+		Where("user_id = ? AND reason = ? AND event_id IN ?", userID, reason, eventIDs).
+		Count(&count).Error
+	if err != nil {
+		return false, MapGormError(err)
+	}
+	return count > 0, nil
+}
+
+func (r *gormPointRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.PointTransaction], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.PointTransaction](ctx, query, opts, pointTransactionListSpec)
+}
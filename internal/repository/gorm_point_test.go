@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+func TestLevelCase(t *testing.T) {
+	expr := levelCase("points", domain.LevelCurve{100, 300})
+
+	assert.Equal(t, "CASE WHEN points >= ? THEN ? WHEN points >= ? THEN ? ELSE 0 END", expr.SQL)
+	assert.Equal(t, []interface{}{300, 2, 100, 1}, expr.Vars)
+	assert.Equal(t, "0", levelCase("points", nil).SQL)
+}
+
+func TestGormPointRepository_Award(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormPointRepository(db)
+	userID, eventID := uuid.New(), uuid.New()
+	curves := domain.LevelCurves{User: domain.LevelCurve{100}, Guild: domain.LevelCurve{1000}}
+
+	t.Run("Success", func(t *testing.T) {
+		entries := []*domain.PointTransaction{
+			{UserID: userID, EventID: &eventID, Reason: domain.PointReasonAttendedEvent, Amount: 10},
+			{UserID: userID, EventID: &eventID, Reason: domain.PointReasonEmcee, Amount: 5},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "point_transaction"`)).
+			WithArgs(sqlmock.AnyArg(), userID, &eventID, domain.PointReasonAttendedEvent, 10, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Already awarded: ON CONFLICT DO NOTHING inserts nothing
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "point_transaction"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "points"=points + $1,"updated_at"=$2 WHERE id = $3`)).
+			WithArgs(10, sqlmock.AnyArg(), userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "level"=CASE WHEN points >= $1 THEN $2 ELSE 0 END,"updated_at"=$3 WHERE id IN ($4)`)).
+			WithArgs(100, 1, sqlmock.AnyArg(), userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild" SET "level"=(SELECT CASE WHEN activity.points >= $1 THEN $2 ELSE 0 END FROM (SELECT COALESCE(SUM(point_transaction.amount), 0) AS points`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Award(context.Background(), entries, curves)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, entries[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyAwarded", func(t *testing.T) {
+		entries := []*domain.PointTransaction{
+			{UserID: userID, EventID: &eventID, Reason: domain.PointReasonAttendedEvent, Amount: 10},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "point_transaction"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.Award(context.Background(), entries, curves)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		entries := []*domain.PointTransaction{
+			{UserID: userID, EventID: &eventID, Reason: domain.PointReasonAttendedEvent, Amount: 10},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "point_transaction"`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Award(context.Background(), entries, curves)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormPointRepository_Recompute(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormPointRepository(db)
+	curves := domain.LevelCurves{User: domain.LevelCurve{100}, Guild: domain.LevelCurve{1000}}
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "points"=(SELECT COALESCE(SUM(amount), 0) FROM point_transaction WHERE point_transaction.user_id = "user".id),"updated_at"=$1 WHERE "user"."deleted_at" IS NULL`)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "level"=CASE WHEN points >= $1 THEN $2 ELSE 0 END`)).
+			WithArgs(100, 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "guild" SET "level"=`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Recompute(context.Background(), curves)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "user" SET "points"=`)).
+			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
+
+		err := repo.Recompute(context.Background(), curves)
+
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormPointRepository_HasReason(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormPointRepository(db)
+	userID, eventID := uuid.New(), uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "point_transaction" WHERE user_id = $1 AND reason = $2 AND event_id IN ($3)`)).
+			WithArgs(userID, domain.PointReasonStreakBonus, eventID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		found, err := repo.HasReason(context.Background(), userID, domain.PointReasonStreakBonus, []uuid.UUID{eventID})
+
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoEvents", func(t *testing.T) {
+		found, err := repo.HasReason(context.Background(), userID, domain.PointReasonStreakBonus, nil)
+
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGormPointRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormPointRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "point_transaction" WHERE user_id = $1 AND reason = $2 ORDER BY "point_transaction"."created_at" DESC, "point_transaction"."id" DESC LIMIT $3`)).
+			WithArgs(userID, "emcee", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "reason", "amount"}).
+				AddRow(uuid.New(), userID, domain.PointReasonEmcee, 5))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{
+			Filters: map[string]string{"reason": "emcee"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, 5, page.Items[0].Amount)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "point_transaction" WHERE user_id = $1`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
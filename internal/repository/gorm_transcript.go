@@ -13,6 +13,21 @@ type gormTranscriptRepository struct {
 	db *gorm.DB
 }
 
+var transcriptListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at":       "created_at",
+		"updated_at":       "updated_at",
+		"start_offset_sec": "start_offset_sec",
+	},
+	defaultSort: "-created_at",
+	filters: map[string]filterSpec{
+		"event_id":       {cond: "event_id = ?", parse: parseUUID},
+		"user_id":        {cond: "user_id = ?", parse: parseUUID},
+		"created_after":  {cond: "created_at >= ?", parse: parseTime},
+		"created_before": {cond: "created_at < ?", parse: parseTime},
+	},
+}
+
 func NewGormTranscriptRepository(db *gorm.DB) domain.TranscriptRepository {
 	return &gormTranscriptRepository{db: db}
 }
@@ -44,6 +59,16 @@ func (r *gormTranscriptRepository) GetByUserID(ctx context.Context, userID uuid.
 	return transcripts, nil
 }
 
+func (r *gormTranscriptRepository) ListByEventID(ctx context.Context, eventID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Transcript], error) {
+	query := r.db.WithContext(ctx).Where("event_id = ?", eventID)
+	return paginate[domain.Transcript](ctx, query, opts, transcriptListSpec)
+}
+
+func (r *gormTranscriptRepository) ListByUserID(ctx context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.Transcript], error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	return paginate[domain.Transcript](ctx, query, opts, transcriptListSpec)
+}
+
 func (r *gormTranscriptRepository) Create(ctx context.Context, transcript *domain.Transcript) error {
 	if transcript.ID == uuid.Nil {
 		transcript.ID = uuid.New()
@@ -276,3 +276,53 @@ func TestGormTranscriptRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormTranscriptRepository_ListByEventID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormTranscriptRepository(db)
+	eventID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transcript" WHERE event_id = $1 ORDER BY "transcript"."start_offset_sec" ASC, "transcript"."id" ASC LIMIT $2`)).
+			WithArgs(eventID, 21).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id"}).
+				AddRow(uuid.New(), eventID).
+				AddRow(uuid.New(), eventID))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Limit: 20, Sort: "start_offset_sec"})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DBError", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transcript" WHERE event_id = $1`)).
+			WithArgs(eventID, domain.DefaultPageLimit+1).
+			WillReturnError(fmt.Errorf("db error"))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+	})
+}
+
+func TestGormTranscriptRepository_ListByUserID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormTranscriptRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "transcript" WHERE user_id = $1 ORDER BY "transcript"."created_at" DESC, "transcript"."id" DESC LIMIT $2`)).
+			WithArgs(userID, domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+				AddRow(uuid.New(), userID))
+
+		page, err := repo.ListByUserID(context.Background(), userID, domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -13,6 +13,18 @@ type gormUserRepository struct {
 	db *gorm.DB
 }
 
+var userListSpec = listSpec{
+	sorts: map[string]string{
+		"created_at": "created_at",
+		"name":       "name",
+	},
+	defaultSort: "created_at",
+	filters: map[string]filterSpec{
+		"role":   {cond: "role = ?", parse: parseString},
+		"status": {cond: "status = ?", parse: parseString},
+	},
+}
+
 // NewGormUserRepository creates a new GORM-based user repository.
 func NewGormUserRepository(db *gorm.DB) domain.UserRepository {
 	return &gormUserRepository{db: db}
@@ -27,6 +39,15 @@ func (r *gormUserRepository) GetByID(ctx context.Context, userID uuid.UUID) (*do
 	return &user, nil
 }
 
+func (r *gormUserRepository) GetDeletedByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&user, "id = ?", userID).Error
+	if err != nil {
+		return nil, MapGormError(err)
+	}
+	return &user, nil
+}
+
 func (r *gormUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
 	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
@@ -45,6 +66,11 @@ func (r *gormUserRepository) GetByName(ctx context.Context, name string) ([]*dom
 	return users, nil
 }
 
+func (r *gormUserRepository) ListByName(ctx context.Context, name string, opts domain.ListOptions) (*domain.Page[*domain.User], error) {
+	query := r.db.WithContext(ctx).Where("name = ?", name)
+	return paginate[domain.User](ctx, query, opts, userListSpec)
+}
+
 func (r *gormUserRepository) Create(ctx context.Context, user *domain.User) error {
 	if user.ID == uuid.Nil {
 		user.ID = uuid.New()
@@ -53,7 +79,7 @@ func (r *gormUserRepository) Create(ctx context.Context, user *domain.User) erro
 }
 
 func (r *gormUserRepository) Update(ctx context.Context, user *domain.User) error {
-	return MapGormError(r.db.WithContext(ctx).Save(user).Error)
+	return MapGormError(r.db.WithContext(ctx).Omit("late_streak", "points", "level").Save(user).Error)
 }
 
 func (r *gormUserRepository) Delete(ctx context.Context, userID uuid.UUID) error {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -81,6 +82,38 @@ func TestGormUserRepository_GetByID(t *testing.T) {
 	})
 }
 
+func TestGormUserRepository_GetDeletedByID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormUserRepository(db)
+	userID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user" WHERE deleted_at IS NOT NULL AND id = $1 ORDER BY "user"."id" LIMIT $2`)).
+			WithArgs(userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "deleted_at"}).
+				AddRow(userID, "test@example.com", time.Now()))
+
+		user, err := repo.GetDeletedByID(context.Background(), userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+		assert.True(t, user.DeletedAt.Valid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user" WHERE deleted_at IS NOT NULL AND id = $1 ORDER BY "user"."id" LIMIT $2`)).
+			WithArgs(userID, 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		user, err := repo.GetDeletedByID(context.Background(), userID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, user)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestGormUserRepository_GetByEmail(t *testing.T) {
 	db, mock := setupMockDB(t)
 	repo := NewGormUserRepository(db)
@@ -248,6 +281,21 @@ func TestGormUserRepository_Update(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("KeepsServerManagedFields", func(t *testing.T) {
+		user := &domain.User{ID: userID, Points: 9999, Level: 99}
+
+		// late_streak, points and level are left out of the SET clause
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user" SET .*"timezone"=\$\d+,"created_at"=`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := repo.Update(context.Background(), user)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("DBError", func(t *testing.T) {
 		user := &domain.User{
 			ID:    userID,
@@ -296,3 +344,28 @@ func TestGormUserRepository_Delete(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestGormUserRepository_ListByName(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormUserRepository(db)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "user" WHERE name = $1 AND "user"."deleted_at" IS NULL ORDER BY "user"."created_at" ASC, "user"."id" ASC LIMIT $2`)).
+			WithArgs("Taro", domain.DefaultPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+				AddRow(uuid.New(), "Taro"))
+
+		page, err := repo.ListByName(context.Background(), "Taro", domain.ListOptions{})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		page, err := repo.ListByName(context.Background(), "Taro", domain.ListOptions{Sort: "password_hash"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidSort)
+		assert.Nil(t, page)
+	})
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+// filterSpec describes a whitelisted list filter. Cond must contain exactly
+// one placeholder, which receives the parsed query value.
+type filterSpec struct {
+	cond  string
+	parse func(string) (interface{}, error)
+}
+
+// listSpec whitelists the sort fields and filters accepted by a list query.
+// Sort field names map to columns of the queried model's table.
+type listSpec struct {
+	sorts       map[string]string
+	defaultSort string
+	filters     map[string]filterSpec
+}
+
+// pageCursor is the decoded form of the opaque cursor handed to clients. It
+// holds the sort column value and ID of the last item of the previous page,
+// and the sort (field and direction) the page was listed in.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"id"`
+}
+
+func parseString(v string) (interface{}, error) {
+	return v, nil
+}
+
+func parseInt(v string) (interface{}, error) {
+	return strconv.Atoi(v)
+}
+
+// parseContains turns v into an ILIKE pattern matching any text containing
+// v literally.
+func parseContains(v string) (interface{}, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(v)
+	return "%" + escaped + "%", nil
+}
+
+func parseTime(v string) (interface{}, error) {
+	return time.Parse(time.RFC3339, v)
+}
+
+func parseUUID(v string) (interface{}, error) {
+	return uuid.Parse(v)
+}
+
+// paginate runs a keyset-paginated query for T on the given (already scoped)
+// query. Items are ordered by the requested sort column with the primary key
+// as tie-breaker, so cursors stay stable while rows are inserted.
+func paginate[T any](ctx context.Context, query *gorm.DB, opts domain.ListOptions, spec listSpec) (*domain.Page[*T], error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = domain.DefaultPageLimit
+	}
+	if limit > domain.MaxPageLimit {
+		limit = domain.MaxPageLimit
+	}
+
+	sortKey := opts.Sort
+	if sortKey == "" {
+		sortKey = spec.defaultSort
+	}
+	desc := strings.HasPrefix(sortKey, "-")
+	sortName := sortKey
+	sortName = strings.TrimPrefix(sortName, "-")
+	column, ok := spec.sorts[sortName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidSort, sortName)
+	}
+
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	sortField := stmt.Schema.LookUpField(column)
+	idField := stmt.Schema.PrioritizedPrimaryField
+	if sortField == nil || idField == nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvalidSort, sortName)
+	}
+	quote := func(name string) string {
+		return query.Statement.Quote(clause.Column{Table: stmt.Schema.Table, Name: name})
+	}
+
+	names := make([]string, 0, len(opts.Filters))
+	for name := range opts.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f, ok := spec.filters[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidFilter, name)
+		}
+		v, err := f.parse(opts.Filters[name])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidFilter, name)
+		}
+		query = query.Where(f.cond, v)
+	}
+
+	if opts.Cursor != "" {
+		cur, err := decodeCursor(opts.Cursor, sortKey)
+		if err != nil {
+			return nil, err
+		}
+		value := reflect.New(sortField.FieldType)
+		if err := json.Unmarshal(cur.Value, value.Interface()); err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		if sortField == idField {
+			query = query.Where(fmt.Sprintf("%s %s ?", quote(idField.DBName), op), cur.ID)
+		} else {
+			query = query.Where(
+				fmt.Sprintf("(%s, %s) %s (?, ?)", quote(sortField.DBName), quote(idField.DBName), op),
+				value.Elem().Interface(), cur.ID,
+			)
+		}
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	order := fmt.Sprintf("%s %s", quote(sortField.DBName), direction)
+	if sortField != idField {
+		order += fmt.Sprintf(", %s %s", quote(idField.DBName), direction)
+	}
+	query = query.Order(order).Limit(limit + 1)
+
+	items := make([]*T, 0, limit+1)
+	if err := query.Find(&items).Error; err != nil {
+		return nil, MapGormError(err)
+	}
+
+	page := &domain.Page[*T]{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := reflect.ValueOf(page.Items[limit-1]).Elem()
+		sortValue, _ := sortField.ValueOf(ctx, last)
+		idValue, _ := idField.ValueOf(ctx, last)
+		id, ok := idValue.(uuid.UUID)
+		if !ok {
+			return nil, fmt.Errorf("unsupported primary key type %T", idValue)
+		}
+		next, err := encodeCursor(sortKey, sortValue, id)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+func encodeCursor(sortKey string, value interface{}, id uuid.UUID) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(pageCursor{Sort: sortKey, Value: raw, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor decodes s, which must come from a page listed in sortKey: a
+// cursor replayed with another sort would skip or repeat rows.
+func decodeCursor(s, sortKey string) (*pageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var cur pageCursor
+	if err := json.Unmarshal(b, &cur); err != nil || len(cur.Value) == 0 || cur.ID == uuid.Nil || cur.Sort != sortKey {
+		return nil, domain.ErrInvalidCursor
+	}
+	return &cur, nil
+}
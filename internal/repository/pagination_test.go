@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := uuid.New()
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	encoded, err := encodeCursor("-created_at", ts, id)
+	assert.NoError(t, err)
+
+	cur, err := decodeCursor(encoded, "-created_at")
+	assert.NoError(t, err)
+	assert.Equal(t, id, cur.ID)
+
+	var decoded time.Time
+	assert.NoError(t, json.Unmarshal(cur.Value, &decoded))
+	assert.True(t, ts.Equal(decoded))
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "not base64", input: "!!!"},
+		{name: "not json", input: "bm90LWpzb24"},
+		{name: "missing id", input: "eyJ2IjoxfQ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.input, "created_at")
+			assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewGormMistakeRepository(db)
+	eventID := uuid.New()
+
+	t.Run("NextCursorWhenMoreRows", func(t *testing.T) {
+		t1 := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+		t2 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+		t3 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		id2 := uuid.New()
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE event_id = $1 ORDER BY "mistake"."created_at" DESC, "mistake"."id" DESC LIMIT $2`)).
+			WithArgs(eventID, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "created_at"}).
+				AddRow(uuid.New(), eventID, t1).
+				AddRow(id2, eventID, t2).
+				AddRow(uuid.New(), eventID, t3))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+		assert.NotEmpty(t, page.NextCursor)
+		cur, err := decodeCursor(page.NextCursor, "-created_at")
+		assert.NoError(t, err)
+		assert.Equal(t, id2, cur.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LastPageHasNoCursor", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE event_id = $1 ORDER BY "mistake"."created_at" DESC, "mistake"."id" DESC LIMIT $2`)).
+			WithArgs(eventID, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id"}).
+				AddRow(uuid.New(), eventID))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CursorSortAndFilter", func(t *testing.T) {
+		lastID := uuid.New()
+		cursor, err := encodeCursor("start_offset_sec", 12.5, lastID)
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE event_id = $1 AND type = $2 AND ("mistake"."start_offset_sec", "mistake"."id") > ($3, $4) ORDER BY "mistake"."start_offset_sec" ASC, "mistake"."id" ASC LIMIT $5`)).
+			WithArgs(eventID, "vocab", 12.5, lastID, 11).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id"}))
+
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{
+			Limit:   10,
+			Cursor:  cursor,
+			Sort:    "start_offset_sec",
+			Filters: map[string]string{"type": "vocab"},
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("LimitIsCapped", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mistake" WHERE event_id = $1 ORDER BY "mistake"."created_at" DESC, "mistake"."id" DESC LIMIT $2`)).
+			WithArgs(eventID, domain.MaxPageLimit+1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id"}))
+
+		_, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Limit: 10000})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Sort: "origin_text"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidSort)
+		assert.Nil(t, page)
+	})
+
+	t.Run("UnknownFilter", func(t *testing.T) {
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{
+			Filters: map[string]string{"note": "x"},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		assert.Nil(t, page)
+	})
+
+	t.Run("MalformedFilterValue", func(t *testing.T) {
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{
+			Filters: map[string]string{"created_after": "yesterday"},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		assert.Nil(t, page)
+	})
+
+	t.Run("CursorOfAnotherSort", func(t *testing.T) {
+		cursor, err := encodeCursor("-created_at", time.Now(), uuid.New())
+		assert.NoError(t, err)
+
+		for _, sort := range []string{"created_at", "-start_offset_sec"} {
+			page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Cursor: cursor, Sort: sort})
+
+			assert.ErrorIs(t, err, domain.ErrInvalidCursor, sort)
+			assert.Nil(t, page)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		page, err := repo.ListByEventID(context.Background(), eventID, domain.ListOptions{Cursor: "garbage"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		assert.Nil(t, page)
+	})
+}
+
+func TestParseContains(t *testing.T) {
+	got, err := parseContains(`50%_off\`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `%50\%\_off\\%`, got)
+}
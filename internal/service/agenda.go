@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSlotMinutes is the length of a report turn when the event sets
+// neither slot_minutes nor expected_duration.
+const DefaultSlotMinutes = 5.0
+
+// AgendaService derives the agenda of an event from its mode and attendees.
+type AgendaService struct {
+	eventRepo         domain.EventRepository
+	eventAttendeeRepo domain.EventAttendeeRepository
+	userRepo          domain.UserRepository
+	mistakeRepo       domain.MistakeRepository
+}
+
+func NewAgendaService(eventRepo domain.EventRepository, eventAttendeeRepo domain.EventAttendeeRepository, userRepo domain.UserRepository, mistakeRepo domain.MistakeRepository) *AgendaService {
+	return &AgendaService{
+		eventRepo:         eventRepo,
+		eventAttendeeRepo: eventAttendeeRepo,
+		userRepo:          userRepo,
+		mistakeRepo:       mistakeRepo,
+	}
+}
+
+// Validate checks the agenda settings of event before it is saved. The
+// event under review must be another event that started earlier.
+func (s *AgendaService) Validate(ctx context.Context, event *domain.Event) error {
+	if event.SlotMinutes != nil && *event.SlotMinutes <= 0 {
+		return fmt.Errorf("%w: slot_minutes must be positive", domain.ErrInvalidAgenda)
+	}
+	if event.ReviewOfID == nil {
+		return nil
+	}
+	if *event.ReviewOfID == event.ID {
+		return fmt.Errorf("%w: an event cannot review itself", domain.ErrInvalidAgenda)
+	}
+	reviewed, err := s.eventRepo.GetByID(ctx, *event.ReviewOfID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("%w: review_of_id: event not found", domain.ErrInvalidAgenda)
+	}
+	if err != nil {
+		return err
+	}
+	if !reviewed.StartTime.Before(event.StartTime) {
+		return fmt.Errorf("%w: review_of_id must be an earlier event", domain.ErrInvalidAgenda)
+	}
+	return nil
+}
+
+// SetSpeakingOrder puts the given attendees first on the agenda, in order.
+func (s *AgendaService) SetSpeakingOrder(ctx context.Context, eventID uuid.UUID, userIDs []uuid.UUID) error {
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: user %s is listed twice", domain.ErrInvalidAgenda, id)
+		}
+		seen[id] = true
+	}
+	err := s.eventAttendeeRepo.SetSpeakingOrder(ctx, eventID, userIDs)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("%w: every user must attend the event", domain.ErrInvalidAgenda)
+	}
+	return err
+}
+
+// Build derives the agenda of event. Report slots follow each other from
+// the time the event started, or is scheduled to start.
+func (s *AgendaService) Build(ctx context.Context, event *domain.Event) (*domain.Agenda, error) {
+	attendees, err := s.eventAttendeeRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	agenda := &domain.Agenda{EventID: event.ID, Mode: event.Mode, Speakers: []domain.AgendaSpeaker{}}
+	order := make(map[uuid.UUID]*int, len(attendees))
+	present := make(map[uuid.UUID]bool, len(attendees))
+	for _, attendee := range attendees {
+		present[attendee.UserID] = true
+		if attendee.Role == domain.EventAttendeeRoleEmcee {
+			continue
+		}
+		speaker := domain.AgendaSpeaker{UserID: attendee.UserID}
+		user, err := s.userRepo.GetByID(ctx, attendee.UserID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, err
+		}
+		if user != nil {
+			speaker.Name = user.Name
+		}
+		order[attendee.UserID] = attendee.SpeakingOrder
+		agenda.Speakers = append(agenda.Speakers, speaker)
+	}
+	sort.SliceStable(agenda.Speakers, func(i, j int) bool {
+		a, b := agenda.Speakers[i], agenda.Speakers[j]
+		oa, ob := order[a.UserID], order[b.UserID]
+		switch {
+		case oa != nil && ob != nil && *oa != *ob:
+			return *oa < *ob
+		case (oa == nil) != (ob == nil):
+			return oa != nil
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		}
+		return a.UserID.String() < b.UserID.String()
+	})
+
+	switch event.Mode {
+	case domain.EventModeReport:
+		agenda.Slots = reportSlots(event, agenda.Speakers)
+	case domain.EventModeConversation:
+		agenda.Pairs = pairSpeakers(agenda.Speakers)
+	case domain.EventModeReview:
+		if agenda.Review, err = s.review(ctx, event, present); err != nil {
+			return nil, err
+		}
+	}
+	return agenda, nil
+}
+
+// review collects the mistakes the attendees made at the event under
+// review. Mistakes of people not attending are left out.
+func (s *AgendaService) review(ctx context.Context, event *domain.Event, present map[uuid.UUID]bool) (*domain.AgendaReview, error) {
+	if event.ReviewOfID == nil {
+		return nil, nil
+	}
+	reviewed, err := s.eventRepo.GetByID(ctx, *event.ReviewOfID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mistakes, err := s.mistakeRepo.GetByEventID(ctx, reviewed.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &domain.AgendaReview{
+		EventID:   reviewed.ID,
+		Title:     reviewed.Title,
+		StartTime: reviewed.StartTime,
+		Mistakes:  []*domain.Mistake{},
+	}
+	for _, mistake := range mistakes {
+		if present[mistake.UserID] {
+			review.Mistakes = append(review.Mistakes, mistake)
+		}
+	}
+	return review, nil
+}
+
+// reportSlots gives each speaker slot_minutes, or an equal share of the
+// expected duration.
+func reportSlots(event *domain.Event, speakers []domain.AgendaSpeaker) []domain.AgendaSlot {
+	if len(speakers) == 0 {
+		return nil
+	}
+	minutes := DefaultSlotMinutes
+	switch {
+	case event.SlotMinutes != nil:
+		minutes = *event.SlotMinutes
+	case event.ExpectedDuration > 0:
+		minutes = event.ExpectedDuration / float64(len(speakers))
+	}
+	length := time.Duration(minutes * float64(time.Minute))
+
+	start := event.StartTime
+	if event.StartedAt != nil {
+		start = *event.StartedAt
+	}
+	slots := make([]domain.AgendaSlot, 0, len(speakers))
+	for _, speaker := range speakers {
+		slots = append(slots, domain.AgendaSlot{AgendaSpeaker: speaker, StartsAt: start, EndsAt: start.Add(length)})
+		start = start.Add(length)
+	}
+	return slots
+}
+
+// pairSpeakers pairs neighbours in speaking order. A speaker left over
+// joins the last pair; a lone speaker forms a group of one.
+func pairSpeakers(speakers []domain.AgendaSpeaker) [][]domain.AgendaSpeaker {
+	var pairs [][]domain.AgendaSpeaker
+	for len(speakers) > 0 {
+		n := 2
+		if len(speakers) < 4 && len(speakers) != 2 {
+			n = len(speakers)
+		}
+		pairs = append(pairs, speakers[:n])
+		speakers = speakers[n:]
+	}
+	return pairs
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEventLookup struct {
+	domain.EventRepository
+	events map[uuid.UUID]*domain.Event
+}
+
+func (r *stubEventLookup) GetByID(_ context.Context, id uuid.UUID) (*domain.Event, error) {
+	if e, ok := r.events[id]; ok {
+		return e, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+type stubUserRepo struct {
+	domain.UserRepository
+	users map[uuid.UUID]*domain.User
+}
+
+func (r *stubUserRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func TestAgendaService_Build(t *testing.T) {
+	start := time.Date(2025, 1, 7, 19, 0, 0, 0, time.UTC)
+	emcee := uuid.New()
+	users := &stubUserRepo{users: map[uuid.UUID]*domain.User{emcee: {ID: emcee, Name: "emcee"}}}
+	attendees := &stubEventAttendeeRepo{attendees: []*domain.EventAttendee{{UserID: emcee, Role: domain.EventAttendeeRoleEmcee}}}
+	ids := map[string]uuid.UUID{}
+	for _, name := range []string{"dan", "bob", "cat", "ann"} {
+		id := uuid.New()
+		ids[name] = id
+		users.users[id] = &domain.User{ID: id, Name: name}
+		attendees.attendees = append(attendees.attendees, &domain.EventAttendee{UserID: id, Role: domain.EventAttendeeRoleMember})
+	}
+	// dan speaks first; the others follow by name
+	first := 1
+	attendees.attendees[1].SpeakingOrder = &first
+
+	past := &domain.Event{ID: uuid.New(), Title: "last week", StartTime: start.Add(-7 * 24 * time.Hour)}
+	mistakes := &stubMistakeRepo{mistakes: []*domain.Mistake{
+		{ID: uuid.New(), EventID: past.ID, UserID: ids["bob"]},
+		{ID: uuid.New(), EventID: past.ID, UserID: uuid.New()},
+	}}
+	events := &stubEventLookup{events: map[uuid.UUID]*domain.Event{past.ID: past}}
+	s := NewAgendaService(events, attendees, users, mistakes)
+
+	names := func(speakers []domain.AgendaSpeaker) []string {
+		var out []string
+		for _, sp := range speakers {
+			out = append(out, sp.Name)
+		}
+		return out
+	}
+
+	t.Run("Report", func(t *testing.T) {
+		event := &domain.Event{ID: uuid.New(), Mode: domain.EventModeReport, StartTime: start, ExpectedDuration: 60}
+
+		agenda, err := s.Build(context.Background(), event)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dan", "ann", "bob", "cat"}, names(agenda.Speakers))
+		require.Len(t, agenda.Slots, 4)
+		assert.Equal(t, start.Add(15*time.Minute), agenda.Slots[1].StartsAt)
+		assert.Equal(t, start.Add(60*time.Minute), agenda.Slots[3].EndsAt)
+		assert.Nil(t, agenda.Pairs)
+	})
+
+	t.Run("ReportFromStart", func(t *testing.T) {
+		minutes := 3.0
+		startedAt := start.Add(10 * time.Minute)
+		event := &domain.Event{ID: uuid.New(), Mode: domain.EventModeReport, StartTime: start, SlotMinutes: &minutes, StartedAt: &startedAt}
+
+		agenda, err := s.Build(context.Background(), event)
+
+		require.NoError(t, err)
+		assert.Equal(t, startedAt, agenda.Slots[0].StartsAt)
+		assert.Equal(t, startedAt.Add(12*time.Minute), agenda.Slots[3].EndsAt)
+	})
+
+	t.Run("Conversation", func(t *testing.T) {
+		event := &domain.Event{ID: uuid.New(), Mode: domain.EventModeConversation, StartTime: start}
+
+		agenda, err := s.Build(context.Background(), event)
+
+		require.NoError(t, err)
+		require.Len(t, agenda.Pairs, 2)
+		assert.Equal(t, []string{"dan", "ann"}, names(agenda.Pairs[0]))
+		assert.Equal(t, []string{"bob", "cat"}, names(agenda.Pairs[1]))
+		assert.Nil(t, agenda.Slots)
+	})
+
+	t.Run("Review", func(t *testing.T) {
+		event := &domain.Event{ID: uuid.New(), Mode: domain.EventModeReview, StartTime: start, ReviewOfID: &past.ID}
+
+		agenda, err := s.Build(context.Background(), event)
+
+		require.NoError(t, err)
+		require.NotNil(t, agenda.Review)
+		assert.Equal(t, past.ID, agenda.Review.EventID)
+		// Only mistakes of people attending are reviewed
+		require.Len(t, agenda.Review.Mistakes, 1)
+		assert.Equal(t, ids["bob"], agenda.Review.Mistakes[0].UserID)
+	})
+}
+
+func TestPairSpeakers(t *testing.T) {
+	speakers := func(n int) []domain.AgendaSpeaker {
+		out := make([]domain.AgendaSpeaker, n)
+		for i := range out {
+			out[i] = domain.AgendaSpeaker{UserID: uuid.New()}
+		}
+		return out
+	}
+	sizes := func(pairs [][]domain.AgendaSpeaker) []int {
+		var out []int
+		for _, p := range pairs {
+			out = append(out, len(p))
+		}
+		return out
+	}
+
+	assert.Nil(t, pairSpeakers(nil))
+	assert.Equal(t, []int{1}, sizes(pairSpeakers(speakers(1))))
+	assert.Equal(t, []int{2}, sizes(pairSpeakers(speakers(2))))
+	assert.Equal(t, []int{3}, sizes(pairSpeakers(speakers(3))))
+	assert.Equal(t, []int{2, 2}, sizes(pairSpeakers(speakers(4))))
+	assert.Equal(t, []int{2, 3}, sizes(pairSpeakers(speakers(5))))
+}
+
+func TestAgendaService_Validate(t *testing.T) {
+	start := time.Date(2025, 1, 7, 19, 0, 0, 0, time.UTC)
+	past := &domain.Event{ID: uuid.New(), StartTime: start.Add(-time.Hour)}
+	later := &domain.Event{ID: uuid.New(), StartTime: start.Add(time.Hour)}
+	s := NewAgendaService(&stubEventLookup{events: map[uuid.UUID]*domain.Event{past.ID: past, later.ID: later}}, nil, nil, nil)
+	self := uuid.New()
+	missing := uuid.New()
+	zero := 0.0
+
+	tests := map[string]struct {
+		event   *domain.Event
+		invalid bool
+	}{
+		"NoSettings":      {event: &domain.Event{StartTime: start}},
+		"PastEvent":       {event: &domain.Event{StartTime: start, ReviewOfID: &past.ID}},
+		"LaterEvent":      {event: &domain.Event{StartTime: start, ReviewOfID: &later.ID}, invalid: true},
+		"Itself":          {event: &domain.Event{ID: self, StartTime: start, ReviewOfID: &self}, invalid: true},
+		"MissingEvent":    {event: &domain.Event{StartTime: start, ReviewOfID: &missing}, invalid: true},
+		"ZeroSlotMinutes": {event: &domain.Event{StartTime: start, SlotMinutes: &zero}, invalid: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := s.Validate(context.Background(), tt.event)
+			if tt.invalid {
+				assert.ErrorIs(t, err, domain.ErrInvalidAgenda)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// DefaultSeriesHorizon is how far ahead occurrences of a series exist as
+// Event rows.
+const DefaultSeriesHorizon = 8 * 7 * 24 * time.Hour
+
+// EventSeriesService schedules recurring practices. It materializes the
+// occurrences of every series up to the horizon, so they can be joined and
+// edited like any other event, and applies edits of a whole series to its
+// upcoming occurrences.
+type EventSeriesService struct {
+	seriesRepo domain.EventSeriesRepository
+	horizon    time.Duration
+	now        func() time.Time
+}
+
+func NewEventSeriesService(seriesRepo domain.EventSeriesRepository, horizon time.Duration) *EventSeriesService {
+	return &EventSeriesService{
+		seriesRepo: seriesRepo,
+		horizon:    horizon,
+		now:        time.Now,
+	}
+}
+
+// Create stores the series hosted by creatorID and materializes its first
+// occurrences.
+func (s *EventSeriesService) Create(ctx context.Context, series *domain.EventSeries, creatorID uuid.UUID) error {
+	if _, _, err := schedule(series); err != nil {
+		return err
+	}
+	series.CreatedBy = creatorID
+	series.MaterializedUntil = nil
+	if err := s.seriesRepo.Create(ctx, series); err != nil {
+		return err
+	}
+	return s.materialize(ctx, series)
+}
+
+// Update saves updated, an edited copy of series. Upcoming occurrences that
+// were not edited on their own take over the template, those the new
+// schedule no longer has are removed, and new ones are materialized.
+// Occurrences that already started keep their details.
+func (s *EventSeriesService) Update(ctx context.Context, series, updated *domain.EventSeries) error {
+	rec, loc, err := schedule(updated)
+	if err != nil {
+		return err
+	}
+	oldRec, _, err := schedule(series)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	var removed []time.Time
+	if series.MaterializedUntil != nil && series.MaterializedUntil.After(now) {
+		kept := map[int64]bool{}
+		for _, t := range rec.Between(updated.StartTime, loc, now, *series.MaterializedUntil) {
+			if !updated.IsExcluded(t) {
+				kept[t.UnixNano()] = true
+			}
+		}
+		for _, t := range oldRec.Between(series.StartTime, loc, now, *series.MaterializedUntil) {
+			if !kept[t.UnixNano()] {
+				removed = append(removed, t)
+			}
+		}
+		// Materialized again from now on, which skips existing occurrences
+		updated.MaterializedUntil = &now
+	}
+
+	if err := s.seriesRepo.Update(ctx, updated, now, removed); err != nil {
+		return err
+	}
+	return s.materialize(ctx, updated)
+}
+
+// Delete removes the series together with its upcoming occurrences that
+// were not edited on their own.
+func (s *EventSeriesService) Delete(ctx context.Context, seriesID uuid.UUID) error {
+	return s.seriesRepo.Delete(ctx, seriesID, s.now())
+}
+
+// MaterializeDue materializes the occurrences of every series up to the
+// horizon.
+func (s *EventSeriesService) MaterializeDue(ctx context.Context) error {
+	due, err := s.seriesRepo.ListDue(ctx, s.now().Add(s.horizon))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, series := range due {
+		if err := s.materialize(ctx, series); err != nil {
+			errs = append(errs, fmt.Errorf("series %s: %w", series.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run calls MaterializeDue every interval until ctx is done.
+func (s *EventSeriesService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.MaterializeDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("materializing event series failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// materialize creates the occurrences between MaterializedUntil and the
+// horizon. Occurrences are never created in the past.
+func (s *EventSeriesService) materialize(ctx context.Context, series *domain.EventSeries) error {
+	rec, loc, err := schedule(series)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	from := series.StartTime
+	if series.MaterializedUntil != nil && series.MaterializedUntil.After(from) {
+		from = *series.MaterializedUntil
+	}
+	if now.After(from) {
+		from = now
+	}
+	until := now.Add(s.horizon)
+	if !until.After(from) {
+		return nil
+	}
+
+	var occurrences []*domain.Event
+	for _, t := range rec.Between(series.StartTime, loc, from, until) {
+		if !series.IsExcluded(t) {
+			occurrences = append(occurrences, series.Occurrence(t))
+		}
+	}
+	if err := s.seriesRepo.Materialize(ctx, series, occurrences, until); err != nil {
+		return err
+	}
+	series.MaterializedUntil = &until
+	return nil
+}
+
+// schedule parses the series' RRule in its timezone.
+func schedule(series *domain.EventSeries) (*Recurrence, *time.Location, error) {
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil || series.Timezone == "" {
+		return nil, nil, fmt.Errorf("%w: unknown timezone %q", domain.ErrInvalidRecurrence, series.Timezone)
+	}
+	rec, err := ParseRecurrence(series.RRule, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rec, loc, nil
+}
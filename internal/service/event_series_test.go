@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEventSeriesRepo struct {
+	domain.EventSeriesRepository
+	due         []*domain.EventSeries
+	created     *domain.EventSeries
+	occurrences []*domain.Event
+	until       time.Time
+	updated     *domain.EventSeries
+	from        time.Time
+	removed     []time.Time
+	failFor     uuid.UUID
+}
+
+func (r *stubEventSeriesRepo) ListDue(context.Context, time.Time) ([]*domain.EventSeries, error) {
+	return r.due, nil
+}
+
+func (r *stubEventSeriesRepo) Create(_ context.Context, series *domain.EventSeries) error {
+	series.ID = uuid.New()
+	r.created = series
+	return nil
+}
+
+func (r *stubEventSeriesRepo) Materialize(_ context.Context, series *domain.EventSeries, occurrences []*domain.Event, until time.Time) error {
+	if r.failFor != uuid.Nil && series.ID == r.failFor {
+		return errors.New("boom")
+	}
+	r.occurrences = append(r.occurrences, occurrences...)
+	r.until = until
+	return nil
+}
+
+func (r *stubEventSeriesRepo) Update(_ context.Context, series *domain.EventSeries, from time.Time, removed []time.Time) error {
+	r.updated = series
+	r.from = from
+	r.removed = removed
+	return nil
+}
+
+func starts(events []*domain.Event) []time.Time {
+	out := make([]time.Time, len(events))
+	for i, e := range events {
+		out[i] = e.StartTime
+	}
+	return out
+}
+
+func TestEventSeriesService(t *testing.T) {
+	// A Monday; the series meets on Tuesdays at 20:00 Taipei time
+	now := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+	tuesday := func(day int) time.Time {
+		return time.Date(2025, 5, day, 20, 0, 0, 0, taipei)
+	}
+	newService := func(repo *stubEventSeriesRepo) *EventSeriesService {
+		svc := NewEventSeriesService(repo, 3*7*24*time.Hour)
+		svc.now = func() time.Time { return now }
+		return svc
+	}
+	newSeries := func() *domain.EventSeries {
+		return &domain.EventSeries{
+			Title:     "Weekly practice",
+			Mode:      domain.EventModeReport,
+			StartTime: time.Date(2025, 4, 1, 20, 0, 0, 0, taipei),
+			Timezone:  "Asia/Taipei",
+			RRule:     "FREQ=WEEKLY",
+		}
+	}
+
+	t.Run("CreateMaterializesUpcoming", func(t *testing.T) {
+		repo := &stubEventSeriesRepo{}
+		creator := uuid.New()
+		series := newSeries()
+		series.ExDates = []time.Time{tuesday(13)}
+
+		require.NoError(t, newService(repo).Create(context.Background(), series, creator))
+
+		assert.Equal(t, creator, repo.created.CreatedBy)
+		// Past occurrences and the exception are skipped
+		assert.Equal(t, []time.Time{tuesday(6), tuesday(20)}, starts(repo.occurrences))
+		for _, e := range repo.occurrences {
+			assert.Equal(t, series.ID, *e.SeriesID)
+			assert.Equal(t, e.StartTime, *e.OccurrenceStart)
+			assert.Equal(t, "Weekly practice", e.Title)
+		}
+		assert.Equal(t, now.Add(3*7*24*time.Hour), *series.MaterializedUntil)
+	})
+
+	t.Run("CreateInvalid", func(t *testing.T) {
+		tests := map[string]func(*domain.EventSeries){
+			"Rule":     func(s *domain.EventSeries) { s.RRule = "FREQ=DAILY" },
+			"Timezone": func(s *domain.EventSeries) { s.Timezone = "Mars/Olympus" },
+			"NoZone":   func(s *domain.EventSeries) { s.Timezone = "" },
+		}
+		for name, mutate := range tests {
+			t.Run(name, func(t *testing.T) {
+				repo := &stubEventSeriesRepo{}
+				series := newSeries()
+				mutate(series)
+
+				err := newService(repo).Create(context.Background(), series, uuid.New())
+
+				assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+				assert.Nil(t, repo.created)
+			})
+		}
+	})
+
+	t.Run("UpdateRemovesDroppedOccurrences", func(t *testing.T) {
+		repo := &stubEventSeriesRepo{}
+		series := newSeries()
+		series.ID = uuid.New()
+		until := now.Add(3 * 7 * 24 * time.Hour)
+		series.MaterializedUntil = &until
+
+		updated := *series
+		updated.Title = "Biweekly practice"
+		updated.RRule = "FREQ=WEEKLY;INTERVAL=2"
+		require.NoError(t, newService(repo).Update(context.Background(), series, &updated))
+
+		// From April 1st every other week meets on May 13th, 27th...
+		assert.Equal(t, []time.Time{tuesday(6), tuesday(20)}, repo.removed)
+		assert.Equal(t, now, repo.from)
+		assert.Equal(t, &updated, repo.updated)
+		assert.Equal(t, []time.Time{tuesday(13)}, starts(repo.occurrences))
+		assert.Equal(t, "Biweekly practice", repo.occurrences[0].Title)
+	})
+
+	t.Run("UpdateExDate", func(t *testing.T) {
+		repo := &stubEventSeriesRepo{}
+		series := newSeries()
+		until := now.Add(3 * 7 * 24 * time.Hour)
+		series.MaterializedUntil = &until
+
+		updated := *series
+		updated.ExDates = []time.Time{tuesday(13)}
+		require.NoError(t, newService(repo).Update(context.Background(), series, &updated))
+
+		assert.Equal(t, []time.Time{tuesday(13)}, repo.removed)
+	})
+
+	t.Run("UpdateInvalid", func(t *testing.T) {
+		repo := &stubEventSeriesRepo{}
+		series := newSeries()
+		updated := *series
+		updated.RRule = "FREQ=WEEKLY;BYDAY=MO"
+
+		err := newService(repo).Update(context.Background(), series, &updated)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+		assert.Nil(t, repo.updated)
+	})
+
+	t.Run("MaterializeDue", func(t *testing.T) {
+		failing := newSeries()
+		failing.ID = uuid.New()
+		due := newSeries()
+		due.ID = uuid.New()
+		until := now.Add(14 * 24 * time.Hour)
+		due.MaterializedUntil = &until
+		repo := &stubEventSeriesRepo{due: []*domain.EventSeries{failing, due}, failFor: failing.ID}
+
+		err := newService(repo).MaterializeDue(context.Background())
+
+		assert.ErrorContains(t, err, failing.ID.String())
+		// Only the part beyond the previous run is materialized
+		assert.Equal(t, []time.Time{tuesday(20)}, starts(repo.occurrences))
+		assert.Equal(t, now.Add(3*7*24*time.Hour), repo.until)
+	})
+}
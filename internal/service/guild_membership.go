@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// DefaultInviteTTL is how long an invite stays valid when no expiry is given.
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+// GuildMembershipService decides who may join or leave a guild. Users join
+// through invites or approved join requests; leaving keeps the membership
+// record with LeftAt set.
+type GuildMembershipService struct {
+	membershipRepo    domain.GuildMembershipRepository
+	guildAttendeeRepo domain.GuildAttendeeRepository
+	now               func() time.Time
+}
+
+func NewGuildMembershipService(membershipRepo domain.GuildMembershipRepository, guildAttendeeRepo domain.GuildAttendeeRepository) *GuildMembershipService {
+	return &GuildMembershipService{
+		membershipRepo:    membershipRepo,
+		guildAttendeeRepo: guildAttendeeRepo,
+		now:               time.Now,
+	}
+}
+
+// CreateInvite generates the invite's code and stores it. Without ExpiresAt
+// the invite expires after DefaultInviteTTL.
+func (s *GuildMembershipService) CreateInvite(ctx context.Context, invite *domain.GuildInvite) error {
+	code, err := newInviteCode()
+	if err != nil {
+		return err
+	}
+	invite.Code = code
+	invite.Uses = 0
+	invite.RevokedAt = nil
+	if invite.ExpiresAt == nil {
+		expiresAt := s.now().Add(DefaultInviteTTL)
+		invite.ExpiresAt = &expiresAt
+	}
+	return s.membershipRepo.CreateInvite(ctx, invite)
+}
+
+// RevokeInvite revokes an invite of the guild. Invites of other guilds are
+// reported as not found.
+func (s *GuildMembershipService) RevokeInvite(ctx context.Context, guildID, inviteID uuid.UUID) error {
+	invite, err := s.membershipRepo.GetInviteByID(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.GuildID != guildID {
+		return domain.ErrNotFound
+	}
+	return s.membershipRepo.RevokeInvite(ctx, inviteID, s.now())
+}
+
+// AcceptInvite joins userID to the invite's guild as a member.
+func (s *GuildMembershipService) AcceptInvite(ctx context.Context, code string, userID uuid.UUID) (*domain.GuildAttendee, error) {
+	invite, err := s.membershipRepo.GetInviteByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if !invite.Usable(now) {
+		return nil, domain.ErrInviteUnavailable
+	}
+	// Checked up front so members do not use up an invite
+	if err := s.ensureNotMember(ctx, invite.GuildID, userID); err != nil {
+		return nil, err
+	}
+	return s.membershipRepo.RedeemInvite(ctx, invite.ID, userID, now)
+}
+
+// RequestToJoin files a pending join request. A second pending request for
+// the same guild fails with ErrDuplicateEntry.
+func (s *GuildMembershipService) RequestToJoin(ctx context.Context, guildID, userID uuid.UUID, message string) (*domain.GuildJoinRequest, error) {
+	if err := s.ensureNotMember(ctx, guildID, userID); err != nil {
+		return nil, err
+	}
+	request := &domain.GuildJoinRequest{
+		GuildID: guildID,
+		UserID:  userID,
+		Status:  domain.GuildJoinRequestStatusPending,
+		Message: message,
+	}
+	if err := s.membershipRepo.CreateJoinRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ResolveJoinRequest approves or rejects a pending request of the guild. An
+// approved request returns the new membership.
+func (s *GuildMembershipService) ResolveJoinRequest(ctx context.Context, guildID, requestID, reviewerID uuid.UUID, approve bool) (*domain.GuildJoinRequest, *domain.GuildAttendee, error) {
+	request, err := s.membershipRepo.GetJoinRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.GuildID != guildID {
+		return nil, nil, domain.ErrNotFound
+	}
+	if request.Status != domain.GuildJoinRequestStatusPending {
+		return nil, nil, domain.ErrRequestResolved
+	}
+
+	now := s.now()
+	request.Status = domain.GuildJoinRequestStatusRejected
+	if approve {
+		request.Status = domain.GuildJoinRequestStatusApproved
+	}
+	request.ReviewedBy = &reviewerID
+	request.ReviewedAt = &now
+	attendee, err := s.membershipRepo.ResolveJoinRequest(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	return request, attendee, nil
+}
+
+// Leave ends a membership, whether the member leaves or a master removes
+// them. The last master cannot leave.
+func (s *GuildMembershipService) Leave(ctx context.Context, attendeeID uuid.UUID) error {
+	return s.membershipRepo.Leave(ctx, attendeeID, s.now())
+}
+
+// ChangeRole promotes or demotes an active member. The last master cannot
+// be demoted.
+func (s *GuildMembershipService) ChangeRole(ctx context.Context, attendeeID uuid.UUID, role domain.GuildAttendeeRole) error {
+	return s.membershipRepo.ChangeRole(ctx, attendeeID, role)
+}
+
+func (s *GuildMembershipService) ensureNotMember(ctx context.Context, guildID, userID uuid.UUID) error {
+	attendee, err := s.guildAttendeeRepo.GetByGuildAndUser(ctx, guildID, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if attendee.LeftAt == nil {
+		return domain.ErrAlreadyMember
+	}
+	return nil
+}
+
+// newInviteCode returns a random URL-safe code of 12 characters.
+func newInviteCode() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
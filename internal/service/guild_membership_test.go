@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubGuildMembershipRepo struct {
+	domain.GuildMembershipRepository
+	invite   *domain.GuildInvite
+	request  *domain.GuildJoinRequest
+	created  *domain.GuildInvite
+	redeemed bool
+	resolved *domain.GuildJoinRequest
+}
+
+func (r *stubGuildMembershipRepo) CreateInvite(_ context.Context, invite *domain.GuildInvite) error {
+	r.created = invite
+	return nil
+}
+
+func (r *stubGuildMembershipRepo) GetInviteByID(context.Context, uuid.UUID) (*domain.GuildInvite, error) {
+	return r.invite, nil
+}
+
+func (r *stubGuildMembershipRepo) GetInviteByCode(context.Context, string) (*domain.GuildInvite, error) {
+	return r.invite, nil
+}
+
+func (r *stubGuildMembershipRepo) RedeemInvite(_ context.Context, _, userID uuid.UUID, _ time.Time) (*domain.GuildAttendee, error) {
+	r.redeemed = true
+	return &domain.GuildAttendee{GuildID: r.invite.GuildID, UserID: userID, Role: domain.GuildAttendeeRoleMember}, nil
+}
+
+func (r *stubGuildMembershipRepo) GetJoinRequestByID(context.Context, uuid.UUID) (*domain.GuildJoinRequest, error) {
+	return r.request, nil
+}
+
+func (r *stubGuildMembershipRepo) ResolveJoinRequest(_ context.Context, request *domain.GuildJoinRequest) (*domain.GuildAttendee, error) {
+	r.resolved = request
+	return nil, nil
+}
+
+type stubGuildAttendeeRepo struct {
+	domain.GuildAttendeeRepository
+	attendee *domain.GuildAttendee
+}
+
+func (r *stubGuildAttendeeRepo) GetByGuildAndUser(context.Context, uuid.UUID, uuid.UUID) (*domain.GuildAttendee, error) {
+	if r.attendee == nil {
+		return nil, domain.ErrNotFound
+	}
+	return r.attendee, nil
+}
+
+func TestGuildMembershipService_CreateInvite(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &stubGuildMembershipRepo{}
+	s := NewGuildMembershipService(repo, &stubGuildAttendeeRepo{})
+	s.now = func() time.Time { return now }
+
+	err := s.CreateInvite(context.Background(), &domain.GuildInvite{GuildID: uuid.New(), Uses: 3})
+
+	require.NoError(t, err)
+	assert.Len(t, repo.created.Code, 12)
+	assert.Equal(t, 0, repo.created.Uses)
+	assert.Equal(t, now.Add(DefaultInviteTTL), *repo.created.ExpiresAt)
+}
+
+func TestGuildMembershipService_AcceptInvite(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	guildID, userID := uuid.New(), uuid.New()
+	maxUses := 2
+	left := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		invite   domain.GuildInvite
+		attendee *domain.GuildAttendee
+		wantErr  error
+	}{
+		{name: "Usable", invite: domain.GuildInvite{MaxUses: &maxUses, Uses: 1}},
+		{name: "Expired", invite: domain.GuildInvite{ExpiresAt: &now}, wantErr: domain.ErrInviteUnavailable},
+		{name: "UsedUp", invite: domain.GuildInvite{MaxUses: &maxUses, Uses: 2}, wantErr: domain.ErrInviteUnavailable},
+		{name: "Revoked", invite: domain.GuildInvite{RevokedAt: &left}, wantErr: domain.ErrInviteUnavailable},
+		{name: "Member", attendee: &domain.GuildAttendee{UserID: userID}, wantErr: domain.ErrAlreadyMember},
+		{name: "FormerMember", attendee: &domain.GuildAttendee{UserID: userID, LeftAt: &left}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite := tt.invite
+			invite.GuildID = guildID
+			repo := &stubGuildMembershipRepo{invite: &invite}
+			s := NewGuildMembershipService(repo, &stubGuildAttendeeRepo{attendee: tt.attendee})
+			s.now = func() time.Time { return now }
+
+			attendee, err := s.AcceptInvite(context.Background(), "code", userID)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.False(t, repo.redeemed)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, repo.redeemed)
+			assert.Equal(t, guildID, attendee.GuildID)
+		})
+	}
+}
+
+func TestGuildMembershipService_ResolveJoinRequest(t *testing.T) {
+	guildID, reviewerID := uuid.New(), uuid.New()
+
+	t.Run("Approve", func(t *testing.T) {
+		repo := &stubGuildMembershipRepo{request: &domain.GuildJoinRequest{ID: uuid.New(), GuildID: guildID, Status: domain.GuildJoinRequestStatusPending}}
+		s := NewGuildMembershipService(repo, &stubGuildAttendeeRepo{})
+
+		request, _, err := s.ResolveJoinRequest(context.Background(), guildID, repo.request.ID, reviewerID, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.GuildJoinRequestStatusApproved, request.Status)
+		assert.Equal(t, reviewerID, *repo.resolved.ReviewedBy)
+		assert.NotNil(t, repo.resolved.ReviewedAt)
+	})
+
+	t.Run("OtherGuild", func(t *testing.T) {
+		repo := &stubGuildMembershipRepo{request: &domain.GuildJoinRequest{ID: uuid.New(), GuildID: uuid.New(), Status: domain.GuildJoinRequestStatusPending}}
+		s := NewGuildMembershipService(repo, &stubGuildAttendeeRepo{})
+
+		_, _, err := s.ResolveJoinRequest(context.Background(), guildID, repo.request.ID, reviewerID, true)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, repo.resolved)
+	})
+
+	t.Run("Resolved", func(t *testing.T) {
+		repo := &stubGuildMembershipRepo{request: &domain.GuildJoinRequest{ID: uuid.New(), GuildID: guildID, Status: domain.GuildJoinRequestStatusRejected}}
+		s := NewGuildMembershipService(repo, &stubGuildAttendeeRepo{})
+
+		_, _, err := s.ResolveJoinRequest(context.Background(), guildID, repo.request.ID, reviewerID, true)
+
+		assert.ErrorIs(t, err, domain.ErrRequestResolved)
+		assert.Nil(t, repo.resolved)
+	})
+}
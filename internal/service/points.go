@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// PointRules sets how many points each reason is worth. A zero amount turns
+// the reason off.
+type PointRules struct {
+	// AttendedEvent is earned by everyone who joined the event
+	AttendedEvent int
+	// Emcee is earned on top of AttendedEvent by the event's emcees
+	Emcee int
+	// MistakeReviewed is earned per own mistake of a review event
+	MistakeReviewed int
+	// StreakBonus is earned for every StreakLength on time arrivals in a row
+	StreakBonus  int
+	StreakLength int
+}
+
+// DefaultPointRules are used unless configured otherwise.
+var DefaultPointRules = PointRules{
+	AttendedEvent:   10,
+	Emcee:           5,
+	MistakeReviewed: 2,
+	StreakBonus:     20,
+	StreakLength:    5,
+}
+
+// DefaultLevelCurves are used unless LEVEL_CURVE / GUILD_LEVEL_CURVE are set.
+var DefaultLevelCurves = domain.LevelCurves{
+	User:  domain.LevelCurve{100, 300, 600, 1000, 1500, 2100, 2800, 3600, 4500},
+	Guild: domain.LevelCurve{1000, 3000, 6000, 10000, 15000, 21000, 28000},
+}
+
+// PointsService awards points for closed events. Points only ever enter
+// User.Points through the ledger, so totals can be rebuilt from it.
+type PointsService struct {
+	pointRepo         domain.PointRepository
+	eventAttendeeRepo domain.EventAttendeeRepository
+	mistakeRepo       domain.MistakeRepository
+	attendanceRepo    domain.AttendanceRepository
+	rules             PointRules
+	curves            domain.LevelCurves
+}
+
+func NewPointsService(pointRepo domain.PointRepository, eventAttendeeRepo domain.EventAttendeeRepository, mistakeRepo domain.MistakeRepository, attendanceRepo domain.AttendanceRepository, rules PointRules, curves domain.LevelCurves) *PointsService {
+	return &PointsService{
+		pointRepo:         pointRepo,
+		eventAttendeeRepo: eventAttendeeRepo,
+		mistakeRepo:       mistakeRepo,
+		attendanceRepo:    attendanceRepo,
+		rules:             rules,
+		curves:            curves,
+	}
+}
+
+// AwardEvent awards the points earned at an event whose punctuality was
+// settled by PunctualityService.CloseEvent. Absent attendees earn nothing.
+// Awarding an event again is a no-op.
+func (s *PointsService) AwardEvent(ctx context.Context, event *domain.Event) error {
+	attendees, err := s.eventAttendeeRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	reviewed := make(map[uuid.UUID]int)
+	if event.Mode == domain.EventModeReview && s.rules.MistakeReviewed != 0 {
+		mistakes, err := s.mistakeRepo.GetByEventID(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		for _, mistake := range mistakes {
+			reviewed[mistake.UserID]++
+		}
+	}
+
+	var entries []*domain.PointTransaction
+	add := func(attendee *domain.EventAttendee, reason domain.PointReason, amount int) {
+		if amount == 0 {
+			return
+		}
+		entries = append(entries, &domain.PointTransaction{
+			UserID:  attendee.UserID,
+			EventID: &event.ID,
+			Reason:  reason,
+			Amount:  amount,
+		})
+	}
+	for _, attendee := range attendees {
+		if attendee.Punctuality == nil || *attendee.Punctuality == domain.PunctualityAbsent {
+			continue
+		}
+		add(attendee, domain.PointReasonAttendedEvent, s.rules.AttendedEvent)
+		if attendee.Role == domain.EventAttendeeRoleEmcee {
+			add(attendee, domain.PointReasonEmcee, s.rules.Emcee)
+		}
+		add(attendee, domain.PointReasonMistakeReviewed, s.rules.MistakeReviewed*reviewed[attendee.UserID])
+		if *attendee.Punctuality == domain.PunctualityOnTime {
+			earned, err := s.earnedStreakBonus(ctx, event, attendee)
+			if err != nil {
+				return err
+			}
+			if earned {
+				add(attendee, domain.PointReasonStreakBonus, s.rules.StreakBonus)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return s.pointRepo.Award(ctx, entries, s.curves)
+}
+
+// earnedStreakBonus reports whether the attendee's latest StreakLength
+// arrivals were all on time and none of the earlier ones already paid a
+// bonus, so the bonus is paid once every StreakLength arrivals.
+func (s *PointsService) earnedStreakBonus(ctx context.Context, event *domain.Event, attendee *domain.EventAttendee) (bool, error) {
+	if s.rules.StreakBonus == 0 || s.rules.StreakLength <= 0 {
+		return false, nil
+	}
+	page, err := s.attendanceRepo.ListRecordsByUserID(ctx, attendee.UserID, domain.ListOptions{Limit: s.rules.StreakLength})
+	if err != nil {
+		return false, err
+	}
+	if len(page.Items) < s.rules.StreakLength {
+		return false, nil
+	}
+
+	earlier := make([]uuid.UUID, 0, len(page.Items))
+	for _, record := range page.Items {
+		if record.Punctuality != domain.PunctualityOnTime {
+			return false, nil
+		}
+		if record.EventID != event.ID {
+			earlier = append(earlier, record.EventID)
+		}
+	}
+	paid, err := s.pointRepo.HasReason(ctx, attendee.UserID, domain.PointReasonStreakBonus, earlier)
+	if err != nil {
+		return false, err
+	}
+	return !paid, nil
+}
+
+// Recompute rebuilds all points and levels from the ledger, e.g. after the
+// level curves changed.
+func (s *PointsService) Recompute(ctx context.Context) error {
+	return s.pointRepo.Recompute(ctx, s.curves)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPointRepo struct {
+	domain.PointRepository
+	awarded    []*domain.PointTransaction
+	curves     domain.LevelCurves
+	streakPaid bool
+}
+
+func (r *stubPointRepo) Award(_ context.Context, entries []*domain.PointTransaction, curves domain.LevelCurves) error {
+	r.awarded, r.curves = entries, curves
+	return nil
+}
+
+func (r *stubPointRepo) HasReason(context.Context, uuid.UUID, domain.PointReason, []uuid.UUID) (bool, error) {
+	return r.streakPaid, nil
+}
+
+type stubMistakeRepo struct {
+	domain.MistakeRepository
+	mistakes []*domain.Mistake
+}
+
+func (r *stubMistakeRepo) GetByEventID(context.Context, uuid.UUID) ([]*domain.Mistake, error) {
+	return r.mistakes, nil
+}
+
+type stubAttendanceRepo struct {
+	domain.AttendanceRepository
+	records map[uuid.UUID][]*domain.AttendanceRecord
+}
+
+func (r *stubAttendanceRepo) ListRecordsByUserID(_ context.Context, userID uuid.UUID, opts domain.ListOptions) (*domain.Page[*domain.AttendanceRecord], error) {
+	records := r.records[userID]
+	if len(records) > opts.Limit {
+		records = records[:opts.Limit]
+	}
+	return &domain.Page[*domain.AttendanceRecord]{Items: records}, nil
+}
+
+func punctuality(p domain.Punctuality) *domain.Punctuality { return &p }
+
+// streak returns n on time records, the latest being eventID.
+func streak(eventID uuid.UUID, n int) []*domain.AttendanceRecord {
+	records := []*domain.AttendanceRecord{{EventID: eventID, Punctuality: domain.PunctualityOnTime}}
+	for len(records) < n {
+		records = append(records, &domain.AttendanceRecord{EventID: uuid.New(), Punctuality: domain.PunctualityOnTime})
+	}
+	return records
+}
+
+func reasons(entries []*domain.PointTransaction, userID uuid.UUID) map[domain.PointReason]int {
+	got := make(map[domain.PointReason]int)
+	for _, entry := range entries {
+		if entry.UserID == userID {
+			got[entry.Reason] = entry.Amount
+		}
+	}
+	return got
+}
+
+func TestPointsService_AwardEvent(t *testing.T) {
+	rules := PointRules{AttendedEvent: 10, Emcee: 5, MistakeReviewed: 2, StreakBonus: 20, StreakLength: 3}
+	emcee, member, late, absent := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	event := &domain.Event{ID: uuid.New(), Mode: domain.EventModeReview}
+
+	newService := func(points *stubPointRepo, records map[uuid.UUID][]*domain.AttendanceRecord) *PointsService {
+		attendees := &stubEventAttendeeRepo{attendees: []*domain.EventAttendee{
+			{UserID: emcee, Role: domain.EventAttendeeRoleEmcee, Punctuality: punctuality(domain.PunctualityOnTime)},
+			{UserID: member, Role: domain.EventAttendeeRoleMember, Punctuality: punctuality(domain.PunctualityOnTime)},
+			{UserID: late, Role: domain.EventAttendeeRoleMember, Punctuality: punctuality(domain.PunctualityLate)},
+			{UserID: absent, Role: domain.EventAttendeeRoleMember, Punctuality: punctuality(domain.PunctualityAbsent)},
+		}}
+		mistakes := &stubMistakeRepo{mistakes: []*domain.Mistake{
+			{UserID: member}, {UserID: member}, {UserID: late},
+		}}
+		return NewPointsService(points, attendees, mistakes, &stubAttendanceRepo{records: records}, rules, DefaultLevelCurves)
+	}
+
+	t.Run("Reasons", func(t *testing.T) {
+		points := &stubPointRepo{}
+		s := newService(points, map[uuid.UUID][]*domain.AttendanceRecord{
+			emcee:  streak(event.ID, 3),
+			member: streak(event.ID, 2),
+		})
+
+		require.NoError(t, s.AwardEvent(context.Background(), event))
+
+		assert.Equal(t, map[domain.PointReason]int{
+			domain.PointReasonAttendedEvent: 10,
+			domain.PointReasonEmcee:         5,
+			domain.PointReasonStreakBonus:   20,
+		}, reasons(points.awarded, emcee))
+		assert.Equal(t, map[domain.PointReason]int{
+			domain.PointReasonAttendedEvent:   10,
+			domain.PointReasonMistakeReviewed: 4,
+		}, reasons(points.awarded, member))
+		assert.Equal(t, map[domain.PointReason]int{
+			domain.PointReasonAttendedEvent:   10,
+			domain.PointReasonMistakeReviewed: 2,
+		}, reasons(points.awarded, late))
+		assert.Empty(t, reasons(points.awarded, absent))
+		assert.Equal(t, DefaultLevelCurves, points.curves)
+		for _, entry := range points.awarded {
+			assert.Equal(t, event.ID, *entry.EventID)
+		}
+	})
+
+	t.Run("StreakAlreadyPaid", func(t *testing.T) {
+		points := &stubPointRepo{streakPaid: true}
+		s := newService(points, map[uuid.UUID][]*domain.AttendanceRecord{
+			emcee: streak(event.ID, 3),
+		})
+
+		require.NoError(t, s.AwardEvent(context.Background(), event))
+
+		assert.NotContains(t, reasons(points.awarded, emcee), domain.PointReasonStreakBonus)
+	})
+
+	t.Run("MistakesOnlyInReview", func(t *testing.T) {
+		points := &stubPointRepo{}
+		s := newService(points, nil)
+
+		require.NoError(t, s.AwardEvent(context.Background(), &domain.Event{ID: event.ID, Mode: domain.EventModeConversation}))
+
+		assert.NotContains(t, reasons(points.awarded, member), domain.PointReasonMistakeReviewed)
+	})
+}
+
+func TestLevelCurve_Level(t *testing.T) {
+	curve := domain.LevelCurve{100, 300}
+
+	assert.Equal(t, 0, curve.Level(99))
+	assert.Equal(t, 1, curve.Level(100))
+	assert.Equal(t, 2, curve.Level(5000))
+	assert.Equal(t, 0, domain.LevelCurve(nil).Level(5000))
+}
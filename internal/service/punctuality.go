@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+// DefaultLateGrace is how long after Event.StartTime an attendee may join and
+// still count as on time, unless configured otherwise.
+const DefaultLateGrace = 5 * time.Minute
+
+// PunctualityService decides whether attendees arrived on time and keeps
+// User.LateStreak up to date when an event closes.
+type PunctualityService struct {
+	eventRepo         domain.EventRepository
+	eventAttendeeRepo domain.EventAttendeeRepository
+	grace             time.Duration
+}
+
+func NewPunctualityService(eventRepo domain.EventRepository, eventAttendeeRepo domain.EventAttendeeRepository, grace time.Duration) *PunctualityService {
+	return &PunctualityService{
+		eventRepo:         eventRepo,
+		eventAttendeeRepo: eventAttendeeRepo,
+		grace:             grace,
+	}
+}
+
+// Classify compares the attendee's first join against the event start.
+// Attendees that never joined are absent, which breaks a streak of
+// punctual arrivals just like being late.
+func (s *PunctualityService) Classify(event *domain.Event, attendee *domain.EventAttendee) domain.Punctuality {
+	switch {
+	case attendee.JoinedAt == nil:
+		return domain.PunctualityAbsent
+	case attendee.JoinedAt.After(event.StartTime.Add(s.grace)):
+		return domain.PunctualityLate
+	default:
+		return domain.PunctualityOnTime
+	}
+}
+
+// CloseEvent saves the ended event together with the punctuality of every
+// attendee. Late and absent attendees get their LateStreak incremented, on
+// time attendees get it reset, all in one transaction.
+func (s *PunctualityService) CloseEvent(ctx context.Context, event *domain.Event) error {
+	attendees, err := s.eventAttendeeRepo.GetByEventID(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	arrivals := make([]domain.Arrival, 0, len(attendees))
+	for _, attendee := range attendees {
+		arrivals = append(arrivals, domain.Arrival{
+			UserID:      attendee.UserID,
+			Punctuality: s.Classify(event, attendee),
+		})
+	}
+	return s.eventRepo.Close(ctx, event, arrivals)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEventRepo struct {
+	domain.EventRepository
+	closed   *domain.Event
+	arrivals []domain.Arrival
+}
+
+func (r *stubEventRepo) Close(_ context.Context, event *domain.Event, arrivals []domain.Arrival) error {
+	r.closed, r.arrivals = event, arrivals
+	return nil
+}
+
+type stubEventAttendeeRepo struct {
+	domain.EventAttendeeRepository
+	attendees []*domain.EventAttendee
+}
+
+func (r *stubEventAttendeeRepo) GetByEventID(context.Context, uuid.UUID) ([]*domain.EventAttendee, error) {
+	return r.attendees, nil
+}
+
+func at(t time.Time) *time.Time { return &t }
+
+func TestPunctualityService_Classify(t *testing.T) {
+	start := time.Date(2025, 1, 7, 19, 0, 0, 0, time.UTC)
+	event := &domain.Event{StartTime: start}
+	s := NewPunctualityService(nil, nil, 5*time.Minute)
+
+	tests := map[string]struct {
+		joinedAt *time.Time
+		want     domain.Punctuality
+	}{
+		"Early":       {joinedAt: at(start.Add(-10 * time.Minute)), want: domain.PunctualityOnTime},
+		"WithinGrace": {joinedAt: at(start.Add(5 * time.Minute)), want: domain.PunctualityOnTime},
+		"AfterGrace":  {joinedAt: at(start.Add(5*time.Minute + time.Second)), want: domain.PunctualityLate},
+		"NeverJoined": {joinedAt: nil, want: domain.PunctualityAbsent},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := s.Classify(event, &domain.EventAttendee{JoinedAt: tt.joinedAt})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPunctualityService_CloseEvent(t *testing.T) {
+	start := time.Date(2025, 1, 7, 19, 0, 0, 0, time.UTC)
+	event := &domain.Event{ID: uuid.New(), StartTime: start}
+	onTime, late, absent := uuid.New(), uuid.New(), uuid.New()
+
+	events := &stubEventRepo{}
+	attendees := &stubEventAttendeeRepo{attendees: []*domain.EventAttendee{
+		{UserID: onTime, JoinedAt: at(start)},
+		{UserID: late, JoinedAt: at(start.Add(time.Hour))},
+		{UserID: absent},
+	}}
+	s := NewPunctualityService(events, attendees, DefaultLateGrace)
+
+	require.NoError(t, s.CloseEvent(context.Background(), event))
+
+	assert.Same(t, event, events.closed)
+	assert.Equal(t, []domain.Arrival{
+		{UserID: onTime, Punctuality: domain.PunctualityOnTime},
+		{UserID: late, Punctuality: domain.PunctualityLate},
+		{UserID: absent, Punctuality: domain.PunctualityAbsent},
+	}, events.arrivals)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+)
+
+// Recurrence frequencies supported in RRULEs.
+const (
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// Recurrence is the supported subset of an RFC 5545 RRULE: FREQ=WEEKLY or
+// FREQ=MONTHLY, an optional INTERVAL (FREQ=WEEKLY;INTERVAL=2 is biweekly)
+// and at most one of UNTIL and COUNT.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	// Until is the last moment an occurrence may start, or nil
+	Until *time.Time
+	// Count is the number of occurrences, or 0 for no limit
+	Count int
+}
+
+// ParseRecurrence parses rule such as "FREQ=WEEKLY;INTERVAL=2;COUNT=10". A
+// date-only UNTIL (20250630) includes that whole day in loc.
+func ParseRecurrence(rule string, loc *time.Location) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("%w: rrule is required", domain.ErrInvalidRecurrence)
+	}
+
+	r := &Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", domain.ErrInvalidRecurrence, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate %s", domain.ErrInvalidRecurrence, name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %s", domain.ErrInvalidRecurrence, value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", domain.ErrInvalidRecurrence)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", domain.ErrInvalidRecurrence)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must look like 20250630 or 20250630T150000Z", domain.ErrInvalidRecurrence)
+			}
+			r.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", domain.ErrInvalidRecurrence, name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", domain.ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("%w: UNTIL and COUNT are mutually exclusive", domain.ErrInvalidRecurrence)
+	}
+	return r, nil
+}
+
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// Between returns the start times of the occurrences in [from, to) of the
+// series starting at start. Occurrences keep the wall-clock time of start
+// in loc across daylight saving changes. Monthly occurrences fall on the
+// day of month of start; months without that day are skipped and do not
+// count towards COUNT.
+func (r *Recurrence) Between(start time.Time, loc *time.Location, from, to time.Time) []time.Time {
+	local := start.In(loc)
+	year, month, day := local.Date()
+	hour, minute, sec := local.Clock()
+
+	var out []time.Time
+	for i, n := 0, 0; r.Count == 0 || n < r.Count; i++ {
+		var t time.Time
+		switch r.Freq {
+		case FreqWeekly:
+			t = time.Date(year, month, day+7*r.Interval*i, hour, minute, sec, local.Nanosecond(), loc)
+		case FreqMonthly:
+			t = time.Date(year, month+time.Month(r.Interval*i), day, hour, minute, sec, local.Nanosecond(), loc)
+		default:
+			return out
+		}
+		if (r.Until != nil && t.After(*r.Until)) || !t.Before(to) {
+			return out
+		}
+		// time.Date normalized a day the month does not have
+		if r.Freq == FreqMonthly && t.Day() != day {
+			continue
+		}
+		n++
+		if !t.Before(from) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
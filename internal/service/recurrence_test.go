@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"jpcorrect-backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+	until := time.Date(2025, 6, 30, 15, 0, 0, 0, time.UTC)
+	endOfDay := time.Date(2025, 7, 1, 0, 0, 0, 0, taipei).Add(-time.Nanosecond)
+
+	tests := []struct {
+		rule    string
+		want    *Recurrence
+		wantErr bool
+	}{
+		{rule: "FREQ=WEEKLY", want: &Recurrence{Freq: FreqWeekly, Interval: 1}},
+		{rule: "RRULE:freq=weekly;interval=2;count=10", want: &Recurrence{Freq: FreqWeekly, Interval: 2, Count: 10}},
+		{rule: "FREQ=MONTHLY;UNTIL=20250630T150000Z", want: &Recurrence{Freq: FreqMonthly, Interval: 1, Until: &until}},
+		{rule: "FREQ=MONTHLY;UNTIL=20250630", want: &Recurrence{Freq: FreqMonthly, Interval: 1, Until: &endOfDay}},
+		{rule: "", wantErr: true},
+		{rule: "FREQ=DAILY", wantErr: true},
+		{rule: "INTERVAL=2", wantErr: true},
+		{rule: "FREQ=WEEKLY;INTERVAL=0", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT=-1", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT=3;UNTIL=20250630", wantErr: true},
+		{rule: "FREQ=WEEKLY;UNTIL=June", wantErr: true},
+		{rule: "FREQ=WEEKLY;BYDAY=TU", wantErr: true},
+		{rule: "FREQ=WEEKLY;FREQ=MONTHLY", wantErr: true},
+		{rule: "FREQ=WEEKLY;COUNT", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.rule, taipei)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Freq, got.Freq)
+			assert.Equal(t, tt.want.Interval, got.Interval)
+			assert.Equal(t, tt.want.Count, got.Count)
+			if tt.want.Until == nil {
+				assert.Nil(t, got.Until)
+			} else {
+				assert.True(t, tt.want.Until.Equal(*got.Until), "until %s, want %s", got.Until, tt.want.Until)
+			}
+		})
+	}
+}
+
+func TestRecurrence_Between(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// A Tuesday, 19:00 in New York
+	start := time.Date(2025, 3, 4, 19, 0, 0, 0, newYork)
+	far := start.AddDate(2, 0, 0)
+
+	parse := func(t *testing.T, rule string) *Recurrence {
+		t.Helper()
+		r, err := ParseRecurrence(rule, newYork)
+		require.NoError(t, err)
+		return r
+	}
+	dates := func(times []time.Time) []string {
+		out := make([]string, len(times))
+		for i, tm := range times {
+			out[i] = tm.In(newYork).Format("2006-01-02 15:04")
+		}
+		return out
+	}
+
+	t.Run("WeeklyKeepsWallClockAcrossDST", func(t *testing.T) {
+		got := parse(t, "FREQ=WEEKLY;COUNT=3").Between(start, newYork, start, far)
+
+		// Daylight saving time starts on 2025-03-09
+		assert.Equal(t, []string{"2025-03-04 19:00", "2025-03-11 19:00", "2025-03-18 19:00"}, dates(got))
+		assert.Equal(t, 0, got[0].UTC().Hour())
+		assert.Equal(t, 23, got[1].UTC().Hour())
+	})
+
+	t.Run("Biweekly", func(t *testing.T) {
+		got := parse(t, "FREQ=WEEKLY;INTERVAL=2;COUNT=3").Between(start, newYork, start, far)
+
+		assert.Equal(t, []string{"2025-03-04 19:00", "2025-03-18 19:00", "2025-04-01 19:00"}, dates(got))
+	})
+
+	t.Run("MonthlySkipsShortMonths", func(t *testing.T) {
+		jan31 := time.Date(2025, 1, 31, 19, 0, 0, 0, newYork)
+		got := parse(t, "FREQ=MONTHLY;COUNT=4").Between(jan31, newYork, jan31, far)
+
+		assert.Equal(t, []string{"2025-01-31 19:00", "2025-03-31 19:00", "2025-05-31 19:00", "2025-07-31 19:00"}, dates(got))
+	})
+
+	t.Run("UntilIncludesWholeDay", func(t *testing.T) {
+		got := parse(t, "FREQ=WEEKLY;UNTIL=20250318").Between(start, newYork, start, far)
+
+		assert.Equal(t, []string{"2025-03-04 19:00", "2025-03-11 19:00", "2025-03-18 19:00"}, dates(got))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		from := time.Date(2025, 3, 10, 0, 0, 0, 0, newYork)
+		to := time.Date(2025, 3, 25, 19, 0, 0, 0, newYork)
+		got := parse(t, "FREQ=WEEKLY").Between(start, newYork, from, to)
+
+		// to is exclusive
+		assert.Equal(t, []string{"2025-03-11 19:00", "2025-03-18 19:00"}, dates(got))
+	})
+
+	t.Run("CountIncludesOccurrencesBeforeWindow", func(t *testing.T) {
+		from := time.Date(2025, 3, 10, 0, 0, 0, 0, newYork)
+		got := parse(t, "FREQ=WEEKLY;COUNT=2").Between(start, newYork, from, far)
+
+		assert.Equal(t, []string{"2025-03-11 19:00"}, dates(got))
+	})
+}